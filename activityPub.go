@@ -33,20 +33,22 @@ func (a *goBlog) initActivityPub() error {
 	}
 	// Add hooks
 	a.pPostHooks = append(a.pPostHooks, func(p *post) {
-		if p.isPublishedSectionPost() {
+		if p.isPublishedSectionPost() && a.syndicateTo(p, syndicationActivityPub) {
 			a.apPost(p)
 		}
 	})
 	a.pUpdateHooks = append(a.pUpdateHooks, func(p *post) {
-		if p.isPublishedSectionPost() {
+		if p.isPublishedSectionPost() && a.syndicateTo(p, syndicationActivityPub) {
 			a.apUpdate(p)
 		}
 	})
 	a.pDeleteHooks = append(a.pDeleteHooks, func(p *post) {
-		a.apDelete(p)
+		if a.syndicateTo(p, syndicationActivityPub) {
+			a.apDelete(p)
+		}
 	})
 	a.pUndeleteHooks = append(a.pUndeleteHooks, func(p *post) {
-		if p.isPublishedSectionPost() {
+		if p.isPublishedSectionPost() && a.syndicateTo(p, syndicationActivityPub) {
 			a.apUndelete(p)
 		}
 	})
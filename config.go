@@ -9,6 +9,7 @@ import (
 
 	"github.com/samber/lo"
 	"github.com/spf13/viper"
+	"golang.org/x/crypto/ssh"
 )
 
 type config struct {
@@ -26,6 +27,7 @@ type config struct {
 	Notifications *configNotifications   `mapstructure:"notifications"`
 	PrivateMode   *configPrivateMode     `mapstructure:"privateMode"`
 	IndexNow      *configIndexNow        `mapstructure:"indexNow"`
+	WebSub        *configWebSub          `mapstructure:"webSub"`
 	EasterEgg     *configEasterEgg       `mapstructure:"easterEgg"`
 	MapTiles      *configMapTiles        `mapstructure:"mapTiles"`
 	TTS           *configTTS             `mapstructure:"tts"`
@@ -85,6 +87,11 @@ type configBlog struct {
 	Taxonomies     []*configTaxonomy         `mapstructure:"taxonomies"`
 	Menus          map[string]*configMenu    `mapstructure:"menus"`
 	Photos         *configPhotos             `mapstructure:"photos"`
+	CustomIndexes  []*configCustomIndex      `mapstructure:"customIndexes"`
+	RelatedPosts   *configRelatedPosts       `mapstructure:"relatedPosts"`
+	Series         *configSeries             `mapstructure:"series"`
+	PostNavigation *configPostNavigation     `mapstructure:"postNavigation"`
+	PostingQueue   *configPostingQueue       `mapstructure:"postingQueue"`
 	Search         *configSearch             `mapstructure:"search"`
 	BlogStats      *configBlogStats          `mapstructure:"blogStats"`
 	Blogroll       *configBlogroll           `mapstructure:"blogroll"`
@@ -96,16 +103,24 @@ type configBlog struct {
 	Map            *configGeoMap             `mapstructure:"map"`
 	Contact        *configContact            `mapstructure:"contact"`
 	Announcement   *configAnnouncement       `mapstructure:"announcement"`
+	Podcast        *configPodcast            `mapstructure:"podcast"`
 }
 
 type configSection struct {
-	Title        string `mapstructure:"title"`
-	Description  string `mapstructure:"description"`
-	PathTemplate string `mapstructure:"pathtemplate"`
-	ShowFull     bool   `mapstructure:"showFull"`
+	Title        string   `mapstructure:"title"`
+	Description  string   `mapstructure:"description"`
+	PathTemplate string   `mapstructure:"pathtemplate"`
+	ShowFull     bool     `mapstructure:"showFull"`
+	QueueSlots   []string `mapstructure:"queueSlots"`
 	Name         string
 }
 
+type configPodcast struct {
+	Image    string `mapstructure:"image"`
+	Category string `mapstructure:"category"`
+	Explicit bool   `mapstructure:"explicit"`
+}
+
 type configTaxonomy struct {
 	Name        string `mapstructure:"name"`
 	Title       string `mapstructure:"title"`
@@ -128,12 +143,53 @@ type configPhotos struct {
 	Description string `mapstructure:"description"`
 }
 
-type configSearch struct {
+type configCustomIndex struct {
+	Path               string              `mapstructure:"path"`
+	Title              string              `mapstructure:"title"`
+	Description        string              `mapstructure:"description"`
+	Sections           []string            `mapstructure:"sections"`
+	ExcludedSections   []string            `mapstructure:"excludedSections"`
+	Taxonomies         map[string][]string `mapstructure:"taxonomies"`
+	TaxonomyMatch      string              `mapstructure:"taxonomyMatch"`
+	ExcludedTaxonomies map[string][]string `mapstructure:"excludedTaxonomies"`
+	Parameters         []string            `mapstructure:"parameters"`
+	ExcludedParameters []string            `mapstructure:"excludedParameters"`
+	Status             []string            `mapstructure:"status"`
+	PublishedAfter     string              `mapstructure:"publishedAfter"`
+	PublishedBefore    string              `mapstructure:"publishedBefore"`
+	Photos             bool                `mapstructure:"photos"`
+}
+
+type configSeries struct {
 	Enabled     bool   `mapstructure:"enabled"`
-	Path        string `mapstructure:"path"`
 	Title       string `mapstructure:"title"`
 	Description string `mapstructure:"description"`
-	Placeholder string `mapstructure:"placeholder"`
+}
+
+type configPostNavigation struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Scope   string `mapstructure:"scope"`
+}
+
+type configPostingQueue struct {
+	Slots []string `mapstructure:"slots"`
+}
+
+type configRelatedPosts struct {
+	Enabled        bool    `mapstructure:"enabled"`
+	Count          int     `mapstructure:"count"`
+	TaxonomyWeight float64 `mapstructure:"taxonomyWeight"`
+	TextWeight     float64 `mapstructure:"textWeight"`
+}
+
+type configSearch struct {
+	Enabled      bool     `mapstructure:"enabled"`
+	Path         string   `mapstructure:"path"`
+	Title        string   `mapstructure:"title"`
+	Description  string   `mapstructure:"description"`
+	Placeholder  string   `mapstructure:"placeholder"`
+	Parameters   []string `mapstructure:"parameters"`
+	Interactions bool     `mapstructure:"interactions"`
 }
 
 type configBlogStats struct {
@@ -244,12 +300,40 @@ type configMicropubMedia struct {
 	FTPAddress  string `mapstructure:"ftpAddress"`
 	FTPUser     string `mapstructure:"ftpUser"`
 	FTPPassword string `mapstructure:"ftpPassword"`
+	// SFTP
+	SFTPAddress  string `mapstructure:"sftpAddress"`
+	SFTPUser     string `mapstructure:"sftpUser"`
+	SFTPPassword string `mapstructure:"sftpPassword"`
+	SFTPKeyFile  string `mapstructure:"sftpKeyFile"`
+	SFTPHostKey  string `mapstructure:"sftpHostKey"`
+	SFTPPath     string `mapstructure:"sftpPath"`
+	// WebDAV
+	WebDAVURL      string `mapstructure:"webdavUrl"`
+	WebDAVUser     string `mapstructure:"webdavUser"`
+	WebDAVPassword string `mapstructure:"webdavPassword"`
+	// S3
+	S3Endpoint         string `mapstructure:"s3Endpoint"`
+	S3Region           string `mapstructure:"s3Region"`
+	S3Bucket           string `mapstructure:"s3Bucket"`
+	S3AccessKey        string `mapstructure:"s3AccessKey"`
+	S3SecretKey        string `mapstructure:"s3SecretKey"`
+	S3PathStyle        bool   `mapstructure:"s3PathStyle"`
+	S3PresignedUploads bool   `mapstructure:"s3PresignedUploads"`
 	// Tinify
 	TinifyKey string `mapstructure:"tinifyKey"`
 	// Cloudflare
 	CloudflareCompressionEnabled bool `mapstructure:"cloudflareCompressionEnabled"`
 	// Local
 	LocalCompressionEnabled bool `mapstructure:"localCompressionEnabled"`
+	// Metadata
+	KeepExif     bool `mapstructure:"keepExif"`
+	ExifLocation bool `mapstructure:"exifLocation"`
+	// Responsive images
+	ImageWidths  []int    `mapstructure:"imageWidths"`
+	ImageFormats []string `mapstructure:"imageFormats"`
+	// Video transcoding
+	FFmpeg       string `mapstructure:"ffmpeg"`
+	VideoHeights []int  `mapstructure:"videoHeights"`
 }
 
 type configRegexRedirect struct {
@@ -291,6 +375,11 @@ type configIndexNow struct {
 	Enabled bool `mapstructure:"enabled"`
 }
 
+type configWebSub struct {
+	Hub  bool     `mapstructure:"hub"`
+	Hubs []string `mapstructure:"hubs"`
+}
+
 type configEasterEgg struct {
 	Enabled bool `mapstructure:"enabled"`
 }
@@ -308,8 +397,20 @@ type configMapTiles struct {
 }
 
 type configTTS struct {
-	Enabled      bool   `mapstructure:"enabled"`
+	Enabled  bool   `mapstructure:"enabled"`
+	Provider string `mapstructure:"provider"`
+	// Google Cloud
 	GoogleAPIKey string `mapstructure:"googleApiKey"`
+	// Local command
+	Command       string `mapstructure:"command"`
+	CommandFormat string `mapstructure:"commandFormat"`
+	Shell         string `mapstructure:"shell"`
+	FFmpeg        string `mapstructure:"ffmpeg"`
+	// OpenAI-compatible API
+	OpenAIURL   string `mapstructure:"openaiUrl"`
+	OpenAIKey   string `mapstructure:"openaiKey"`
+	OpenAIModel string `mapstructure:"openaiModel"`
+	OpenAIVoice string `mapstructure:"openaiVoice"`
 }
 
 type configReactions struct {
@@ -392,8 +493,19 @@ func (a *goBlog) initConfig() error {
 		return errors.New("default blog does not exist")
 	}
 	// Check media storage config
-	if ms := a.cfg.Micropub.MediaStorage; ms != nil && ms.MediaURL != "" {
-		ms.MediaURL = strings.TrimSuffix(ms.MediaURL, "/")
+	if ms := a.cfg.Micropub.MediaStorage; ms != nil {
+		if ms.MediaURL != "" {
+			ms.MediaURL = strings.TrimSuffix(ms.MediaURL, "/")
+		}
+		// SFTP requires the host key to verify the server
+		if ms.SFTPAddress != "" {
+			if ms.SFTPHostKey == "" {
+				return errors.New("sftpHostKey is required for SFTP media storage")
+			}
+			if _, _, _, _, err := ssh.ParseAuthorizedKey([]byte(ms.SFTPHostKey)); err != nil {
+				return errors.New("Invalid SFTP host key: " + err.Error())
+			}
+		}
 	}
 	// Check if webmention receiving is disabled
 	if wm := a.cfg.Webmention; wm != nil && wm.DisableReceiving {
@@ -408,6 +520,14 @@ func (a *goBlog) initConfig() error {
 		for k, s := range blog.Sections {
 			s.Name = k
 		}
+		// Check posting queue slots
+		if err := blog.checkQueueSlots(); err != nil {
+			return err
+		}
+		// Check custom indexes
+		if err := blog.checkCustomIndexes(); err != nil {
+			return err
+		}
 		// Check if language is set
 		if blog.Lang == "" {
 			blog.Lang = "en"
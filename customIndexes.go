@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/araddon/dateparse"
+	"github.com/samber/lo"
+)
+
+// Statuses allowed for custom indexes, they are publicly visible
+var customIndexStatuses = []postStatus{statusPublished, statusUnlisted}
+
+func (bc *configBlog) checkCustomIndexes() error {
+	for _, ci := range bc.CustomIndexes {
+		for _, s := range ci.Status {
+			if !lo.Contains(customIndexStatuses, postStatus(s)) {
+				return errors.New("invalid status for custom index " + ci.Path + ": " + s)
+			}
+		}
+	}
+	return nil
+}
+
+// Filter the posts of an index page using the custom index configuration
+func applyCustomIndex(c *postsRequestConfig, ci *configCustomIndex) {
+	if len(ci.Sections) > 0 {
+		c.sections = ci.Sections
+	}
+	c.sections = lo.Filter(c.sections, func(s string, _ int) bool { return !lo.Contains(ci.ExcludedSections, s) })
+	c.taxonomyValues = ci.Taxonomies
+	c.taxonomyValuesMatchAll = ci.TaxonomyMatch == "all"
+	c.excludedTaxonomyValues = ci.ExcludedTaxonomies
+	c.requiredParameters = ci.Parameters
+	c.excludedParameters = append(c.excludedParameters, ci.ExcludedParameters...)
+	if statusse := lo.Filter(lo.Map(ci.Status, func(s string, _ int) postStatus { return postStatus(s) }), func(s postStatus, _ int) bool {
+		return lo.Contains(customIndexStatuses, s)
+	}); len(statusse) > 0 {
+		c.statusse = statusse
+	}
+	if ci.PublishedAfter != "" {
+		c.publishedAfter = timeNoErr(dateparse.ParseLocal(ci.PublishedAfter))
+	}
+	if ci.PublishedBefore != "" {
+		c.publishedBefore = timeNoErr(dateparse.ParseLocal(ci.PublishedBefore))
+	}
+}
+
+// Paths of the custom indexes that contain the post
+func (a *goBlog) postCustomIndexPaths(p *post) (paths []string) {
+	bc := a.cfg.Blogs[p.Blog]
+	if bc == nil {
+		return nil
+	}
+	for _, ci := range bc.CustomIndexes {
+		if ci.Path == "" {
+			continue
+		}
+		c := &postsRequestConfig{
+			blog:     p.Blog,
+			path:     p.Path,
+			sections: lo.Keys(bc.Sections),
+			statusse: []postStatus{statusPublished},
+		}
+		applyCustomIndex(c, ci)
+		if count, err := a.db.countPosts(c); err == nil && count > 0 {
+			paths = append(paths, bc.getRelativePath(ci.Path))
+		}
+	}
+	return paths
+}
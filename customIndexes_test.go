@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/carlmjohnson/requests"
+	"github.com/mmcdole/gofeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_customIndexes(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	_ = app.initConfig()
+	bc := app.cfg.Blogs["default"]
+	bc.Sections["micro"] = &configSection{Name: "micro", Title: "Micro"}
+	bc.CustomIndexes = []*configCustomIndex{
+		{
+			Path:               "/travel-photos",
+			Title:              "Travel photos",
+			ExcludedSections:   []string{"micro"},
+			Taxonomies:         map[string][]string{"tags": {"travel", "vacation"}},
+			ExcludedTaxonomies: map[string][]string{"tags": {"private"}},
+			Parameters:         []string{"images"},
+		},
+		{
+			Path:            "/beach-2021",
+			Taxonomies:      map[string][]string{"tags": {"travel", "beach"}},
+			TaxonomyMatch:   "all",
+			PublishedAfter:  "2021-01-01",
+			PublishedBefore: "2022-01-01",
+		},
+	}
+	_ = app.initDatabase(false)
+	defer app.db.close()
+	app.initComponents(false)
+	app.d = app.buildRouter()
+	handlerClient := newHandlerClient(app.d)
+
+	for _, p := range []*post{
+		{Path: "/1", Section: "posts", Published: "2021-05-01T00:00:00Z", Parameters: map[string][]string{"tags": {"Travel", "Beach"}, "images": {"https://example.com/1.jpg"}}},
+		{Path: "/2", Section: "posts", Published: "2020-05-01T00:00:00Z", Parameters: map[string][]string{"tags": {"Vacation", "Beach"}, "images": {"https://example.com/2.jpg"}}},
+		{Path: "/3", Section: "micro", Published: "2021-06-01T00:00:00Z", Parameters: map[string][]string{"tags": {"Travel", "Beach"}, "images": {"https://example.com/3.jpg"}}},
+		{Path: "/4", Section: "posts", Published: "2021-07-01T00:00:00Z", Parameters: map[string][]string{"tags": {"Travel", "Private"}, "images": {"https://example.com/4.jpg"}}},
+		{Path: "/5", Section: "posts", Published: "2021-08-01T00:00:00Z", Parameters: map[string][]string{"tags": {"Travel"}}},
+	} {
+		p.Status = statusPublished
+		p.Content = "Test"
+		require.NoError(t, app.createPost(p))
+	}
+
+	getFeedPaths := func(path string) []string {
+		var feed *gofeed.Feed
+		err := requests.URL("http://localhost:8080" + path + ".json").Client(handlerClient).
+			Handle(func(r *http.Response) (err error) {
+				defer r.Body.Close()
+				feed, err = gofeed.NewParser().Parse(r.Body)
+				return
+			}).
+			Fetch(context.Background())
+		require.NoError(t, err)
+		var paths []string
+		for _, item := range feed.Items {
+			paths = append(paths, item.GUID)
+		}
+		return paths
+	}
+
+	assert.ElementsMatch(t, []string{"/1", "/2"}, getFeedPaths("/travel-photos"))
+	assert.ElementsMatch(t, []string{"/1", "/3"}, getFeedPaths("/beach-2021"))
+
+	// HTML page
+	var html string
+	err := requests.URL("http://localhost:8080/travel-photos").Client(handlerClient).ToString(&html).Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, html, "Travel photos")
+
+	// Index paths for WebSub
+	p, err := app.getPost("/1")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"/travel-photos", "/beach-2021"}, app.postCustomIndexPaths(p))
+	p, err = app.getPost("/5")
+	require.NoError(t, err)
+	assert.Empty(t, app.postCustomIndexPaths(p))
+
+	// Only public statuses
+	bc.CustomIndexes[0].Status = []string{"private"}
+	assert.Error(t, bc.checkCustomIndexes())
+	bc.CustomIndexes[0].Status = []string{"published", "unlisted"}
+	assert.NoError(t, bc.checkCustomIndexes())
+}
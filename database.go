@@ -54,6 +54,9 @@ func (a *goBlog) initDatabase(logging bool) (err error) {
 		})
 		db.dump(a.cfg.Db.DumpFile)
 	}
+	if err = db.setSearchParameters(a.searchIndexParameters()); err != nil {
+		return err
+	}
 	if logging {
 		log.Println("Initialized database")
 	}
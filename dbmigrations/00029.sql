@@ -0,0 +1,7 @@
+create table media_derivatives (
+    original text not null,
+    location text not null,
+    width integer not null,
+    type text not null,
+    primary key (original, location)
+);
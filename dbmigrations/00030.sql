@@ -0,0 +1,8 @@
+create table media_exif (
+    location text primary key,
+    captured text not null default '',
+    camera text not null default '',
+    caption text not null default '',
+    lat real,
+    lon real
+);
@@ -0,0 +1,20 @@
+create table media (
+    name text primary key,
+    location text not null default '',
+    original_name text not null default '',
+    mime_type text not null default '',
+    width integer not null default 0,
+    height integer not null default 0,
+    size integer not null default 0,
+    uploader text not null default '',
+    alt text not null default '',
+    caption text not null default '',
+    uploaded text not null default '',
+    compressed text not null default ''
+);
+create table media_tags (
+    name text not null,
+    tag text not null,
+    primary key (name, tag)
+);
+create index index_media_tags_tag on media_tags (tag);
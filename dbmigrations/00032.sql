@@ -0,0 +1,5 @@
+create table media_videos (
+    location text primary key,
+    playlist text not null,
+    poster text not null default ''
+);
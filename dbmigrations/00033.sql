@@ -0,0 +1,5 @@
+create table media_audio (
+    location text primary key,
+    size integer not null default 0,
+    duration integer not null default 0
+);
@@ -0,0 +1,11 @@
+create table search_parameters (parameter text not null primary key);
+drop table posts_fts;
+drop view posts_fts_view;
+create view posts_fts_view as select p.rowid as id, p.path as path, coalesce(pp.value, '') as title, p.content as content, coalesce((select group_concat(sp.value, ' ') from post_parameters sp where sp.path = p.path and sp.parameter in (select parameter from search_parameters)), '') as params from posts p left outer join (select * from post_parameters pp where pp.parameter = 'title') pp on p.path = pp.path;
+create virtual table posts_fts using fts5(path unindexed, title, content, params, content=posts_fts_view, content_rowid=id);
+insert into posts_fts(posts_fts) values ('rebuild');
+create virtual table webmentions_fts using fts5(title, content, author, content=webmentions, content_rowid=id);
+create trigger trigger_webmentions_fts_insert after insert on webmentions begin insert into webmentions_fts(rowid, title, content, author) values (new.id, new.title, new.content, new.author); end;
+create trigger trigger_webmentions_fts_delete after delete on webmentions begin insert into webmentions_fts(webmentions_fts, rowid, title, content, author) values ('delete', old.id, old.title, old.content, old.author); end;
+create trigger trigger_webmentions_fts_update after update on webmentions begin insert into webmentions_fts(webmentions_fts, rowid, title, content, author) values ('delete', old.id, old.title, old.content, old.author); insert into webmentions_fts(rowid, title, content, author) values (new.id, new.title, new.content, new.author); end;
+insert into webmentions_fts(webmentions_fts) values ('rebuild');
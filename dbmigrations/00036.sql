@@ -0,0 +1,2 @@
+create index index_posts_blo_sta_sec_pub on posts (blog, status, section, published);
+create index index_posts_blo_sta_pub on posts (blog, status, published);
@@ -0,0 +1,9 @@
+create table taxonomy_terms (
+    blog text not null,
+    taxonomy text not null,
+    value text not null,
+    description text not null default '',
+    image text not null default '',
+    parent text not null default '',
+    primary key (blog, taxonomy, value)
+);
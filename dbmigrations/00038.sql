@@ -0,0 +1,7 @@
+create table taxonomy_redirects (
+    blog text not null,
+    taxonomy text not null,
+    value text not null,
+    target text not null,
+    primary key (blog, taxonomy, value)
+);
@@ -20,7 +20,9 @@ const editorPath = "/editor"
 
 func (a *goBlog) serveEditor(w http.ResponseWriter, r *http.Request) {
 	a.render(w, r, a.renderEditor, &renderData{
-		Data: &editorRenderData{},
+		Data: &editorRenderData{
+			media: a.editorMedia(),
+		},
 	})
 }
 
@@ -90,6 +92,7 @@ func (a *goBlog) serveEditorPost(w http.ResponseWriter, r *http.Request) {
 				Data: &editorRenderData{
 					updatePostUrl:     a.fullPostURL(post),
 					updatePostContent: a.postToMfItem(post).Properties.Content[0],
+					media:             a.editorMedia(),
 				},
 			})
 		case "updatepost":
@@ -219,6 +222,10 @@ func (a *goBlog) editorPostDesc(bc *configBlog) string {
 		a.cfg.Micropub.ReplyParam,
 		a.cfg.Micropub.ReplyTitleParam,
 		gpxParameter,
+		syndicateToParam,
+		expiresParam,
+		expiresStatusParam,
+		queueParam,
 	} {
 		if param == "" {
 			continue
@@ -2,34 +2,19 @@ package main
 
 import (
 	"net/http"
-	"sort"
-
-	"github.com/samber/lo"
+	"strings"
 )
 
 func (a *goBlog) serveEditorFiles(w http.ResponseWriter, r *http.Request) {
-	// Get files
-	files, err := a.mediaFiles()
-	if err != nil {
-		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	// Check if files at all
-	if len(files) == 0 {
-		a.render(w, r, a.renderEditorFiles, &renderData{
-			Data: &editorFilesRenderData{},
-		})
-		return
+	query := r.URL.Query()
+	filter := &mediaLibraryFilter{
+		query:   strings.TrimSpace(query.Get("q")),
+		typ:     query.Get("type"),
+		tag:     query.Get("tag"),
+		missAlt: query.Get("missingalt") == "on",
 	}
-	// Sort files time desc
-	sort.Slice(files, func(i, j int) bool {
-		return files[i].Time.After(files[j].Time)
-	})
-	// Find uses
-	fileNames := lo.Map(files, func(f *mediaFile, _ int) string {
-		return f.Name
-	})
-	uses, err := a.db.usesOfMediaFile(fileNames...)
+	// Get files with metadata
+	files, tags, err := a.mediaLibrary(filter)
 	if err != nil {
 		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
 		return
@@ -37,8 +22,9 @@ func (a *goBlog) serveEditorFiles(w http.ResponseWriter, r *http.Request) {
 	// Serve HTML
 	a.render(w, r, a.renderEditorFiles, &renderData{
 		Data: &editorFilesRenderData{
-			files: files,
-			uses:  uses,
+			files:  files,
+			tags:   tags,
+			filter: filter,
 		},
 	})
 }
@@ -52,13 +38,32 @@ func (a *goBlog) serveEditorFilesView(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, a.mediaFileLocation(filename), http.StatusFound)
 }
 
+func (a *goBlog) serveEditorFilesEdit(w http.ResponseWriter, r *http.Request) {
+	filename := r.FormValue("filename")
+	if filename == "" {
+		a.serveError(w, r, "No file selected", http.StatusBadRequest)
+		return
+	}
+	err := a.db.updateMediaRecord(
+		filename, a.getFullAddress(a.mediaFileLocation(filename)),
+		strings.TrimSpace(r.FormValue("alt")), strings.TrimSpace(r.FormValue("caption")),
+		strings.Split(r.FormValue("tags"), ","),
+	)
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	_, bc := a.getBlog(r)
+	http.Redirect(w, r, bc.getRelativePath("/editor/files")+"#"+filename, http.StatusFound)
+}
+
 func (a *goBlog) serveEditorFilesDelete(w http.ResponseWriter, r *http.Request) {
 	filename := r.FormValue("filename")
 	if filename == "" {
 		a.serveError(w, r, "No file selected", http.StatusBadRequest)
 		return
 	}
-	if err := a.deleteMediaFile(filename); err != nil {
+	if err := a.deleteMedia(filename); err != nil {
 		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/araddon/dateparse"
+	"github.com/samber/lo"
+)
+
+const editorPostsLimit = 100
+
+type editorPostsFilter struct {
+	status, section string
+	taxonomy, value string
+	from, to        string // Dates in isoDateFormat
+	page            int
+}
+
+type editorPostsRenderData struct {
+	filter           *editorPostsFilter
+	posts            []*post
+	hasPrev, hasNext bool
+	prev, next       string
+	query            string // Filter query to keep after bulk actions
+}
+
+// Statuses that can be selected for bulk changes, scheduled results from the date
+var editorPostsStatuses = []postStatus{statusPublished, statusDraft, statusUnlisted, statusPrivate}
+
+func editorPostsFilterFromQuery(query url.Values) *editorPostsFilter {
+	f := &editorPostsFilter{
+		status:   query.Get("status"),
+		section:  query.Get("section"),
+		taxonomy: query.Get("taxonomy"),
+		value:    strings.TrimSpace(query.Get("value")),
+		from:     query.Get("from"),
+		to:       query.Get("to"),
+	}
+	f.page, _ = strconv.Atoi(query.Get("page"))
+	if f.page < 1 {
+		f.page = 1
+	}
+	return f
+}
+
+func (f *editorPostsFilter) query(page int) string {
+	values := url.Values{}
+	for key, value := range map[string]string{
+		"status": f.status, "section": f.section, "taxonomy": f.taxonomy, "value": f.value, "from": f.from, "to": f.to,
+	} {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	if page > 1 {
+		values.Set("page", strconv.Itoa(page))
+	}
+	return values.Encode()
+}
+
+func (a *goBlog) serveEditorPosts(w http.ResponseWriter, r *http.Request) {
+	blog, bc := a.getBlog(r)
+	filter := editorPostsFilterFromQuery(r.URL.Query())
+	config := &postsRequestConfig{
+		blog:                 blog,
+		limit:                editorPostsLimit + 1,
+		offset:               (filter.page - 1) * editorPostsLimit,
+		withOnlyParameters:   []string{"title"},
+		withoutRenderedTitle: true,
+	}
+	if filter.status != "" {
+		config.status = postStatus(filter.status)
+	} else {
+		// Deleted posts only when explicitly filtered
+		config.statusse = []postStatus{statusPublished, statusDraft, statusUnlisted, statusPrivate, statusScheduled}
+	}
+	if filter.section != "" {
+		config.sections = []string{filter.section}
+	}
+	if filter.taxonomy != "" && filter.value != "" && bc.hasTaxonomy(filter.taxonomy) {
+		config.taxonomyValues = map[string][]string{filter.taxonomy: {filter.value}}
+	}
+	if filter.from != "" {
+		config.publishedAfter = timeNoErr(dateparse.ParseLocal(filter.from))
+	}
+	if filter.to != "" {
+		if to := timeNoErr(dateparse.ParseLocal(filter.to)); !to.IsZero() {
+			// Include the whole day
+			config.publishedBefore = to.AddDate(0, 0, 1)
+		}
+	}
+	posts, err := a.getPosts(config)
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	data := &editorPostsRenderData{
+		filter: filter,
+		posts:  posts,
+		query:  filter.query(filter.page),
+	}
+	editorPostsPath := bc.getRelativePath("/editor/posts")
+	if len(posts) > editorPostsLimit {
+		data.posts = posts[:editorPostsLimit]
+		data.hasNext = true
+		data.next = editorPostsPath + "?" + filter.query(filter.page+1)
+	}
+	if filter.page > 1 {
+		data.hasPrev = true
+		data.prev = strings.TrimSuffix(editorPostsPath+"?"+filter.query(filter.page-1), "?")
+	}
+	a.render(w, r, a.renderEditorPosts, &renderData{
+		Data: data,
+	})
+}
+
+func (a *goBlog) serveEditorPostsBulk(w http.ResponseWriter, r *http.Request) {
+	blog, bc := a.getBlog(r)
+	if err := r.ParseForm(); err != nil {
+		a.serveError(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+	action := &bulkPostAction{
+		action:    r.FormValue("bulkaction"),
+		status:    postStatus(r.FormValue("status")),
+		section:   r.FormValue("section"),
+		taxonomy:  r.FormValue("taxonomy"),
+		value:     strings.TrimSpace(r.FormValue("value")),
+		published: strings.TrimSpace(r.FormValue("published")),
+	}
+	if err := a.bulkEditPosts(blog, r.Form["path"], action); err != nil {
+		a.serveError(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+	target := bc.getRelativePath("/editor/posts")
+	if query := r.FormValue("query"); query != "" {
+		target += "?" + query
+	}
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+type bulkPostAction struct {
+	action    string // status, section, addvalue, removevalue, delete, undelete, reschedule or queue
+	status    postStatus
+	section   string
+	taxonomy  string
+	value     string
+	published string
+}
+
+// Apply the action to all posts, the search index and cache are only updated once at the end
+func (a *goBlog) bulkEditPosts(blog string, paths []string, action *bulkPostAction) error {
+	if len(paths) == 0 {
+		return errors.New("no posts selected")
+	}
+	bc, ok := a.cfg.Blogs[blog]
+	if !ok {
+		return errors.New("blog doesn't exist")
+	}
+	// Check action parameters
+	switch action.action {
+	case "status":
+		if !lo.Contains(editorPostsStatuses, action.status) {
+			return errors.New("invalid status")
+		}
+	case "section":
+		if _, ok := bc.Sections[action.section]; !ok {
+			return errors.New("section doesn't exist")
+		}
+	case "addvalue", "removevalue":
+		if !bc.hasTaxonomy(action.taxonomy) {
+			return errors.New("unknown taxonomy")
+		}
+		if action.value == "" {
+			return errors.New("value required")
+		}
+	case "reschedule":
+		if _, err := dateparse.ParseLocal(action.published); err != nil {
+			return errors.New("invalid date")
+		}
+	case "queue":
+		if !bc.postingQueueEnabled() {
+			return errors.New("posting queue not configured")
+		}
+	case "delete", "undelete":
+	default:
+		return errors.New("unknown action")
+	}
+	var failed []string
+	for _, path := range paths {
+		if err := a.bulkEditPost(blog, path, action); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", path, err.Error()))
+		}
+	}
+	a.db.rebuildFTSIndex()
+	a.cache.purge()
+	if len(failed) > 0 {
+		return errors.New(strings.Join(failed, "\n"))
+	}
+	return nil
+}
+
+func (a *goBlog) bulkEditPost(blog, path string, action *bulkPostAction) error {
+	p, err := a.getPost(path)
+	if err != nil {
+		return err
+	}
+	if p.Blog != blog {
+		return errPostNotFound
+	}
+	switch action.action {
+	case "delete":
+		if p.Deleted() {
+			// Deleting again would remove the post permanently
+			return errors.New("post is already deleted")
+		}
+		return a.deletePostWithOptions(p.Path, &postDeletionOptions{bulk: true})
+	case "undelete":
+		if !p.Deleted() {
+			return nil
+		}
+		return a.undeletePostWithOptions(p.Path, &postDeletionOptions{bulk: true})
+	}
+	if p.Deleted() {
+		return errors.New("post is deleted")
+	}
+	oldStatus := p.Status
+	switch action.action {
+	case "status":
+		p.Status = action.status
+		if p.Status == statusPublished && postIsInFuture(p) {
+			p.Status = statusScheduled
+		}
+	case "section":
+		p.Section = action.section
+	case "addvalue":
+		if lo.ContainsBy(p.Parameters[action.taxonomy], func(v string) bool { return strings.EqualFold(v, action.value) }) {
+			return nil
+		}
+		if p.Parameters == nil {
+			p.Parameters = map[string][]string{}
+		}
+		p.Parameters[action.taxonomy] = append(p.Parameters[action.taxonomy], action.value)
+	case "removevalue":
+		values := []string{}
+		for _, v := range p.Parameters[action.taxonomy] {
+			if !strings.EqualFold(v, action.value) {
+				values = append(values, v)
+			}
+		}
+		if len(values) == len(p.Parameters[action.taxonomy]) {
+			return nil
+		}
+		p.Parameters[action.taxonomy] = values
+	case "reschedule":
+		p.Published = action.published
+		if postIsInFuture(p) && (p.Status == statusPublished || p.Status == statusScheduled) {
+			p.Status = statusScheduled
+		} else if p.Status == statusScheduled {
+			p.Status = statusPublished
+		}
+	case "queue":
+		if p.Status != statusDraft {
+			return errors.New("only drafts can be queued")
+		}
+	}
+	if err := a.createOrReplacePost(p, &postCreationOptions{oldPath: p.Path, oldStatus: oldStatus, bulk: true, queue: action.action == "queue"}); err != nil {
+		return err
+	}
+	a.postHiddenHooks(p, oldStatus)
+	return nil
+}
+
+func postIsInFuture(p *post) bool {
+	return timeNoErr(dateparse.ParseLocal(p.Published)).After(time.Now())
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.goblog.app/app/pkgs/contenttype"
+)
+
+func Test_editorPosts(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	_ = app.initConfig()
+	_ = app.initDatabase(false)
+	defer app.db.close()
+	app.initComponents(false)
+	app.d = app.buildRouter()
+
+	// Delete hooks run in the background
+	deleted := make(chan string, 20)
+	app.pDeleteHooks = append(app.pDeleteHooks, func(p *post) {
+		deleted <- p.Path
+	})
+	deletedPaths := func(n int) (paths []string) {
+		for i := 0; i < n; i++ {
+			select {
+			case path := <-deleted:
+				paths = append(paths, path)
+			case <-time.After(time.Second):
+				return paths
+			}
+		}
+		return paths
+	}
+
+	for _, p := range []*post{
+		{Path: "/1", Section: "posts", Published: "2021-01-01T00:00:00Z", Parameters: map[string][]string{"title": {"One"}, "tags": {"Go"}}},
+		{Path: "/2", Section: "posts", Published: "2021-02-01T00:00:00Z", Parameters: map[string][]string{"title": {"Two"}, "tags": {"Web"}}},
+		{Path: "/3", Section: "", Published: "2022-01-01T00:00:00Z", Parameters: map[string][]string{"title": {"Three"}}},
+	} {
+		p.Status = statusPublished
+		p.Content = "Test"
+		require.NoError(t, app.createPost(p))
+	}
+
+	editorRequest := func(method, target string, values url.Values) *httptest.ResponseRecorder {
+		var body io.Reader
+		if values != nil {
+			body = strings.NewReader(values.Encode())
+		}
+		req := httptest.NewRequest(method, target, body)
+		if body != nil {
+			req.Header.Set(contentType, contenttype.WWWForm)
+		}
+		req = req.WithContext(context.WithValue(req.Context(), blogKey, "default"))
+		rec := httptest.NewRecorder()
+		if method == http.MethodPost {
+			app.serveEditorPostsBulk(rec, req)
+		} else {
+			app.serveEditorPosts(rec, req)
+		}
+		return rec
+	}
+	getPost := func(path string) *post {
+		p, err := app.getPost(path)
+		require.NoError(t, err)
+		return p
+	}
+
+	// Filters
+	rec := editorRequest(http.MethodGet, "/editor/posts", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `value=/1`)
+	assert.Contains(t, rec.Body.String(), `value=/3`)
+	rec = editorRequest(http.MethodGet, "/editor/posts?section=posts&taxonomy=tags&value=go", nil)
+	assert.Contains(t, rec.Body.String(), `value=/1`)
+	assert.NotContains(t, rec.Body.String(), `value=/2`)
+	rec = editorRequest(http.MethodGet, "/editor/posts?from=2021-02-01&to=2021-12-31", nil)
+	assert.Contains(t, rec.Body.String(), `value=/2`)
+	assert.NotContains(t, rec.Body.String(), `value=/1`)
+	assert.NotContains(t, rec.Body.String(), `value=/3`)
+
+	// Status
+	rec = editorRequest(http.MethodPost, "/editor/posts", url.Values{
+		"path": {"/1", "/2"}, "bulkaction": {"status"}, "status": {"draft"}, "query": {"section=posts"},
+	})
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, "/editor/posts?section=posts", rec.Header().Get("Location"))
+	assert.Equal(t, statusDraft, getPost("/1").Status)
+	assert.Equal(t, statusDraft, getPost("/2").Status)
+	assert.Equal(t, statusPublished, getPost("/3").Status)
+	// Not public anymore
+	assert.ElementsMatch(t, []string{"/1", "/2"}, deletedPaths(2))
+	rec = editorRequest(http.MethodPost, "/editor/posts", url.Values{
+		"path": {"/1"}, "bulkaction": {"status"}, "status": {"scheduled"},
+	})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Section
+	editorRequest(http.MethodPost, "/editor/posts", url.Values{"path": {"/3"}, "bulkaction": {"section"}, "section": {"posts"}})
+	assert.Equal(t, "posts", getPost("/3").Section)
+	rec = editorRequest(http.MethodPost, "/editor/posts", url.Values{"path": {"/3"}, "bulkaction": {"section"}, "section": {"unknown"}})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Taxonomy values
+	editorRequest(http.MethodPost, "/editor/posts", url.Values{"path": {"/1", "/2", "/3"}, "bulkaction": {"addvalue"}, "taxonomy": {"tags"}, "value": {"go"}})
+	assert.Equal(t, []string{"Go"}, getPost("/1").Parameters["tags"])
+	assert.Equal(t, []string{"Web", "go"}, getPost("/2").Parameters["tags"])
+	assert.Equal(t, []string{"go"}, getPost("/3").Parameters["tags"])
+	editorRequest(http.MethodPost, "/editor/posts", url.Values{"path": {"/1", "/2"}, "bulkaction": {"removevalue"}, "taxonomy": {"tags"}, "value": {"GO"}})
+	assert.Empty(t, getPost("/1").Parameters["tags"])
+	assert.Equal(t, []string{"Web"}, getPost("/2").Parameters["tags"])
+	assert.Equal(t, "One", getPost("/1").Title())
+
+	// Reschedule
+	editorRequest(http.MethodPost, "/editor/posts", url.Values{"path": {"/3"}, "bulkaction": {"reschedule"}, "published": {"2100-01-01T10:00"}})
+	assert.Equal(t, statusScheduled, getPost("/3").Status)
+	editorRequest(http.MethodPost, "/editor/posts", url.Values{"path": {"/3"}, "bulkaction": {"reschedule"}, "published": {"2020-01-01T10:00"}})
+	assert.Equal(t, statusPublished, getPost("/3").Status)
+	assert.True(t, strings.HasPrefix(getPost("/3").Published, "2020-01-01T10:00"))
+
+	// Delete and undelete
+	editorRequest(http.MethodPost, "/editor/posts", url.Values{"path": {"/1", "/3"}, "bulkaction": {"delete"}})
+	assert.Equal(t, statusDraftDeleted, getPost("/1").Status)
+	assert.Equal(t, statusPublishedDeleted, getPost("/3").Status)
+	rec = editorRequest(http.MethodGet, "/editor/posts", nil)
+	assert.NotContains(t, rec.Body.String(), `value=/1`)
+	rec = editorRequest(http.MethodGet, "/editor/posts?status=draft-deleted", nil)
+	assert.Contains(t, rec.Body.String(), `value=/1`)
+	// Deleted posts aren't deleted permanently
+	rec = editorRequest(http.MethodPost, "/editor/posts", url.Values{"path": {"/1"}, "bulkaction": {"delete"}})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, statusDraftDeleted, getPost("/1").Status)
+	editorRequest(http.MethodPost, "/editor/posts", url.Values{"path": {"/1", "/3"}, "bulkaction": {"undelete"}})
+	assert.Equal(t, statusDraft, getPost("/1").Status)
+	assert.Equal(t, statusPublished, getPost("/3").Status)
+
+	// Errors are collected per post
+	rec = editorRequest(http.MethodPost, "/editor/posts", url.Values{"path": {"/unknown", "/2"}, "bulkaction": {"status"}, "status": {"private"}})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "/unknown")
+	assert.Equal(t, statusPrivate, getPost("/2").Status)
+}
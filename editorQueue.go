@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/http"
+)
+
+type editorQueueRenderData struct {
+	posts []*post
+}
+
+func (a *goBlog) serveEditorQueue(w http.ResponseWriter, r *http.Request) {
+	blog, bc := a.getBlog(r)
+	if !bc.postingQueueEnabled() {
+		a.serve404(w, r)
+		return
+	}
+	posts, err := a.getPosts(&postsRequestConfig{
+		blog:           blog,
+		status:         statusScheduled,
+		parameter:      queueParam,
+		ascendingOrder: true,
+	})
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	a.render(w, r, a.renderEditorQueue, &renderData{
+		Data: &editorQueueRenderData{
+			posts: posts,
+		},
+	})
+}
+
+func (a *goBlog) serveEditorQueueMove(w http.ResponseWriter, r *http.Request) {
+	blog, bc := a.getBlog(r)
+	path := r.FormValue("path")
+	if err := a.moveQueuedPost(blog, path, r.FormValue("direction") == "up"); err != nil {
+		a.serveError(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+	http.Redirect(w, r, bc.getRelativePath("/editor/queue"), http.StatusFound)
+}
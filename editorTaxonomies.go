@@ -0,0 +1,126 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+type editorTaxonomiesRenderData struct {
+	taxonomies []*editorTaxonomy
+}
+
+type editorTaxonomy struct {
+	taxonomy *configTaxonomy
+	terms    []*taxonomyTerm
+	counts   map[string]int // Number of posts per value
+}
+
+func (a *goBlog) serveEditorTaxonomies(w http.ResponseWriter, r *http.Request) {
+	blog, bc := a.getBlog(r)
+	data := &editorTaxonomiesRenderData{}
+	for _, tax := range bc.Taxonomies {
+		terms, err := a.db.getTaxonomyTerms(blog, tax.Name)
+		if err != nil {
+			a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		counts, err := a.db.countTaxonomyValues(blog, tax.Name)
+		if err != nil {
+			a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// Used values without metadata yet, including spelling variants to clean up
+		for value := range counts {
+			if !lo.ContainsBy(terms, func(t *taxonomyTerm) bool { return t.Value == value }) {
+				terms = append(terms, &taxonomyTerm{Value: value})
+			}
+		}
+		sort.Slice(terms, func(i, j int) bool {
+			if li, lj := strings.ToLower(terms[i].Value), strings.ToLower(terms[j].Value); li != lj {
+				return li < lj
+			}
+			return terms[i].Value < terms[j].Value
+		})
+		data.taxonomies = append(data.taxonomies, &editorTaxonomy{taxonomy: tax, terms: terms, counts: counts})
+	}
+	a.render(w, r, a.renderEditorTaxonomies, &renderData{
+		Data: data,
+	})
+}
+
+func (a *goBlog) serveEditorTaxonomiesSave(w http.ResponseWriter, r *http.Request) {
+	blog, bc := a.getBlog(r)
+	tax := r.FormValue("taxonomy")
+	if !bc.hasTaxonomy(tax) {
+		a.serveError(w, r, "Unknown taxonomy", http.StatusBadRequest)
+		return
+	}
+	term := &taxonomyTerm{
+		Value:       r.FormValue("value"),
+		Description: r.FormValue("description"),
+		Image:       r.FormValue("image"),
+		Parent:      r.FormValue("parent"),
+	}
+	if err := a.db.saveTaxonomyTerm(blog, tax, term); err != nil {
+		a.serveError(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+	a.cache.purge()
+	http.Redirect(w, r, bc.getRelativePath("/editor/taxonomies")+"#"+tax+"-"+urlize(term.Value), http.StatusFound)
+}
+
+func (a *goBlog) serveEditorTaxonomiesDelete(w http.ResponseWriter, r *http.Request) {
+	blog, bc := a.getBlog(r)
+	tax := r.FormValue("taxonomy")
+	if !bc.hasTaxonomy(tax) {
+		a.serveError(w, r, "Unknown taxonomy", http.StatusBadRequest)
+		return
+	}
+	if err := a.db.deleteTaxonomyTerm(blog, tax, r.FormValue("value")); err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	a.cache.purge()
+	http.Redirect(w, r, bc.getRelativePath("/editor/taxonomies")+"#"+tax, http.StatusFound)
+}
+
+func (a *goBlog) serveEditorTaxonomiesRename(w http.ResponseWriter, r *http.Request) {
+	blog, bc := a.getBlog(r)
+	tax := r.FormValue("taxonomy")
+	if !bc.hasTaxonomy(tax) {
+		a.serveError(w, r, "Unknown taxonomy", http.StatusBadRequest)
+		return
+	}
+	newValue := strings.TrimSpace(r.FormValue("newvalue"))
+	if err := a.renameTaxonomyValue(blog, tax, r.FormValue("value"), newValue, r.FormValue("hooks") == "on"); err != nil {
+		a.serveError(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+	http.Redirect(w, r, bc.getRelativePath("/editor/taxonomies")+"#"+tax+"-"+urlize(newValue), http.StatusFound)
+}
+
+func (a *goBlog) serveEditorTaxonomiesRemove(w http.ResponseWriter, r *http.Request) {
+	blog, bc := a.getBlog(r)
+	tax := r.FormValue("taxonomy")
+	if !bc.hasTaxonomy(tax) {
+		a.serveError(w, r, "Unknown taxonomy", http.StatusBadRequest)
+		return
+	}
+	if err := a.deleteTaxonomyValue(blog, tax, r.FormValue("value"), r.FormValue("hooks") == "on"); err != nil {
+		a.serveError(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+	http.Redirect(w, r, bc.getRelativePath("/editor/taxonomies")+"#"+tax, http.StatusFound)
+}
+
+func (bc *configBlog) hasTaxonomy(name string) bool {
+	for _, tax := range bc.Taxonomies {
+		if tax.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -1,13 +1,19 @@
 package main
 
 import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
 	"io"
+	"mime"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/araddon/dateparse"
 	"github.com/jlelse/feeds"
+	"github.com/samber/lo"
 	"go.goblog.app/app/pkgs/bufferpool"
 	"go.goblog.app/app/pkgs/contenttype"
 )
@@ -15,16 +21,38 @@ import (
 type feedType string
 
 const (
-	noFeed   feedType = ""
-	rssFeed  feedType = "rss"
-	atomFeed feedType = "atom"
-	jsonFeed feedType = "json"
+	noFeed      feedType = ""
+	rssFeed     feedType = "rss"
+	atomFeed    feedType = "atom"
+	jsonFeed    feedType = "json"
+	podcastFeed feedType = "podcast"
 )
 
+// Information the feeds library doesn't support
+type feedItemExtras struct {
+	categories []*feedCategory
+	enclosures []*feedEnclosure // audio first, then images
+}
+
+type feedEnclosure struct {
+	url, typ string
+	size     int64
+}
+
+type feedCategory struct {
+	term, scheme string
+}
+
 func (a *goBlog) generateFeed(blog string, f feedType, w http.ResponseWriter, r *http.Request, posts []*post, title, description string) {
+	if f == podcastFeed {
+		a.generatePodcastFeed(blog, w, r, posts, title, description)
+		return
+	}
 	now := time.Now()
-	title = a.renderMdTitle(defaultIfEmpty(title, a.cfg.Blogs[blog].Title))
-	description = defaultIfEmpty(description, a.cfg.Blogs[blog].Description)
+	bc := a.cfg.Blogs[blog]
+	title = a.renderMdTitle(defaultIfEmpty(title, bc.Title))
+	description = defaultIfEmpty(description, bc.Description)
+	self := a.getFullAddress(r.URL.Path)
 	feed := &feeds.Feed{
 		Title:       title,
 		Description: description,
@@ -38,12 +66,14 @@ func (a *goBlog) generateFeed(blog string, f feedType, w http.ResponseWriter, r
 			Url: a.cfg.User.Picture,
 		},
 	}
+	extras := make([]*feedItemExtras, 0, len(posts))
 	for _, p := range posts {
 		buf := bufferpool.Get()
 		a.feedHtml(buf, p)
 		feed.Add(&feeds.Item{
 			Title:       p.RenderedTitle,
 			Link:        &feeds.Link{Href: a.fullPostURL(p)},
+			Author:      &feeds.Author{Name: a.cfg.User.Name, Email: a.cfg.User.Email},
 			Description: a.postSummary(p),
 			Id:          p.Path,
 			Content:     buf.String(),
@@ -51,19 +81,31 @@ func (a *goBlog) generateFeed(blog string, f feedType, w http.ResponseWriter, r
 			Updated:     timeNoErr(dateparse.ParseLocal(p.Updated)),
 		})
 		bufferpool.Put(buf)
+		extras = append(extras, a.feedItemExtras(bc, p))
+	}
+	a.feedEnclosureSizes(extras)
+	var hubs []string
+	if a.webSubEnabled() {
+		hubs = a.webSubHubs()
 	}
 	var feedWriteFunc func(w io.Writer) error
 	var feedMediaType string
 	switch f {
 	case rssFeed:
 		feedMediaType = contenttype.RSS
-		feedWriteFunc = feed.WriteRss
+		feedWriteFunc = func(w io.Writer) error {
+			return writeRssFeed(w, feed, extras, self, hubs)
+		}
 	case atomFeed:
 		feedMediaType = contenttype.ATOM
-		feedWriteFunc = feed.WriteAtom
+		feedWriteFunc = func(w io.Writer) error {
+			return writeAtomFeed(w, feed, extras, self, hubs)
+		}
 	case jsonFeed:
 		feedMediaType = contenttype.JSONFeed
-		feedWriteFunc = feed.WriteJSON
+		feedWriteFunc = func(w io.Writer) error {
+			return writeJSONFeed(w, feed, extras, self, hubs)
+		}
 	default:
 		a.serve404(w, r)
 		return
@@ -73,7 +115,210 @@ func (a *goBlog) generateFeed(blog string, f feedType, w http.ResponseWriter, r
 		writeErr := feedWriteFunc(pipeWriter)
 		_ = pipeWriter.CloseWithError(writeErr)
 	}()
+	a.setWebSubLinkHeaders(w, self)
 	w.Header().Set(contentType, feedMediaType+contenttype.CharsetUtf8Suffix)
 	minifyErr := a.min.Get().Minify(feedMediaType, w, pipeReader)
 	_ = pipeReader.CloseWithError(minifyErr)
 }
+
+// Add the sizes from the media library to enclosures without size
+func (a *goBlog) feedEnclosureSizes(extras []*feedItemExtras) {
+	var locations []string
+	for _, e := range extras {
+		for _, enc := range e.enclosures {
+			if enc.size == 0 {
+				locations = append(locations, enc.url)
+			}
+		}
+	}
+	sizes, err := a.db.getMediaSizes(lo.Uniq(locations))
+	if err != nil {
+		return
+	}
+	for _, e := range extras {
+		for _, enc := range e.enclosures {
+			if enc.size == 0 {
+				enc.size = sizes[enc.url]
+			}
+		}
+	}
+}
+
+func (a *goBlog) feedItemExtras(bc *configBlog, p *post) *feedItemExtras {
+	extras := &feedItemExtras{}
+	// Categories from the taxonomies
+	for _, tax := range bc.Taxonomies {
+		for _, value := range p.Parameters[tax.Name] {
+			extras.categories = append(extras.categories, &feedCategory{
+				term:   value,
+				scheme: a.getFullAddress(bc.getRelativePath(tax.Name)),
+			})
+		}
+	}
+	// Enclosures from audio and images
+	enclosure := func(location, fallbackType string, size int64) *feedEnclosure {
+		return &feedEnclosure{
+			url:  location,
+			typ:  defaultIfEmpty(mime.TypeByExtension(urlExt(location)), fallbackType),
+			size: size,
+		}
+	}
+	if audio, _ := a.podcastAudio(p); audio != "" {
+		audio = a.getFullAddress(audio)
+		size, _ := a.mediaAudioInfo(audio)
+		extras.enclosures = append(extras.enclosures, enclosure(audio, "audio/mpeg", size))
+	}
+	if mp := a.cfg.Micropub; mp != nil {
+		for _, image := range p.Parameters[mp.PhotoParam] {
+			extras.enclosures = append(extras.enclosures, enclosure(a.getFullAddress(image), "image/jpeg", 0))
+		}
+	}
+	return extras
+}
+
+// RSS
+
+type rssFeedXml struct {
+	XMLName          xml.Name    `xml:"rss"`
+	Version          string      `xml:"version,attr"`
+	ContentNamespace string      `xml:"xmlns:content,attr"`
+	AtomNamespace    string      `xml:"xmlns:atom,attr"`
+	MediaNamespace   string      `xml:"xmlns:media,attr"`
+	Channel          *rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	*feeds.RssFeed
+	Links []*rssAtomLink `xml:"atom:link"`
+	Items []*rssItem     `xml:"item"`
+}
+
+type rssAtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type rssItem struct {
+	*feeds.RssItem
+	Author     string             `xml:"author,omitempty"`
+	Categories []*rssCategory     `xml:"category"`
+	Media      []*rssMediaContent `xml:"media:content"`
+}
+
+type rssCategory struct {
+	Domain string `xml:"domain,attr,omitempty"`
+	Value  string `xml:",chardata"`
+}
+
+type rssMediaContent struct {
+	URL      string `xml:"url,attr"`
+	Type     string `xml:"type,attr,omitempty"`
+	Medium   string `xml:"medium,attr,omitempty"`
+	FileSize int64  `xml:"fileSize,attr,omitempty"`
+}
+
+func writeRssFeed(w io.Writer, feed *feeds.Feed, extras []*feedItemExtras, self string, hubs []string) error {
+	rf := (&feeds.Rss{Feed: feed}).RssFeed()
+	channel := &rssChannel{RssFeed: rf}
+	channel.Links = append(channel.Links, &rssAtomLink{Href: self, Rel: "self", Type: contenttype.RSS})
+	for _, hub := range hubs {
+		channel.Links = append(channel.Links, &rssAtomLink{Href: hub, Rel: "hub"})
+	}
+	for i, ri := range rf.Items {
+		item := &rssItem{RssItem: ri}
+		if author := feed.Items[i].Author; author != nil {
+			// RSS requires the email address
+			item.Author = author.Name
+			if author.Email != "" {
+				item.Author = fmt.Sprintf("%s (%s)", author.Email, author.Name)
+			}
+		}
+		for _, c := range extras[i].categories {
+			item.Categories = append(item.Categories, &rssCategory{Domain: c.scheme, Value: c.term})
+		}
+		// RSS only allows one enclosure, other media using Media RSS
+		for j, e := range extras[i].enclosures {
+			if j == 0 {
+				ri.Enclosure = &feeds.RssEnclosure{Url: e.url, Type: e.typ, Length: strconv.FormatInt(e.size, 10)}
+				continue
+			}
+			medium, _, _ := strings.Cut(e.typ, "/")
+			item.Media = append(item.Media, &rssMediaContent{URL: e.url, Type: e.typ, Medium: medium, FileSize: e.size})
+		}
+		channel.Items = append(channel.Items, item)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(&rssFeedXml{
+		Version:          "2.0",
+		ContentNamespace: "http://purl.org/rss/1.0/modules/content/",
+		AtomNamespace:    "http://www.w3.org/2005/Atom",
+		MediaNamespace:   "http://search.yahoo.com/mrss/",
+		Channel:          channel,
+	})
+}
+
+// Atom
+
+type atomFeedXml struct {
+	*feeds.AtomFeed
+	Links   []*feeds.AtomLink `xml:"link"`
+	Entries []*atomEntry      `xml:"entry"`
+}
+
+type atomEntry struct {
+	*feeds.AtomEntry
+	Categories []*atomCategory `xml:"category"`
+}
+
+type atomCategory struct {
+	Term   string `xml:"term,attr"`
+	Scheme string `xml:"scheme,attr,omitempty"`
+}
+
+func writeAtomFeed(w io.Writer, feed *feeds.Feed, extras []*feedItemExtras, self string, hubs []string) error {
+	af := (&feeds.Atom{Feed: feed}).AtomFeed()
+	x := &atomFeedXml{AtomFeed: af}
+	x.Links = append(x.Links, af.Link, &feeds.AtomLink{Href: self, Rel: "self", Type: contenttype.ATOM})
+	for _, hub := range hubs {
+		x.Links = append(x.Links, &feeds.AtomLink{Href: hub, Rel: "hub"})
+	}
+	for i, ae := range af.Entries {
+		entry := &atomEntry{AtomEntry: ae}
+		for _, c := range extras[i].categories {
+			entry.Categories = append(entry.Categories, &atomCategory{Term: c.term, Scheme: c.scheme})
+		}
+		for _, e := range extras[i].enclosures {
+			ae.Links = append(ae.Links, feeds.AtomLink{Href: e.url, Rel: "enclosure", Type: e.typ, Length: strconv.FormatInt(e.size, 10)})
+		}
+		x.Entries = append(x.Entries, entry)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(x)
+}
+
+// JSON Feed
+
+func writeJSONFeed(w io.Writer, feed *feeds.Feed, extras []*feedItemExtras, self string, hubs []string) error {
+	jf := (&feeds.JSON{Feed: feed}).JSONFeed()
+	jf.FeedUrl = self
+	for _, hub := range hubs {
+		jf.Hubs = append(jf.Hubs, &feeds.JSONHub{Type: "WebSub", Url: hub})
+	}
+	for i, item := range jf.Items {
+		for _, c := range extras[i].categories {
+			item.Tags = append(item.Tags, c.term)
+		}
+		for _, e := range extras[i].enclosures {
+			if item.Image == "" && strings.HasPrefix(e.typ, "image/") {
+				item.Image = e.url
+			}
+			item.Attachments = append(item.Attachments, &feeds.JSONAttachment{Url: e.url, MIMEType: e.typ, Size: int(e.size)})
+		}
+	}
+	return json.NewEncoder(w).Encode(jf)
+}
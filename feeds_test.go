@@ -3,39 +3,68 @@ package main
 import (
 	"context"
 	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/carlmjohnson/requests"
 	"github.com/mmcdole/gofeed"
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
 func Test_feeds(t *testing.T) {
+	var publishedMu sync.Mutex
+	var published []string
+	publishRequests := 0
+	hub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "publish", r.FormValue("hub.mode"))
+		publishedMu.Lock()
+		publishRequests++
+		published = append(published, r.PostForm["hub.url"]...)
+		publishedMu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer hub.Close()
+
 	app := &goBlog{
 		cfg: createDefaultTestConfig(t),
 	}
 	_ = app.initConfig()
+	app.cfg.User.Name = "John Doe"
+	app.cfg.User.Email = "john@example.com"
+	app.cfg.WebSub = &configWebSub{Hubs: []string{hub.URL}}
 	_ = app.initDatabase(false)
 	defer app.db.close()
 	app.initComponents(false)
 	app.d = app.buildRouter()
 	handlerClient := newHandlerClient(app.d)
 
+	// Size of the image from the media library
+	require.NoError(t, app.db.saveMediaRecord(&mediaRecord{Name: "image.png", Location: "https://example.com/image.png", Size: 1234}))
+
 	err := app.createPost(&post{
-		Path:       "/testpost",
-		Section:    "posts",
-		Status:     "published",
-		Published:  "2020-01-01T00:00:00Z",
-		Parameters: map[string][]string{"title": {"Test Post"}},
-		Content:    "Test Content",
+		Path:      "/testpost",
+		Section:   "posts",
+		Status:    "published",
+		Published: "2020-01-01T00:00:00Z",
+		Parameters: map[string][]string{
+			"title":  {"Test Post"},
+			"tags":   {"Foo", "Bar"},
+			"images": {"https://example.com/image.png", "https://example.com/image2.jpg"},
+		},
+		Content: "Test Content",
 	})
 	require.NoError(t, err)
 
 	for _, typ := range []feedType{rssFeed, atomFeed, jsonFeed} {
 		var feed *gofeed.Feed
+		var links []string
 		err := requests.URL("http://localhost:8080/posts." + string(typ)).Client(handlerClient).
 			Handle(func(r *http.Response) (err error) {
+				links = r.Header.Values("Link")
 				fp := gofeed.NewParser()
 				defer r.Body.Close()
 				feed, err = fp.Parse(r.Body)
@@ -50,6 +79,34 @@ func Test_feeds(t *testing.T) {
 		if assert.Len(t, feed.Items, 1) {
 			assert.Equal(t, "Test Post", feed.Items[0].Title)
 			assert.Equal(t, "Test Content", feed.Items[0].Description)
+			assert.ElementsMatch(t, []string{"Foo", "Bar"}, feed.Items[0].Categories)
+			if assert.NotEmpty(t, feed.Items[0].Enclosures) {
+				assert.Equal(t, "https://example.com/image.png", feed.Items[0].Enclosures[0].URL)
+				assert.Equal(t, "image/png", feed.Items[0].Enclosures[0].Type)
+				if typ != jsonFeed {
+					// gofeed doesn't parse the size of JSON feed attachments
+					assert.Equal(t, "1234", feed.Items[0].Enclosures[0].Length)
+				}
+			}
+			if assert.NotNil(t, feed.Items[0].Author, string(typ)) {
+				assert.Equal(t, "John Doe", feed.Items[0].Author.Name)
+			}
 		}
+
+		assert.Contains(t, links, "<"+hub.URL+">; rel=hub")
+		assert.Contains(t, links, "<http://localhost:8080/posts."+string(typ)+">; rel=self")
 	}
+
+	// Hubs are notified about the feeds of the post
+	assert.Eventually(t, func() bool {
+		publishedMu.Lock()
+		defer publishedMu.Unlock()
+		return lo.Contains(published, "http://localhost:8080/posts.rss") &&
+			lo.Contains(published, "http://localhost:8080/tags/foo.atom") &&
+			lo.Contains(published, "http://localhost:8080/.json")
+	}, 5*time.Second, 50*time.Millisecond)
+	// One request with all topics
+	publishedMu.Lock()
+	assert.Equal(t, 1, publishRequests)
+	publishedMu.Unlock()
 }
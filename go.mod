@@ -41,6 +41,7 @@ require (
 	github.com/microcosm-cc/bluemonday v1.0.18
 	github.com/mmcdole/gofeed v1.1.3
 	github.com/paulmach/go.geojson v1.4.0
+	github.com/pkg/sftp v1.13.5
 	github.com/posener/wstest v1.2.0
 	github.com/pquerna/otp v1.3.0
 	github.com/samber/lo v1.21.0
@@ -112,6 +113,7 @@ require (
 	github.com/jsimonetti/rtnetlink v1.1.2-0.20220408201609-d380b505068b // indirect
 	github.com/json-iterator/go v1.1.12 // indirect
 	github.com/kortschak/wol v0.0.0-20200729010619-da482cc4850a // indirect
+	github.com/kr/fs v0.1.0 // indirect
 	github.com/lestrrat-go/strftime v1.0.5 // indirect
 	github.com/magiconair/properties v1.8.6 // indirect
 	github.com/mdlayher/genetlink v1.2.0 // indirect
@@ -70,6 +70,17 @@ func (a *goBlog) postDeleteHooks(p *post) {
 	}
 }
 
+// Trigger the delete hooks when a published or unlisted post got hidden (e.g. private or draft),
+// so it's removed from ActivityPub, Telegram etc.
+func (a *goBlog) postHiddenHooks(p *post, oldStatus postStatus) {
+	public := func(s postStatus) bool {
+		return s == statusPublished || s == statusUnlisted
+	}
+	if public(oldStatus) && !public(p.Status) {
+		a.postDeleteHooks(p)
+	}
+}
+
 func (a *goBlog) postUndeleteHooks(p *post) {
 	if hc := a.cfg.Hooks; hc != nil {
 		for _, cmdTmplString := range hc.PostUndelete {
@@ -113,7 +113,7 @@ func (*goBlog) redirectToHttps(w http.ResponseWriter, r *http.Request) {
 
 const (
 	paginationPath = "/page/{page:[0-9-]+}"
-	feedPath       = ".{feed:(rss|json|atom)}"
+	feedPath       = ".{feed:(rss|json|atom|podcast)}"
 )
 
 func (a *goBlog) buildRouter() http.Handler {
@@ -209,6 +209,11 @@ func (a *goBlog) buildRouter() http.Handler {
 		}
 	}
 
+	// WebSub hub
+	if a.webSubHubEnabled() {
+		r.Post(webSubPath, a.serveWebSubHub)
+	}
+
 	// Robots.txt
 	r.With(cacheLoggedIn, a.cacheMiddleware).Get(robotsTXTPath, a.serveRobotsTXT)
 
@@ -17,6 +17,7 @@ func (a *goBlog) micropubRouter(r chi.Router) {
 	r.Use(a.checkIndieAuth)
 	r.Get("/", a.serveMicropubQuery)
 	r.Post("/", a.serveMicropubPost)
+	r.Get(micropubMediaSubPath, a.serveMicropubMediaQuery)
 	r.Post(micropubMediaSubPath, a.serveMicropubMedia)
 }
 
@@ -140,6 +141,12 @@ func (a *goBlog) blogRouter(blog string, conf *configBlog) func(r chi.Router) {
 		// Photos
 		r.Group(a.blogPhotosRouter(conf))
 
+		// Custom indexes
+		r.Group(a.blogCustomIndexesRouter(conf))
+
+		// Series
+		r.Group(a.blogSeriesRouter(conf))
+
 		// Search
 		r.Group(a.blogSearchRouter(conf))
 
@@ -278,6 +285,58 @@ func (a *goBlog) blogPhotosRouter(conf *configBlog) func(r chi.Router) {
 	}
 }
 
+// Blog - Custom indexes
+func (a *goBlog) blogCustomIndexesRouter(conf *configBlog) func(r chi.Router) {
+	return func(r chi.Router) {
+		r.Use(
+			a.privateModeHandler,
+			a.cacheMiddleware,
+		)
+		for _, ci := range conf.CustomIndexes {
+			if ci.Path == "" {
+				continue
+			}
+			r.Group(func(r chi.Router) {
+				ciPath := conf.getRelativePath(ci.Path)
+				summaryTemplate := defaultSummary
+				if ci.Photos {
+					summaryTemplate = photoSummary
+				}
+				r.Use(middleware.WithValue(indexConfigKey, &indexConfig{
+					path:            ciPath,
+					custom:          ci,
+					title:           ci.Title,
+					description:     ci.Description,
+					summaryTemplate: summaryTemplate,
+				}))
+				r.Get(ciPath, a.serveIndex)
+				r.Get(ciPath+feedPath, a.serveIndex)
+				r.Get(ciPath+paginationPath, a.serveIndex)
+			})
+		}
+	}
+}
+
+// Blog - Series
+func (a *goBlog) blogSeriesRouter(conf *configBlog) func(r chi.Router) {
+	return func(r chi.Router) {
+		if !conf.seriesEnabled() {
+			return
+		}
+		r.Use(
+			a.privateModeHandler,
+			a.cacheMiddleware,
+			middleware.WithValue(taxonomyContextKey, a.seriesTaxonomy(conf)),
+		)
+		seriesBasePath := conf.getRelativePath(seriesParam)
+		r.Get(seriesBasePath, a.serveTaxonomy)
+		seriesPath := seriesBasePath + "/{taxValue}"
+		r.Get(seriesPath, a.serveSeries)
+		r.Get(seriesPath+feedPath, a.serveSeries)
+		r.Get(seriesPath+paginationPath, a.serveSeries)
+	}
+}
+
 // Blog - Search
 func (a *goBlog) blogSearchRouter(conf *configBlog) func(r chi.Router) {
 	return func(r chi.Router) {
@@ -296,6 +355,8 @@ func (a *goBlog) blogSearchRouter(conf *configBlog) func(r chi.Router) {
 					r.Get(searchResultPath, a.serveSearchResult)
 					r.Get(searchResultPath+feedPath, a.serveSearchResult)
 					r.Get(searchResultPath+paginationPath, a.serveSearchResult)
+					r.Get("/api", a.serveSearchAPI)
+					r.Get("/suggest", a.serveSearchSuggestions)
 				})
 				r.With(
 					// No private mode, to allow using OpenSearch in browser
@@ -333,7 +394,17 @@ func (a *goBlog) blogEditorRouter(_ *configBlog) func(r chi.Router) {
 		r.Post("/", a.serveEditorPost)
 		r.Get("/files", a.serveEditorFiles)
 		r.Post("/files/view", a.serveEditorFilesView)
+		r.Post("/files/edit", a.serveEditorFilesEdit)
 		r.Post("/files/delete", a.serveEditorFilesDelete)
+		r.Get("/queue", a.serveEditorQueue)
+		r.Post("/queue/move", a.serveEditorQueueMove)
+		r.Get("/posts", a.serveEditorPosts)
+		r.Post("/posts", a.serveEditorPostsBulk)
+		r.Get("/taxonomies", a.serveEditorTaxonomies)
+		r.Post("/taxonomies/save", a.serveEditorTaxonomiesSave)
+		r.Post("/taxonomies/delete", a.serveEditorTaxonomiesDelete)
+		r.Post("/taxonomies/rename", a.serveEditorTaxonomiesRename)
+		r.Post("/taxonomies/remove", a.serveEditorTaxonomiesRemove)
 		r.Get("/drafts", a.serveDrafts)
 		r.Get("/drafts"+feedPath, a.serveDrafts)
 		r.Get("/drafts"+paginationPath, a.serveDrafts)
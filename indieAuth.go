@@ -8,7 +8,10 @@ import (
 	"github.com/hacdias/indieauth/v2"
 )
 
-const indieAuthScope contextKey = "scope"
+const (
+	indieAuthScope  contextKey = "scope"
+	indieAuthClient contextKey = "client"
+)
 
 func (a *goBlog) initIndieAuth() {
 	a.ias = indieauth.NewServer(
@@ -25,7 +28,9 @@ func (a *goBlog) checkIndieAuth(next http.Handler) http.Handler {
 			a.serveError(w, r, err.Error(), http.StatusUnauthorized)
 			return
 		}
-		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), indieAuthScope, strings.Join(data.Scopes, " "))))
+		ctx := context.WithValue(r.Context(), indieAuthScope, strings.Join(data.Scopes, " "))
+		ctx = context.WithValue(ctx, indieAuthClient, data.ClientID)
+		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
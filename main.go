@@ -158,6 +158,16 @@ func main() {
 		return
 	}
 
+	// Media migration
+	if len(os.Args) >= 3 && os.Args[1] == "media" && os.Args[2] == "migrate" {
+		if err = app.mediaMigrateCommand(os.Args[3:]); err != nil {
+			app.logErrAndQuit("Failed to migrate media:", err.Error())
+			return
+		}
+		app.shutdown.ShutdownAndWait()
+		return
+	}
+
 	// Initialize components
 	app.initComponents(true)
 
@@ -209,12 +219,15 @@ func (app *goBlog) initComponents(logging bool) {
 	app.initWebmention()
 	app.initTelegram()
 	app.initBlogStats()
+	app.initRelatedPosts()
 	app.initTTS()
+	app.initVideoTranscoding()
 	app.initSessions()
 	app.initIndieAuth()
 	app.startPostsScheduler()
 	app.initPostsDeleter()
 	app.initIndexNow()
+	app.initWebSub()
 	// Log finish
 	if logging {
 		log.Println("Initialized components")
@@ -2,7 +2,9 @@ package main
 
 import (
 	"bytes"
+	"fmt"
 	"io"
+	"strings"
 
 	marktag "git.jlel.se/jlelse/goldmark-mark"
 	"github.com/yuin/goldmark"
@@ -47,6 +49,7 @@ func (a *goBlog) initMarkdown() {
 	a.md = goldmark.New(append(defaultGoldmarkOptions, goldmark.WithExtensions(&customExtension{
 		absoluteLinks: false,
 		publicAddress: publicAddress,
+		derivatives:   a.mediaDerivatives,
 	}))...)
 	a.absoluteMd = goldmark.New(append(defaultGoldmarkOptions, goldmark.WithExtensions(&customExtension{
 		absoluteLinks: true,
@@ -118,6 +121,7 @@ func (a *goBlog) renderMdTitle(s string) string {
 type customExtension struct {
 	publicAddress string
 	absoluteLinks bool
+	derivatives   func(url string) []*mediaDerivative
 }
 
 func (l *customExtension) Extend(m goldmark.Markdown) {
@@ -125,6 +129,7 @@ func (l *customExtension) Extend(m goldmark.Markdown) {
 		util.Prioritized(&customRenderer{
 			absoluteLinks: l.absoluteLinks,
 			publicAddress: l.publicAddress,
+			derivatives:   l.derivatives,
 		}, 500),
 	))
 }
@@ -132,6 +137,7 @@ func (l *customExtension) Extend(m goldmark.Markdown) {
 type customRenderer struct {
 	publicAddress string
 	absoluteLinks bool
+	derivatives   func(url string) []*mediaDerivative
 }
 
 func (c *customRenderer) RegisterFuncs(r renderer.NodeRendererFuncRegisterer) {
@@ -183,13 +189,47 @@ func (c *customRenderer) renderImage(w util.BufWriter, source []byte, node ast.N
 			dest = resolved[0]
 		}
 	}
+	// Responsive image sources, grouped by type
+	var derivatives []*mediaDerivative
+	if c.derivatives != nil {
+		derivatives = c.derivatives(dest)
+	}
+	imgType := ""
+	var types []string
+	srcsets := map[string][]string{}
+	for _, d := range derivatives {
+		if d.Location == dest || d.Location == c.publicAddress+dest {
+			imgType = d.Type
+		}
+		if _, ok := srcsets[d.Type]; !ok {
+			types = append(types, d.Type)
+		}
+		srcsets[d.Type] = append(srcsets[d.Type], fmt.Sprintf("%s %dw", d.Location, d.Width))
+	}
 	hb := newHtmlBuilder(w)
 	hb.writeElementOpen("a", "href", dest)
-	imgEls := []any{"src", dest, "alt", string(n.Text(source)), "loading", "lazy"}
+	hasSources := len(types) > 1
+	if hasSources {
+		hb.writeElementOpen("picture")
+		for _, t := range types {
+			if t == imgType {
+				continue
+			}
+			hb.writeElementOpen("source", "type", t, "srcset", strings.Join(srcsets[t], ", "), "sizes", responsiveImageSizes)
+		}
+	}
+	imgEls := []any{"src", dest}
+	if imgSrcset := srcsets[imgType]; imgType != "" && len(imgSrcset) > 1 {
+		imgEls = append(imgEls, "srcset", strings.Join(imgSrcset, ", "), "sizes", responsiveImageSizes)
+	}
+	imgEls = append(imgEls, "alt", string(n.Text(source)), "loading", "lazy")
 	if len(n.Title) > 0 {
 		imgEls = append(imgEls, "title", string(n.Title))
 	}
 	hb.writeElementOpen("img", imgEls...)
+	if hasSources {
+		hb.writeElementClose("picture")
+	}
 	hb.writeElementClose("a")
 	return ast.WalkSkipChildren, nil
 }
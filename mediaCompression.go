@@ -5,6 +5,7 @@ import (
 	"crypto/sha256"
 	"errors"
 	"fmt"
+	"image"
 	"image/png"
 	"io"
 	"log"
@@ -20,6 +21,8 @@ const defaultCompressionHeight = 3000
 
 type mediaCompression interface {
 	compress(url string, save mediaStorageSaveFunc, hc *http.Client) (location string, err error)
+	// Resize to the width and convert to the format (empty keeps the format), empty location if not supported
+	resize(url string, width int, format string, save mediaStorageSaveFunc, hc *http.Client) (location string, err error)
 }
 
 func (a *goBlog) compressMediaFile(url string) (location string, err error) {
@@ -57,12 +60,43 @@ type tinify struct {
 }
 
 func (tf *tinify) compress(url string, upload mediaStorageSaveFunc, hc *http.Client) (string, error) {
-	tinifyErr := errors.New("failed to compress image using tinify")
 	// Check url
 	fileExtension, allowed := urlHasExt(url, "jpg", "jpeg", "png")
 	if !allowed {
 		return "", nil
 	}
+	return tf.process(url, fileExtension, map[string]any{
+		"resize": map[string]any{
+			"method": "fit",
+			"width":  defaultCompressionWidth,
+			"height": defaultCompressionHeight,
+		},
+	}, upload, hc)
+}
+
+func (tf *tinify) resize(url string, width int, format string, upload mediaStorageSaveFunc, hc *http.Client) (string, error) {
+	// Check url
+	fileExtension, allowed := urlHasExt(url, "jpg", "jpeg", "png")
+	if !allowed {
+		return "", nil
+	}
+	options := map[string]any{
+		"resize": map[string]any{
+			"method": "scale",
+			"width":  width,
+		},
+	}
+	if format != "" {
+		options["convert"] = map[string]any{
+			"type": "image/" + format,
+		}
+		fileExtension = format
+	}
+	return tf.process(url, fileExtension, options, upload, hc)
+}
+
+func (tf *tinify) process(url, fileExtension string, options map[string]any, upload mediaStorageSaveFunc, hc *http.Client) (string, error) {
+	tinifyErr := errors.New("failed to compress image using tinify")
 	// Compress
 	headers := http.Header{}
 	err := requests.
@@ -94,13 +128,7 @@ func (tf *tinify) compress(url string, upload mediaStorageSaveFunc, hc *http.Cli
 		Client(hc).
 		Method(http.MethodPost).
 		BasicAuth("api", tf.key).
-		BodyJSON(map[string]any{
-			"resize": map[string]any{
-				"method": "fit",
-				"width":  defaultCompressionWidth,
-				"height": defaultCompressionHeight,
-			},
-		}).
+		BodyJSON(options).
 		ToBytesBuffer(imgBuffer).
 		Fetch(context.Background())
 	if err != nil {
@@ -113,18 +141,31 @@ func (tf *tinify) compress(url string, upload mediaStorageSaveFunc, hc *http.Cli
 
 type cloudflare struct{}
 
-func (*cloudflare) compress(url string, upload mediaStorageSaveFunc, hc *http.Client) (string, error) {
+func (cf *cloudflare) compress(url string, upload mediaStorageSaveFunc, hc *http.Client) (string, error) {
+	// Force jpeg
+	return cf.process(url, "jpeg", fmt.Sprintf("f=jpeg,q=75,metadata=none,fit=scale-down,w=%d,h=%d", defaultCompressionWidth, defaultCompressionHeight), upload, hc)
+}
+
+func (cf *cloudflare) resize(url string, width int, format string, upload mediaStorageSaveFunc, hc *http.Client) (string, error) {
+	if format == "" {
+		format = "jpeg"
+	}
+	if format != "jpeg" && format != "webp" && format != "avif" {
+		return "", nil
+	}
+	return cf.process(url, format, fmt.Sprintf("f=%s,q=75,metadata=none,fit=scale-down,w=%d", format, width), upload, hc)
+}
+
+func (*cloudflare) process(url, fileExtension, options string, upload mediaStorageSaveFunc, hc *http.Client) (string, error) {
 	// Check url
 	if _, allowed := urlHasExt(url, "jpg", "jpeg", "png"); !allowed {
 		return "", nil
 	}
-	// Force jpeg
-	fileExtension := "jpeg"
 	// Compress
 	imgBuffer := bufferpool.Get()
 	defer bufferpool.Put(imgBuffer)
 	err := requests.
-		URL(fmt.Sprintf("https://www.cloudflare.com/cdn-cgi/image/f=jpeg,q=75,metadata=none,fit=scale-down,w=%d,h=%d/%s", defaultCompressionWidth, defaultCompressionHeight, url)).
+		URL(fmt.Sprintf("https://www.cloudflare.com/cdn-cgi/image/%s/%s", options, url)).
 		Client(hc).
 		ToBytesBuffer(imgBuffer).
 		Fetch(context.Background())
@@ -138,12 +179,31 @@ func (*cloudflare) compress(url string, upload mediaStorageSaveFunc, hc *http.Cl
 
 type localMediaCompressor struct{}
 
-func (*localMediaCompressor) compress(url string, upload mediaStorageSaveFunc, hc *http.Client) (string, error) {
+func (lc *localMediaCompressor) compress(url string, upload mediaStorageSaveFunc, hc *http.Client) (string, error) {
+	return lc.process(url, "", func(img image.Image) image.Image {
+		return imaging.Fit(img, defaultCompressionWidth, defaultCompressionHeight, imaging.Lanczos)
+	}, upload, hc)
+}
+
+func (lc *localMediaCompressor) resize(url string, width int, format string, upload mediaStorageSaveFunc, hc *http.Client) (string, error) {
+	if format != "" && format != "jpeg" && format != "png" {
+		// Modern formats are not supported
+		return "", nil
+	}
+	return lc.process(url, format, func(img image.Image) image.Image {
+		return imaging.Resize(img, width, 0, imaging.Lanczos)
+	}, upload, hc)
+}
+
+func (*localMediaCompressor) process(url, format string, transform func(image.Image) image.Image, upload mediaStorageSaveFunc, hc *http.Client) (string, error) {
 	// Check url
 	fileExtension, allowed := urlHasExt(url, "jpg", "jpeg", "png")
 	if !allowed {
 		return "", nil
 	}
+	if format != "" {
+		fileExtension = format
+	}
 	// Download image
 	imgBuffer := bufferpool.Get()
 	defer bufferpool.Put(imgBuffer)
@@ -163,7 +223,7 @@ func (*localMediaCompressor) compress(url string, upload mediaStorageSaveFunc, h
 		return "", errors.New("failed to compress image using local compressor")
 	}
 	// Resize image
-	resizedImage := imaging.Fit(img, defaultCompressionWidth, defaultCompressionHeight, imaging.Lanczos)
+	resizedImage := transform(img)
 	// Encode image
 	resizedBuffer := bufferpool.Get()
 	defer bufferpool.Put(resizedBuffer)
@@ -35,4 +35,19 @@ func Test_compress(t *testing.T) {
 		assert.Nil(t, err)
 		assert.Equal(t, "https://example.com/"+fakeSha256+".jpeg", res)
 	})
+	t.Run("Cloudflare resize", func(t *testing.T) {
+		fakeClient := newFakeHttpClient()
+		fakeClient.setHandler(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "https://www.cloudflare.com/cdn-cgi/image/f=webp,q=75,metadata=none,fit=scale-down,w=800/https://example.com/original.jpg", r.URL.String())
+
+			rw.WriteHeader(http.StatusOK)
+			_, _ = io.WriteString(rw, fakeFileContent)
+		}))
+
+		cf := &cloudflare{}
+		res, err := cf.resize("https://example.com/original.jpg", 800, "webp", uf, fakeClient.Client)
+
+		assert.Nil(t, err)
+		assert.Equal(t, "https://example.com/"+fakeSha256+".webp", res)
+	})
 }
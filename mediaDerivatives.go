@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"log"
+	"math"
+	"mime"
+	"net/http"
+	"path"
+	"sort"
+
+	"github.com/carlmjohnson/requests"
+	"github.com/samber/lo"
+	"go.goblog.app/app/pkgs/bufferpool"
+)
+
+// Value for the sizes attribute of responsive images, matches the maximum content width
+const responsiveImageSizes = "(max-width: 700px) 100vw, 700px"
+
+type mediaDerivative struct {
+	Location string
+	Width    int
+	Type     string
+}
+
+func (a *goBlog) mediaDerivativesEnabled() bool {
+	if a.cfg.Micropub == nil || a.cfg.Micropub.MediaStorage == nil {
+		return false
+	}
+	return len(a.cfg.Micropub.MediaStorage.ImageWidths) > 0
+}
+
+// Create resized and converted copies of the uploaded image at source.
+// The derivatives are recorded for location, the URL of the (compressed) image used in posts.
+func (a *goBlog) createMediaDerivatives(source, location string) error {
+	if !a.mediaDerivativesEnabled() {
+		return nil
+	}
+	if _, allowed := urlHasExt(source, "jpg", "jpeg", "png"); !allowed {
+		return nil
+	}
+	// Init compressors
+	a.compressorsInit.Do(a.initMediaCompressors)
+	if len(a.compressors) == 0 {
+		return nil
+	}
+	// Get dimensions of the original image
+	var imgConfig image.Config
+	err := requests.
+		URL(source).
+		Client(a.httpClient).
+		Handle(func(r *http.Response) (err error) {
+			imgConfig, _, err = image.DecodeConfig(r.Body)
+			return err
+		}).
+		Fetch(context.Background())
+	if err != nil {
+		return err
+	}
+	// Width of the image at location
+	fullWidth := imgConfig.Width
+	if location != source {
+		fullWidth, _ = fitDimensions(imgConfig.Width, imgConfig.Height, defaultCompressionWidth, defaultCompressionHeight)
+	}
+	derivatives := []*mediaDerivative{
+		{Location: location, Width: fullWidth, Type: mime.TypeByExtension(path.Ext(location))},
+	}
+	// Smaller widths, the image itself is the largest one
+	widths := lo.Filter(a.cfg.Micropub.MediaStorage.ImageWidths, func(w int, _ int) bool {
+		return w > 0 && w < fullWidth
+	})
+	sort.Ints(widths)
+	// Empty format keeps the format of the compressed image
+	for _, format := range append([]string{""}, a.cfg.Micropub.MediaStorage.ImageFormats...) {
+		formatWidths := widths
+		if format != "" {
+			// Modern formats also in full size
+			formatWidths = append(formatWidths, fullWidth)
+		}
+		for _, width := range formatWidths {
+			for _, c := range a.compressors {
+				loc, err := c.resize(source, width, format, a.saveMediaFile, a.httpClient)
+				if err != nil {
+					log.Printf("Failed to create media derivative: %v", err)
+					continue
+				}
+				if loc != "" {
+					derivatives = append(derivatives, &mediaDerivative{Location: loc, Width: width, Type: mime.TypeByExtension(path.Ext(loc))})
+					break
+				}
+			}
+		}
+	}
+	return a.db.saveMediaDerivatives(location, derivatives)
+}
+
+// Scale the dimensions down to fit into the maximum dimensions
+func fitDimensions(width, height, maxWidth, maxHeight int) (int, int) {
+	if width <= 0 || height <= 0 || (width <= maxWidth && height <= maxHeight) {
+		return width, height
+	}
+	if width*maxHeight > height*maxWidth {
+		return maxWidth, int(math.Round(float64(height) * float64(maxWidth) / float64(width)))
+	}
+	return int(math.Round(float64(width) * float64(maxHeight) / float64(height))), maxHeight
+}
+
+func (db *database) saveMediaDerivatives(original string, derivatives []*mediaDerivative) error {
+	if len(derivatives) <= 1 {
+		// Nothing more than the image itself
+		return nil
+	}
+	sqlBuilder := bufferpool.Get()
+	defer bufferpool.Put(sqlBuilder)
+	sqlArgs := []any{dbNoCache}
+	sqlBuilder.WriteString("begin;")
+	for _, d := range derivatives {
+		sqlBuilder.WriteString("insert or replace into media_derivatives (original, location, width, type) values (?, ?, ?, ?);")
+		sqlArgs = append(sqlArgs, original, d.Location, d.Width, d.Type)
+	}
+	sqlBuilder.WriteString("commit;")
+	_, err := db.exec(sqlBuilder.String(), sqlArgs...)
+	return err
+}
+
+func (db *database) getMediaDerivatives(original string) ([]*mediaDerivative, error) {
+	rows, err := db.query("select location, width, type from media_derivatives where original = @original order by type, width", sql.Named("original", original))
+	if err != nil {
+		return nil, err
+	}
+	var derivatives []*mediaDerivative
+	for rows.Next() {
+		d := &mediaDerivative{}
+		if err = rows.Scan(&d.Location, &d.Width, &d.Type); err != nil {
+			return nil, err
+		}
+		derivatives = append(derivatives, d)
+	}
+	return derivatives, nil
+}
+
+func (db *database) deleteMediaDerivatives(original string) error {
+	_, err := db.exec("delete from media_derivatives where original = @original", sql.Named("original", original))
+	return err
+}
+
+// Delete the derivative files of a deleted media file
+func (a *goBlog) deleteMediaDerivatives(original string) error {
+	if a.db == nil {
+		return nil
+	}
+	original = a.getFullAddress(original)
+	derivatives, err := a.db.getMediaDerivatives(original)
+	if err != nil {
+		return err
+	}
+	for _, d := range derivatives {
+		if d.Location == original {
+			continue
+		}
+		if err = a.deleteMediaFile(path.Base(d.Location)); err != nil {
+			return err
+		}
+	}
+	return a.db.deleteMediaDerivatives(original)
+}
+
+// Used by the Markdown renderer, errors just mean no responsive image
+func (a *goBlog) mediaDerivatives(url string) []*mediaDerivative {
+	if a.db == nil || !a.mediaDerivativesEnabled() {
+		return nil
+	}
+	derivatives, err := a.db.getMediaDerivatives(a.getFullAddress(url))
+	if err != nil {
+		return nil
+	}
+	return derivatives
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_fitDimensions(t *testing.T) {
+	w, h := fitDimensions(4000, 3000, 2000, 3000)
+	assert.Equal(t, 2000, w)
+	assert.Equal(t, 1500, h)
+	w, h = fitDimensions(1000, 6000, 2000, 3000)
+	assert.Equal(t, 500, w)
+	assert.Equal(t, 3000, h)
+	w, h = fitDimensions(2000, 6000, 2000, 3000)
+	assert.Equal(t, 1000, w)
+	assert.Equal(t, 3000, h)
+	w, h = fitDimensions(800, 600, 2000, 3000)
+	assert.Equal(t, 800, w)
+	assert.Equal(t, 600, h)
+}
+
+func Test_mediaDerivatives(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	app.cfg.Micropub.MediaStorage = &configMicropubMedia{
+		ImageWidths:  []int{400},
+		ImageFormats: []string{"webp"},
+	}
+	_ = app.initConfig()
+	_ = app.initDatabase(false)
+	defer app.db.close()
+	app.initComponents(false)
+
+	img := "https://example.com/m/image.jpeg"
+	err := app.db.saveMediaDerivatives(img, []*mediaDerivative{
+		{Location: img, Width: 1000, Type: "image/jpeg"},
+		{Location: "https://example.com/m/small.jpeg", Width: 400, Type: "image/jpeg"},
+		{Location: "https://example.com/m/small.webp", Width: 400, Type: "image/webp"},
+		{Location: "https://example.com/m/large.webp", Width: 1000, Type: "image/webp"},
+	})
+	require.NoError(t, err)
+
+	rendered, err := app.renderMarkdown("![Alt]("+img+")", false)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(rendered), "<picture>")
+	assert.Contains(t, string(rendered), `<source type="image/webp" srcset="https://example.com/m/small.webp 400w, https://example.com/m/large.webp 1000w" sizes="`+responsiveImageSizes+`">`)
+	assert.Contains(t, string(rendered), `srcset="https://example.com/m/small.jpeg 400w, https://example.com/m/image.jpeg 1000w"`)
+
+	// Other images stay unchanged
+	rendered, err = app.renderMarkdown("![Alt](https://example.com/other.jpg)", false)
+	require.NoError(t, err)
+
+	assert.NotContains(t, string(rendered), "<picture>")
+	assert.NotContains(t, string(rendered), "srcset")
+
+	// Deleting removes the records
+	require.NoError(t, app.db.deleteMediaDerivatives(img))
+	ds, err := app.db.getMediaDerivatives(img)
+	require.NoError(t, err)
+	assert.Empty(t, ds)
+}
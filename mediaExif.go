@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/disintegration/imaging"
+	"go.goblog.app/app/pkgs/bufferpool"
+	"go.goblog.app/app/pkgs/exif"
+)
+
+// Read the metadata of an uploaded image and remove sensitive metadata from the file in buffer.
+// If the metadata can't be removed, an error is returned and the file must not be saved.
+func (a *goBlog) processMediaExif(fileExtension string, buffer *bytes.Buffer) (*exif.Metadata, error) {
+	fileExtension = strings.ToLower(strings.TrimPrefix(fileExtension, "."))
+	if fileExtension != "jpg" && fileExtension != "jpeg" && fileExtension != "png" {
+		return nil, nil
+	}
+	mediaConfig := a.cfg.Micropub.MediaStorage
+	keep, withLocation := false, false
+	if mediaConfig != nil {
+		keep, withLocation = mediaConfig.KeepExif, mediaConfig.ExifLocation
+	}
+	metadata, err := exif.Read(buffer.Bytes())
+	if err != nil {
+		metadata = nil
+	} else if !withLocation {
+		metadata.HasLocation, metadata.Latitude, metadata.Longitude = false, 0, 0
+	}
+	if keep {
+		return metadata, nil
+	}
+	data, description := buffer.Bytes(), ""
+	if metadata != nil {
+		description = metadata.Description
+		if metadata.Orientation > 1 && fileExtension != "png" {
+			// Apply orientation, because it gets removed
+			rotated, err := rotateImage(data)
+			if err != nil {
+				return nil, errors.New("failed to apply image orientation: " + err.Error())
+			}
+			data = rotated
+		}
+	}
+	stripped, err := exif.Strip(data, description)
+	if err != nil {
+		return nil, errors.New("failed to remove image metadata: " + err.Error())
+	}
+	buffer.Reset()
+	_, _ = buffer.Write(stripped)
+	return metadata, nil
+}
+
+func rotateImage(data []byte) ([]byte, error) {
+	img, err := imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
+	if err != nil {
+		return nil, err
+	}
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+	if err = imaging.Encode(buf, img, imaging.JPEG, imaging.JPEGQuality(95)); err != nil {
+		return nil, err
+	}
+	return append([]byte{}, buf.Bytes()...), nil
+}
+
+func (db *database) saveMediaExif(locations []string, m *exif.Metadata) error {
+	if m == nil {
+		return nil
+	}
+	captured := ""
+	if !m.Time.IsZero() {
+		captured = m.Time.Format(time.RFC3339)
+	}
+	var lat, lon sql.NullFloat64
+	if m.HasLocation {
+		lat = sql.NullFloat64{Float64: m.Latitude, Valid: true}
+		lon = sql.NullFloat64{Float64: m.Longitude, Valid: true}
+	}
+	for _, location := range locations {
+		_, err := db.exec(
+			"insert or replace into media_exif (location, captured, camera, caption, lat, lon) values (@location, @captured, @camera, @caption, @lat, @lon)",
+			sql.Named("location", location), sql.Named("captured", captured), sql.Named("camera", m.Camera()),
+			sql.Named("caption", m.Description), sql.Named("lat", lat), sql.Named("lon", lon),
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (db *database) getMediaExif(location string) (*exif.Metadata, error) {
+	row, err := db.queryRow("select captured, camera, caption, lat, lon from media_exif where location = @location", sql.Named("location", location))
+	if err != nil {
+		return nil, err
+	}
+	var captured string
+	var lat, lon sql.NullFloat64
+	m := &exif.Metadata{}
+	if err = row.Scan(&captured, &m.Model, &m.Description, &lat, &lon); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if captured != "" {
+		m.Time, _ = time.Parse(time.RFC3339, captured)
+	}
+	if lat.Valid && lon.Valid {
+		m.HasLocation, m.Latitude, m.Longitude = true, lat.Float64, lon.Float64
+	}
+	return m, nil
+}
+
+func (a *goBlog) mediaExif(url string) *exif.Metadata {
+	if a.db == nil {
+		return nil
+	}
+	m, err := a.db.getMediaExif(a.getFullAddress(url))
+	if err != nil {
+		return nil
+	}
+	return m
+}
+
+// Use captions and location of uploaded photos for missing post parameters
+func (a *goBlog) addPhotoMetadataParameters(p *post) {
+	images := p.Parameters[a.cfg.Micropub.PhotoParam]
+	fillAlts := len(p.Parameters[a.cfg.Micropub.PhotoDescriptionParam]) == 0
+	alts, hasAlts := make([]string, len(images)), false
+	for i, image := range images {
+		m := a.mediaExif(image)
+		if m == nil {
+			continue
+		}
+		if fillAlts && m.Description != "" {
+			alts[i], hasAlts = m.Description, true
+		}
+		if m.HasLocation && len(p.Parameters[a.cfg.Micropub.LocationParam]) == 0 {
+			p.Parameters[a.cfg.Micropub.LocationParam] = []string{
+				"geo:" + strconv.FormatFloat(m.Latitude, 'f', -1, 64) + "," + strconv.FormatFloat(m.Longitude, 'f', -1, 64),
+			}
+		}
+	}
+	if hasAlts {
+		p.Parameters[a.cfg.Micropub.PhotoDescriptionParam] = alts
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.goblog.app/app/pkgs/exif"
+)
+
+func Test_mediaExif(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	app.cfg.Micropub.MediaStorage = &configMicropubMedia{
+		ExifLocation: true,
+	}
+	_ = app.initConfig()
+	_ = app.initDatabase(false)
+	defer app.db.close()
+	app.initComponents(false)
+
+	t.Run("Process upload", func(t *testing.T) {
+		var img bytes.Buffer
+		require.NoError(t, jpeg.Encode(&img, image.NewGray(image.Rect(0, 0, 4, 4)), nil))
+		withCaption, err := exif.Strip(img.Bytes(), "Caption")
+		require.NoError(t, err)
+
+		buf := bytes.NewBuffer(withCaption)
+		m, err := app.processMediaExif(".jpg", buf)
+		require.NoError(t, err)
+		require.NotNil(t, m)
+		assert.Equal(t, "Caption", m.Description)
+
+		// Caption is kept in the file
+		m, err = exif.Read(buf.Bytes())
+		require.NoError(t, err)
+		assert.Equal(t, "Caption", m.Description)
+
+		// Other files are untouched
+		m, err = app.processMediaExif(".mp3", bytes.NewBufferString("test"))
+		assert.NoError(t, err)
+		assert.Nil(t, m)
+	})
+
+	t.Run("Reject malformed image", func(t *testing.T) {
+		app.cfg.Micropub.MediaStorage.ExifLocation = false
+		defer func() { app.cfg.Micropub.MediaStorage.ExifLocation = true }()
+		mediaDir := t.TempDir()
+		app.mediaStorageInit.Do(func() {
+			app.mediaStorage = &localMediaStorage{path: mediaDir}
+		})
+
+		// GPS metadata followed by a broken segment, so the metadata can be read, but not removed
+		malformed := testGPSJpegSegment()
+		malformed = append(malformed, 0xFF, 0xDB, 0xFF, 0xFF, 0x00)
+		m, err := exif.Read(malformed)
+		require.NoError(t, err)
+		require.True(t, m.HasLocation)
+
+		buf := bytes.NewBuffer(malformed)
+		_, err = app.processMediaExif(".jpg", buf)
+		assert.Error(t, err)
+
+		// Upload is rejected and nothing is saved
+		body := &bytes.Buffer{}
+		mw := multipart.NewWriter(body)
+		fw, err := mw.CreateFormFile("file", "photo.jpg")
+		require.NoError(t, err)
+		_, _ = fw.Write(malformed)
+		require.NoError(t, mw.Close())
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:8080/micropub/media", body)
+		req.Header.Set(contentType, mw.FormDataContentType())
+		req = req.WithContext(context.WithValue(req.Context(), indieAuthScope, "media"))
+		rec := httptest.NewRecorder()
+		app.serveMicropubMedia(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		files, err := os.ReadDir(mediaDir)
+		require.NoError(t, err)
+		assert.Empty(t, files)
+	})
+
+	t.Run("Fill post parameters", func(t *testing.T) {
+		photo := "https://example.com/m/photo.jpg"
+		require.NoError(t, app.db.saveMediaExif([]string{photo}, &exif.Metadata{
+			Make:        "Fujifilm",
+			Model:       "X-T3",
+			Description: "Sunset",
+			HasLocation: true,
+			Latitude:    52.5,
+			Longitude:   13.25,
+		}))
+
+		p := &post{
+			Content: "Test",
+			Parameters: map[string][]string{
+				"images": {photo, "https://example.com/other.jpg"},
+			},
+		}
+		require.NoError(t, app.computeExtraPostParameters(p))
+
+		assert.Equal(t, []string{"geo:52.5,13.25"}, p.Parameters["location"])
+		assert.Equal(t, []string{"Sunset", ""}, p.Parameters["imagealts"])
+		assert.Contains(t, p.Content, `![Sunset](`+photo+` "Sunset")`)
+
+		// Existing location is kept
+		p = &post{
+			Parameters: map[string][]string{
+				"images":   {photo},
+				"location": {"geo:1,1"},
+			},
+		}
+		require.NoError(t, app.computeExtraPostParameters(p))
+		assert.Equal(t, []string{"geo:1,1"}, p.Parameters["location"])
+
+		// Camera in photo summary
+		buf := &bytes.Buffer{}
+		app.renderPhotoMetadata(newHtmlBuilder(buf), photo)
+		assert.Contains(t, buf.String(), "Fujifilm X-T3")
+	})
+}
+
+// JPEG start and EXIF segment with the location 52.5, 13.25
+func testGPSJpegSegment() []byte {
+	var tiff bytes.Buffer
+	w := func(v any) { _ = binary.Write(&tiff, binary.BigEndian, v) }
+	tiff.WriteString("MM\x00\x2a")
+	w(uint32(8))
+	// IFD0 with GPS IFD pointer
+	w(uint16(1))
+	w([]uint16{0x8825, 4})
+	w([]uint32{1, 26, 0})
+	// GPS IFD, values after the IFD at 80
+	w(uint16(4))
+	w([]uint16{0x0001, 2})
+	w([]uint32{2})
+	tiff.WriteString("N\x00\x00\x00")
+	w([]uint16{0x0002, 5})
+	w([]uint32{3, 80})
+	w([]uint16{0x0003, 2})
+	w([]uint32{2})
+	tiff.WriteString("E\x00\x00\x00")
+	w([]uint16{0x0004, 5})
+	w([]uint32{3, 104})
+	w(uint32(0))
+	w([]uint32{52, 1, 30, 1, 0, 1, 13, 1, 15, 1, 0, 1})
+	segment := []byte{0xFF, 0xD8, 0xFF, 0xE1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(2+6+tiff.Len()))
+	segment = append(segment, "Exif\x00\x00"...)
+	return append(segment, tiff.Bytes()...)
+}
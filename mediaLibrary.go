@@ -0,0 +1,311 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"image"
+	_ "image/gif"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"go.goblog.app/app/pkgs/bufferpool"
+	"go.goblog.app/app/pkgs/exif"
+)
+
+type mediaRecord struct {
+	Name         string
+	Location     string
+	OriginalName string
+	MimeType     string
+	Width        int
+	Height       int
+	Size         int64
+	Uploader     string
+	Alt          string
+	Caption      string
+	Uploaded     time.Time
+	Compressed   string // Name of the compressed version
+	Tags         []string
+}
+
+func (m *mediaRecord) isImage() bool {
+	return strings.HasPrefix(m.MimeType, "image/")
+}
+
+// Markdown to embed the file in a post
+func (m *mediaRecord) markdown() string {
+	if !m.isImage() {
+		return "[" + defaultIfEmpty(m.OriginalName, m.Name) + "](" + m.Location + ")"
+	}
+	md := "![" + m.Alt + "](" + m.Location
+	if m.Caption != "" {
+		md += " \"" + strings.ReplaceAll(m.Caption, "\"", "'") + "\""
+	}
+	return md + ")"
+}
+
+// Record of a new upload, dimensions are read from the data if it's an image
+func newMediaRecord(name, location, originalName string, data []byte) *mediaRecord {
+	m := &mediaRecord{
+		Name:         name,
+		Location:     location,
+		OriginalName: originalName,
+		MimeType:     mime.TypeByExtension(path.Ext(name)),
+		Size:         int64(len(data)),
+		Uploaded:     time.Now(),
+	}
+	if m.isImage() {
+		if imgConfig, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+			m.Width, m.Height = imgConfig.Width, imgConfig.Height
+		}
+	}
+	return m
+}
+
+// Record the uploaded file and its compressed version in the media library
+func (a *goBlog) saveMediaUpload(r *http.Request, originalName, originalLocation, location string, data []byte, metadata *exif.Metadata) {
+	uploader, _ := r.Context().Value(indieAuthClient).(string)
+	if uploader == "" {
+		uploader = a.cfg.User.Nick
+	}
+	original := newMediaRecord(path.Base(originalLocation), originalLocation, originalName, data)
+	original.Uploader = uploader
+	if metadata != nil {
+		original.Caption = metadata.Description
+	}
+	records := []*mediaRecord{original}
+	if location != originalLocation {
+		// The compressed version is scaled to fit, the size is only known to the storage
+		compressed := *original
+		compressed.Name, compressed.Location, compressed.Size = path.Base(location), location, 0
+		compressed.MimeType = mime.TypeByExtension(path.Ext(compressed.Name))
+		compressed.Width, compressed.Height = fitDimensions(original.Width, original.Height, defaultCompressionWidth, defaultCompressionHeight)
+		original.Compressed = compressed.Name
+		records = append(records, &compressed)
+	}
+	for _, m := range records {
+		if err := a.db.saveMediaRecord(m); err != nil {
+			log.Println("Failed to save media record:", err.Error())
+		}
+	}
+}
+
+func (db *database) saveMediaRecord(m *mediaRecord) error {
+	_, err := db.exec(
+		"insert or replace into media (name, location, original_name, mime_type, width, height, size, uploader, alt, caption, uploaded, compressed) values (@name, @location, @original, @mime, @width, @height, @size, @uploader, @alt, @caption, @uploaded, @compressed)",
+		sql.Named("name", m.Name), sql.Named("location", m.Location), sql.Named("original", m.OriginalName),
+		sql.Named("mime", m.MimeType), sql.Named("width", m.Width), sql.Named("height", m.Height),
+		sql.Named("size", m.Size), sql.Named("uploader", m.Uploader), sql.Named("alt", m.Alt),
+		sql.Named("caption", m.Caption), sql.Named("uploaded", m.Uploaded.UTC().Format(time.RFC3339)),
+		sql.Named("compressed", m.Compressed),
+	)
+	return err
+}
+
+// Update alt text, caption and tags, creates the record if it doesn't exist.
+// Alt text and caption are also set for the compressed version.
+func (db *database) updateMediaRecord(name, location, alt, caption string, tags []string) error {
+	tags = lo.Uniq(lo.Filter(lo.Map(tags, func(t string, _ int) string {
+		return strings.TrimSpace(t)
+	}), loStringNotEmpty))
+	sqlBuilder := bufferpool.Get()
+	defer bufferpool.Put(sqlBuilder)
+	sqlArgs := []any{dbNoCache}
+	sqlBuilder.WriteString("begin;")
+	sqlBuilder.WriteString("insert or ignore into media (name, location, mime_type) values (?, ?, ?);")
+	sqlArgs = append(sqlArgs, name, location, mime.TypeByExtension(path.Ext(name)))
+	sqlBuilder.WriteString("update media set alt = ?, caption = ? where name = ? or name in (select compressed from media where name = ?);")
+	sqlArgs = append(sqlArgs, alt, caption, name, name)
+	sqlBuilder.WriteString("delete from media_tags where name = ?;")
+	sqlArgs = append(sqlArgs, name)
+	for _, tag := range tags {
+		sqlBuilder.WriteString("insert into media_tags (name, tag) values (?, ?);")
+		sqlArgs = append(sqlArgs, name, tag)
+	}
+	sqlBuilder.WriteString("commit;")
+	_, err := db.exec(sqlBuilder.String(), sqlArgs...)
+	return err
+}
+
+func (db *database) deleteMediaRecord(name string) error {
+	_, err := db.exec("begin; delete from media_tags where name = ?; delete from media where name = ?; commit;", dbNoCache, name, name)
+	return err
+}
+
+// Sizes of the files in the media library with the given locations
+func (db *database) getMediaSizes(locations []string) (map[string]int64, error) {
+	sizes := map[string]int64{}
+	if len(locations) == 0 {
+		return sizes, nil
+	}
+	args := make([]any, 0, len(locations))
+	named := make([]string, 0, len(locations))
+	for i, location := range locations {
+		name := "location" + strconv.Itoa(i)
+		named = append(named, "@"+name)
+		args = append(args, sql.Named(name, location))
+	}
+	rows, err := db.query("select location, size from media where location in ("+strings.Join(named, ", ")+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	var location string
+	var size int64
+	for rows.Next() {
+		if err = rows.Scan(&location, &size); err != nil {
+			return nil, err
+		}
+		sizes[location] = size
+	}
+	return sizes, rows.Err()
+}
+
+func (db *database) getMediaRecords() (map[string]*mediaRecord, error) {
+	rows, err := db.query("select name, location, original_name, mime_type, width, height, size, uploader, alt, caption, uploaded, compressed from media")
+	if err != nil {
+		return nil, err
+	}
+	records := map[string]*mediaRecord{}
+	var uploaded string
+	for rows.Next() {
+		m := &mediaRecord{}
+		if err = rows.Scan(&m.Name, &m.Location, &m.OriginalName, &m.MimeType, &m.Width, &m.Height, &m.Size, &m.Uploader, &m.Alt, &m.Caption, &uploaded, &m.Compressed); err != nil {
+			return nil, err
+		}
+		m.Uploaded, _ = time.Parse(time.RFC3339, uploaded)
+		records[m.Name] = m
+	}
+	rows, err = db.query("select name, tag from media_tags order by tag")
+	if err != nil {
+		return nil, err
+	}
+	var name, tag string
+	for rows.Next() {
+		if err = rows.Scan(&name, &tag); err != nil {
+			return nil, err
+		}
+		if m, ok := records[name]; ok {
+			m.Tags = append(m.Tags, tag)
+		}
+	}
+	return records, nil
+}
+
+// Recorded uploads for the media picker in the editor, newest first
+func (a *goBlog) editorMedia() []*mediaRecord {
+	records, err := a.db.getMediaRecords()
+	if err != nil {
+		log.Println("Failed to get media records:", err.Error())
+		return nil
+	}
+	media := make([]*mediaRecord, 0, len(records))
+	for _, m := range records {
+		if m.Compressed != "" {
+			// Posts should use the compressed version
+			continue
+		}
+		media = append(media, m)
+	}
+	sort.Slice(media, func(i, j int) bool {
+		if !media[i].Uploaded.Equal(media[j].Uploaded) {
+			return media[i].Uploaded.After(media[j].Uploaded)
+		}
+		return media[i].Name < media[j].Name
+	})
+	return media
+}
+
+type mediaLibraryFilter struct {
+	query   string
+	typ     string // image, audio, video or other
+	tag     string
+	missAlt bool
+}
+
+type mediaLibraryItem struct {
+	*mediaRecord
+	Time time.Time
+	Uses int
+}
+
+// All files of the media storage with their metadata, sorted by time desc
+func (a *goBlog) mediaLibrary(filter *mediaLibraryFilter) (items []*mediaLibraryItem, tags []string, err error) {
+	files, err := a.mediaFiles()
+	if err != nil || len(files) == 0 {
+		return nil, nil, err
+	}
+	records, err := a.db.getMediaRecords()
+	if err != nil {
+		return nil, nil, err
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Time.After(files[j].Time)
+	})
+	uses, err := a.db.usesOfMediaFile(lo.Map(files, func(f *mediaFile, _ int) string {
+		return f.Name
+	})...)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, f := range files {
+		record, ok := records[f.Name]
+		if !ok {
+			record = &mediaRecord{
+				Name:     f.Name,
+				MimeType: mime.TypeByExtension(path.Ext(f.Name)),
+			}
+		}
+		// Storage is the source of truth for location and size
+		record.Location, record.Size = a.getFullAddress(f.Location), f.Size
+		tags = append(tags, record.Tags...)
+		item := &mediaLibraryItem{mediaRecord: record, Time: f.Time, Uses: uses[i]}
+		if filter == nil || filter.matches(item) {
+			items = append(items, item)
+		}
+	}
+	tags = lo.Uniq(tags)
+	sort.Strings(tags)
+	return items, tags, nil
+}
+
+func (f *mediaLibraryFilter) matches(item *mediaLibraryItem) bool {
+	if f.query != "" {
+		query := strings.ToLower(f.query)
+		found := false
+		for _, s := range append([]string{item.Name, item.OriginalName, item.Alt, item.Caption}, item.Tags...) {
+			if strings.Contains(strings.ToLower(s), query) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.typ != "" && mediaType(item.MimeType) != f.typ {
+		return false
+	}
+	if f.tag != "" && !lo.Contains(item.Tags, f.tag) {
+		return false
+	}
+	if f.missAlt && (!item.isImage() || item.Alt != "") {
+		return false
+	}
+	return true
+}
+
+func mediaType(mimeType string) string {
+	for _, t := range []string{"image", "audio", "video"} {
+		if strings.HasPrefix(mimeType, t+"/") {
+			return t
+		}
+	}
+	return "other"
+}
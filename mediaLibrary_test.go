@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.goblog.app/app/pkgs/contenttype"
+)
+
+func Test_mediaLibrary(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	_ = app.initConfig()
+	_ = app.initDatabase(false)
+	defer app.db.close()
+	app.initComponents(false)
+
+	// Use temporary media storage
+	mediaDir := t.TempDir()
+	app.mediaStorageInit.Do(func() {
+		app.mediaStorage = &localMediaStorage{path: mediaDir}
+	})
+
+	// Upload an image
+	imgBuf := &bytes.Buffer{}
+	require.NoError(t, png.Encode(imgBuf, image.NewRGBA(image.Rect(0, 0, 40, 30))))
+	require.NoError(t, os.WriteFile(filepath.Join(mediaDir, "img.png"), imgBuf.Bytes(), 0644))
+	req := httptest.NewRequest(http.MethodPost, "/micropub/media", nil)
+	req = req.WithContext(context.WithValue(req.Context(), indieAuthClient, "https://client.example.com/"))
+	app.saveMediaUpload(req, "Holiday.png", "/m/img.png", "/m/img.png", imgBuf.Bytes(), nil)
+	// Other files without record
+	require.NoError(t, os.WriteFile(filepath.Join(mediaDir, "song.mp3"), []byte("mp3"), 0644))
+
+	t.Run("Upload record", func(t *testing.T) {
+		records, err := app.db.getMediaRecords()
+		require.NoError(t, err)
+		m := records["img.png"]
+		require.NotNil(t, m)
+		assert.Equal(t, "Holiday.png", m.OriginalName)
+		assert.Equal(t, "image/png", m.MimeType)
+		assert.Equal(t, 40, m.Width)
+		assert.Equal(t, 30, m.Height)
+		assert.Equal(t, "https://client.example.com/", m.Uploader)
+		assert.Equal(t, "![](/m/img.png)", m.markdown())
+	})
+
+	t.Run("Missing alt text", func(t *testing.T) {
+		items, _, err := app.mediaLibrary(&mediaLibraryFilter{missAlt: true})
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, "img.png", items[0].Name)
+	})
+
+	t.Run("Edit", func(t *testing.T) {
+		values := url.Values{"filename": {"img.png"}, "alt": {"A beach"}, "caption": {"Summer \"2022\""}, "tags": {"holiday, beach,,holiday"}}
+		req := httptest.NewRequest(http.MethodPost, "/editor/files/edit", strings.NewReader(values.Encode()))
+		req.Header.Set(contentType, contenttype.WWWForm)
+		rec := httptest.NewRecorder()
+		app.serveEditorFilesEdit(rec, req)
+		assert.Equal(t, http.StatusFound, rec.Code)
+
+		records, err := app.db.getMediaRecords()
+		require.NoError(t, err)
+		m := records["img.png"]
+		assert.Equal(t, "A beach", m.Alt)
+		assert.Equal(t, []string{"beach", "holiday"}, m.Tags)
+		assert.Equal(t, "Holiday.png", m.OriginalName)
+		assert.Equal(t, "![A beach](/m/img.png \"Summer '2022'\")", m.markdown())
+
+		// File without record gets one
+		require.NoError(t, app.db.updateMediaRecord("song.mp3", "/m/song.mp3", "", "", []string{"music"}))
+		records, err = app.db.getMediaRecords()
+		require.NoError(t, err)
+		m = records["song.mp3"]
+		assert.Equal(t, "audio/mpeg", m.MimeType)
+		assert.Equal(t, []string{"music"}, m.Tags)
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		items, tags, err := app.mediaLibrary(nil)
+		require.NoError(t, err)
+		assert.Len(t, items, 2)
+		assert.Equal(t, []string{"beach", "holiday", "music"}, tags)
+
+		items, _, err = app.mediaLibrary(&mediaLibraryFilter{query: "BEACH"})
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, "img.png", items[0].Name)
+
+		items, _, err = app.mediaLibrary(&mediaLibraryFilter{typ: "audio"})
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, "song.mp3", items[0].Name)
+
+		items, _, err = app.mediaLibrary(&mediaLibraryFilter{tag: "music", typ: "image"})
+		require.NoError(t, err)
+		assert.Len(t, items, 0)
+
+		items, _, err = app.mediaLibrary(&mediaLibraryFilter{missAlt: true})
+		require.NoError(t, err)
+		assert.Len(t, items, 0)
+	})
+
+	t.Run("Files page", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/editor/files?q=holiday", nil)
+		rec := httptest.NewRecorder()
+		app.serveEditorFiles(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		body := rec.Body.String()
+		assert.Contains(t, body, "Holiday.png")
+		assert.Contains(t, body, "40×30")
+		assert.Contains(t, body, "A beach")
+		assert.NotContains(t, body, "song.mp3")
+	})
+
+	t.Run("Editor picker", func(t *testing.T) {
+		media := app.editorMedia()
+		require.Len(t, media, 2)
+		assert.Equal(t, "img.png", media[0].Name)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		// Responsive derivatives are deleted too
+		require.NoError(t, os.WriteFile(filepath.Join(mediaDir, "img-20.webp"), []byte("webp"), 0644))
+		require.NoError(t, app.db.saveMediaDerivatives("http://localhost:8080/m/img.png", []*mediaDerivative{
+			{Location: "http://localhost:8080/m/img.png", Width: 40, Type: "image/png"},
+			{Location: "http://localhost:8080/m/img-20.webp", Width: 20, Type: "image/webp"},
+		}))
+
+		req := httptest.NewRequest(http.MethodPost, "/editor/files/delete", strings.NewReader("filename=img.png"))
+		req.Header.Set(contentType, contenttype.WWWForm)
+		rec := httptest.NewRecorder()
+		app.serveEditorFilesDelete(rec, req)
+		assert.Equal(t, http.StatusFound, rec.Code)
+
+		records, err := app.db.getMediaRecords()
+		require.NoError(t, err)
+		assert.Nil(t, records["img.png"])
+		assert.NoFileExists(t, filepath.Join(mediaDir, "img.png"))
+		assert.NoFileExists(t, filepath.Join(mediaDir, "img-20.webp"))
+		derivatives, err := app.db.getMediaDerivatives("http://localhost:8080/m/img.png")
+		require.NoError(t, err)
+		assert.Empty(t, derivatives)
+	})
+
+	t.Run("Compressed version", func(t *testing.T) {
+		app.saveMediaUpload(req, "Big.png", "/m/big.png", "/m/big.jpg", imgBuf.Bytes(), nil)
+		require.NoError(t, app.db.updateMediaRecord("big.png", "/m/big.png", "A mountain", "Winter", nil))
+
+		// The editor shows the compressed version with the alt text of the original
+		media := app.editorMedia()
+		m, ok := lo.Find(media, func(m *mediaRecord) bool { return m.Name == "big.jpg" })
+		require.True(t, ok)
+		assert.Equal(t, "A mountain", m.Alt)
+		assert.Equal(t, "Winter", m.Caption)
+	})
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/carlmjohnson/requests"
+	"github.com/samber/lo"
+	"go.goblog.app/app/pkgs/bufferpool"
+)
+
+var mediaStorageNames = []string{"local", "s3", "bunny", "sftp", "webdav", "ftp"}
+
+// Create a configured media storage by name, optionally with a different media URL
+func (a *goBlog) mediaStorageByName(name, mediaURL string) (mediaStorage, error) {
+	inits := map[string]func() mediaStorage{
+		"local":  a.initLocalMediaStorage,
+		"s3":     a.initS3MediaStorage,
+		"bunny":  a.initBunnyCdnMediaStorage,
+		"sftp":   a.initSftpMediaStorage,
+		"webdav": a.initWebdavMediaStorage,
+		"ftp":    a.initFtpMediaStorage,
+	}
+	init, ok := inits[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown media storage %q, available: %s", name, strings.Join(mediaStorageNames, ", "))
+	}
+	// Use a copy of the config to override the media URL
+	original := a.cfg.Micropub.MediaStorage
+	mediaConfig := &configMicropubMedia{}
+	if original != nil {
+		*mediaConfig = *original
+	}
+	if mediaURL != "" {
+		mediaConfig.MediaURL = strings.TrimSuffix(mediaURL, "/")
+	}
+	a.cfg.Micropub.MediaStorage = mediaConfig
+	defer func() {
+		a.cfg.Micropub.MediaStorage = original
+	}()
+	ms := init()
+	if ms == nil {
+		return nil, fmt.Errorf("media storage %q is not configured", name)
+	}
+	return ms, nil
+}
+
+type mediaMigrationResult struct {
+	files        int
+	replacements map[string]string
+	posts        []string
+}
+
+// Copy all files from one media storage to another and update the references in posts
+func (a *goBlog) migrateMedia(from, to mediaStorage, dryRun bool) (*mediaMigrationResult, error) {
+	files, err := from.files()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+	result := &mediaMigrationResult{replacements: map[string]string{}}
+	for _, f := range files {
+		newLocation := to.location(f.Name)
+		if !dryRun {
+			if newLocation, err = a.copyMediaFile(from, to, f); err != nil {
+				return result, fmt.Errorf("failed to copy %s: %w", f.Name, err)
+			}
+		}
+		result.files++
+		if f.Location == newLocation {
+			continue
+		}
+		result.replacements[a.getFullAddress(f.Location)] = a.getFullAddress(newLocation)
+		if !isAbsoluteURL(f.Location) {
+			// Posts can also use the relative URL
+			result.replacements[f.Location] = newLocation
+		}
+	}
+	result.posts, err = a.replaceMediaURLs(result.replacements, dryRun)
+	return result, err
+}
+
+func (a *goBlog) copyMediaFile(from, to mediaStorage, f *mediaFile) (string, error) {
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+	if err := a.readMediaFile(from, f.Name, buf); err != nil {
+		return "", err
+	}
+	return to.save(f.Name, buf)
+}
+
+// Read a file of the media storage, local files are read directly because the server might not be running
+func (a *goBlog) readMediaFile(ms mediaStorage, name string, w io.Writer) error {
+	if local, ok := ms.(*localMediaStorage); ok {
+		file, err := os.Open(filepath.Join(local.path, filepath.Base(name)))
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(w, file)
+		_ = file.Close()
+		return err
+	}
+	return requests.URL(a.getFullAddress(ms.location(name))).Client(a.httpClient).ToWriter(w).Fetch(context.Background())
+}
+
+// Replace media URLs in post content and parameters, returns the paths of changed posts
+func (a *goBlog) replaceMediaURLs(replacements map[string]string, dryRun bool) ([]string, error) {
+	if len(replacements) == 0 {
+		return nil, nil
+	}
+	// Replace longer URLs first, relative URLs are part of absolute ones
+	olds := make([]string, 0, len(replacements))
+	for old := range replacements {
+		olds = append(olds, old)
+	}
+	sort.Slice(olds, func(i, j int) bool {
+		if len(olds[i]) != len(olds[j]) {
+			return len(olds[i]) > len(olds[j])
+		}
+		return olds[i] < olds[j]
+	})
+	pairs := make([]string, 0, 2*len(olds))
+	for _, old := range olds {
+		pairs = append(pairs, old, replacements[old])
+	}
+	replacer := strings.NewReplacer(pairs...)
+	posts, err := a.getPosts(&postsRequestConfig{
+		withoutRenderedTitle: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var changed []string
+	for _, p := range posts {
+		newContent := replacer.Replace(p.Content)
+		newParams := map[string][]string{}
+		for param, values := range p.Parameters {
+			newValues := make([]string, len(values))
+			paramChanged := false
+			for i, v := range values {
+				newValues[i] = replacer.Replace(v)
+				paramChanged = paramChanged || newValues[i] != v
+			}
+			if paramChanged {
+				newParams[param] = newValues
+			}
+		}
+		if newContent == p.Content && len(newParams) == 0 {
+			continue
+		}
+		if !dryRun {
+			if err = a.db.replacePostMedia(p.Path, newContent, newParams); err != nil {
+				return changed, err
+			}
+		}
+		changed = append(changed, p.Path)
+	}
+	if !dryRun {
+		// Update FTS index
+		a.db.rebuildFTSIndex()
+		// Update media metadata
+		for _, old := range olds {
+			if err = a.db.replaceMediaLocation(old, replacements[old]); err != nil {
+				return changed, err
+			}
+		}
+	}
+	return changed, nil
+}
+
+// Replace content and the given parameters of a post in a single transaction, the FTS index isn't updated
+func (db *database) replacePostMedia(path, content string, params map[string][]string) error {
+	// Lock post creation
+	db.pcm.Lock()
+	defer db.pcm.Unlock()
+	// Build SQL
+	sqlBuilder := bufferpool.Get()
+	defer bufferpool.Put(sqlBuilder)
+	var sqlArgs = []any{dbNoCache}
+	// Start transaction
+	sqlBuilder.WriteString("begin;")
+	sqlBuilder.WriteString("update posts set content = ? where path = ?;")
+	sqlArgs = append(sqlArgs, content, path)
+	// Sorted to keep the order of the parameters stable
+	paramNames := lo.Keys(params)
+	sort.Strings(paramNames)
+	for _, param := range paramNames {
+		sqlBuilder.WriteString("delete from post_parameters where path = ? and parameter = ?;")
+		sqlArgs = append(sqlArgs, path, param)
+		for _, value := range lo.Filter(params[param], loStringNotEmpty) {
+			sqlBuilder.WriteString("insert into post_parameters (path, parameter, value) values (?, ?, ?);")
+			sqlArgs = append(sqlArgs, path, param, value)
+		}
+	}
+	// Commit transaction
+	sqlBuilder.WriteString("commit;")
+	_, err := db.exec(sqlBuilder.String(), sqlArgs...)
+	return err
+}
+
+func (db *database) replaceMediaLocation(oldLocation, newLocation string) error {
+	_, err := db.exec(
+		"begin;"+
+			"update or replace media_exif set location = ? where location = ?;"+
+			"update or replace media_derivatives set original = ? where original = ?;"+
+			"update or replace media_derivatives set location = ? where location = ?;"+
+			"update media set location = ? where location = ?;"+
+			"update or replace media_videos set location = ? where location = ?;"+
+			"update media_videos set playlist = ? where playlist = ?;"+
+			"update media_videos set poster = ? where poster = ?;"+
+			"update or replace media_audio set location = ? where location = ?;"+
+			"commit;",
+		dbNoCache, newLocation, oldLocation, newLocation, oldLocation, newLocation, oldLocation, newLocation, oldLocation,
+		newLocation, oldLocation, newLocation, oldLocation, newLocation, oldLocation, newLocation, oldLocation,
+	)
+	return err
+}
+
+// Command line tool: media migrate --from X --to Y
+func (a *goBlog) mediaMigrateCommand(args []string) error {
+	fs := flag.NewFlagSet("media migrate", flag.ContinueOnError)
+	from := fs.String("from", "", "media storage to copy the files from ("+strings.Join(mediaStorageNames, ", ")+")")
+	to := fs.String("to", "", "media storage to copy the files to ("+strings.Join(mediaStorageNames, ", ")+")")
+	fromURL := fs.String("from-url", "", "media URL of the source storage, if different from the configured one")
+	toURL := fs.String("to-url", "", "media URL of the target storage, if different from the configured one")
+	dryRun := fs.Bool("dry-run", false, "only report the changes")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *from == "" || *to == "" || *from == *to {
+		return errors.New("--from and --to are required and must be different")
+	}
+	fromStorage, err := a.mediaStorageByName(*from, *fromURL)
+	if err != nil {
+		return err
+	}
+	toStorage, err := a.mediaStorageByName(*to, *toURL)
+	if err != nil {
+		return err
+	}
+	result, err := a.migrateMedia(fromStorage, toStorage, *dryRun)
+	if result != nil {
+		log.Printf("Copied %d files", result.files)
+		olds := make([]string, 0, len(result.replacements))
+		for old := range result.replacements {
+			olds = append(olds, old)
+		}
+		sort.Strings(olds)
+		for _, old := range olds {
+			log.Printf("%s -> %s", old, result.replacements[old])
+		}
+		log.Printf("Updated %d posts", len(result.posts))
+		for _, p := range result.posts {
+			log.Println(p)
+		}
+		if *dryRun {
+			log.Println("Dry run, nothing changed")
+		}
+	}
+	return err
+}
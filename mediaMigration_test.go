@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_mediaMigration(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	_ = app.initConfig()
+	_ = app.initDatabase(false)
+	defer app.db.close()
+	app.initComponents(false)
+
+	fromDir, toDir := t.TempDir(), t.TempDir()
+	from := &localMediaStorage{path: fromDir}
+	to := &localMediaStorage{path: toDir, mediaURL: "https://media.example.com"}
+	require.NoError(t, os.WriteFile(filepath.Join(fromDir, "a.jpg"), []byte("a"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(fromDir, "b.mp3"), []byte("b"), 0644))
+
+	require.NoError(t, app.createPost(&post{
+		Path:    "/test/a",
+		Content: "![](/m/a.jpg) ![](http://localhost:8080/m/a.jpg)",
+		Parameters: map[string][]string{
+			"images": {"http://localhost:8080/m/a.jpg"},
+			"audio":  {"http://localhost:8080/m/b.mp3"},
+		},
+	}))
+	require.NoError(t, app.createPost(&post{
+		Path:    "/test/b",
+		Content: "No media",
+	}))
+
+	t.Run("Dry run", func(t *testing.T) {
+		result, err := app.migrateMedia(from, to, true)
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.files)
+		assert.Equal(t, []string{"/test/a"}, result.posts)
+		assert.NoFileExists(t, filepath.Join(toDir, "a.jpg"))
+
+		p, err := app.getPost("/test/a")
+		require.NoError(t, err)
+		assert.Contains(t, p.Content, "/m/a.jpg")
+	})
+
+	t.Run("Migrate", func(t *testing.T) {
+		result, err := app.migrateMedia(from, to, false)
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.files)
+		assert.Equal(t, "https://media.example.com/a.jpg", result.replacements["http://localhost:8080/m/a.jpg"])
+		assert.Equal(t, []string{"/test/a"}, result.posts)
+		assert.FileExists(t, filepath.Join(toDir, "a.jpg"))
+		assert.FileExists(t, filepath.Join(toDir, "b.mp3"))
+
+		p, err := app.getPost("/test/a")
+		require.NoError(t, err)
+		assert.Equal(t, "![](https://media.example.com/a.jpg) ![](https://media.example.com/a.jpg)", p.Content)
+		assert.Equal(t, []string{"https://media.example.com/a.jpg"}, p.Parameters["images"])
+		assert.Equal(t, []string{"https://media.example.com/b.mp3"}, p.Parameters["audio"])
+	})
+
+	t.Run("Storage by name", func(t *testing.T) {
+		ms, err := app.mediaStorageByName("local", "https://old.example.com")
+		require.NoError(t, err)
+		assert.Equal(t, "https://old.example.com/a.jpg", ms.location("a.jpg"))
+
+		_, err = app.mediaStorageByName("s3", "")
+		assert.Error(t, err)
+
+		_, err = app.mediaStorageByName("unknown", "")
+		assert.Error(t, err)
+	})
+}
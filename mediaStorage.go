@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"os"
 	"path/filepath"
 	"strings"
@@ -15,7 +16,7 @@ import (
 func (a *goBlog) initMediaStorage() {
 	a.mediaStorageInit.Do(func() {
 		type initFunc func() mediaStorage
-		for _, fc := range []initFunc{a.initBunnyCdnMediaStorage, a.initFtpMediaStorage, a.initLocalMediaStorage} {
+		for _, fc := range []initFunc{a.initS3MediaStorage, a.initBunnyCdnMediaStorage, a.initSftpMediaStorage, a.initWebdavMediaStorage, a.initFtpMediaStorage, a.initLocalMediaStorage} {
 			a.mediaStorage = fc()
 			if a.mediaStorage != nil {
 				break
@@ -51,6 +52,21 @@ func (a *goBlog) deleteMediaFile(filename string) error {
 	return a.mediaStorage.delete(filepath.Base(filename))
 }
 
+// Delete a file of the media storage with its responsive derivatives and its library record
+func (a *goBlog) deleteMedia(filename string) error {
+	filename = filepath.Base(filename)
+	if err := a.deleteMediaFile(filename); err != nil {
+		return err
+	}
+	if err := a.deleteMediaDerivatives(a.mediaFileLocation(filename)); err != nil {
+		log.Println("Failed to delete media derivatives:", err.Error())
+	}
+	if err := a.db.deleteMediaRecord(filename); err != nil {
+		log.Println("Failed to delete media record:", err.Error())
+	}
+	return nil
+}
+
 type mediaFile struct {
 	Name     string
 	Location string
@@ -81,6 +97,11 @@ type mediaStorage interface {
 	location(filename string) (location string)
 }
 
+// Optional, for storages that support direct uploads
+type mediaStoragePresigner interface {
+	presignedUpload(filename string) (uploadURL string, err error)
+}
+
 type localMediaStorage struct {
 	mediaURL string // optional
 	path     string // required
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/carlmjohnson/requests"
+	"github.com/pkg/sftp"
+	"go.goblog.app/app/pkgs/bufferpool"
+	"golang.org/x/crypto/ssh"
+)
+
+// SFTP
+
+type sftpMediaStorage struct {
+	address  string // required
+	config   *ssh.ClientConfig
+	path     string // optional
+	mediaURL string // required
+	// Reused connection
+	mu     sync.Mutex
+	conn   *ssh.Client
+	client *sftp.Client
+}
+
+func (a *goBlog) initSftpMediaStorage() mediaStorage {
+	config := a.cfg.Micropub.MediaStorage
+	if config == nil || config.SFTPAddress == "" || config.SFTPUser == "" || config.MediaURL == "" {
+		return nil
+	}
+	var auth []ssh.AuthMethod
+	if config.SFTPKeyFile != "" {
+		key, err := os.ReadFile(config.SFTPKeyFile)
+		if err != nil {
+			log.Println("Failed to read SFTP key file:", err.Error())
+			return nil
+		}
+		signer, err := ssh.ParsePrivateKey(key)
+		if err != nil {
+			log.Println("Failed to parse SFTP key:", err.Error())
+			return nil
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if config.SFTPPassword != "" {
+		auth = append(auth, ssh.Password(config.SFTPPassword))
+	}
+	if len(auth) == 0 {
+		return nil
+	}
+	// Verify the server with the configured host key
+	hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(config.SFTPHostKey))
+	if err != nil {
+		log.Println("Failed to parse SFTP host key:", err.Error())
+		return nil
+	}
+	return &sftpMediaStorage{
+		address: config.SFTPAddress,
+		config: &ssh.ClientConfig{
+			User:            config.SFTPUser,
+			Auth:            auth,
+			HostKeyCallback: ssh.FixedHostKey(hostKey),
+			Timeout:         5 * time.Second,
+		},
+		path:     config.SFTPPath,
+		mediaURL: config.MediaURL,
+	}
+}
+
+func (s *sftpMediaStorage) save(filename string, file io.Reader) (location string, err error) {
+	// Buffer file to be able to retry
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+	if _, err = io.Copy(buf, file); err != nil {
+		return "", err
+	}
+	err = s.withClient(func(c *sftp.Client) error {
+		f, err := c.Create(s.filePath(filename))
+		if err != nil {
+			return err
+		}
+		if _, err = io.Copy(f, bytes.NewReader(buf.Bytes())); err != nil {
+			_ = f.Close()
+			return err
+		}
+		return f.Close()
+	})
+	if err != nil {
+		return "", err
+	}
+	return s.location(filename), nil
+}
+
+func (s *sftpMediaStorage) delete(filename string) (err error) {
+	return s.withClient(func(c *sftp.Client) error {
+		return c.Remove(s.filePath(filename))
+	})
+}
+
+func (s *sftpMediaStorage) files() (files []*mediaFile, err error) {
+	err = s.withClient(func(c *sftp.Client) error {
+		entries, err := c.ReadDir(s.filePath(""))
+		if err != nil {
+			return err
+		}
+		files = nil
+		for _, e := range entries {
+			if e.Mode().IsRegular() {
+				files = append(files, &mediaFile{
+					Name:     e.Name(),
+					Location: s.location(e.Name()),
+					Time:     e.ModTime(),
+					Size:     e.Size(),
+				})
+			}
+		}
+		return nil
+	})
+	return files, err
+}
+
+func (s *sftpMediaStorage) location(name string) string {
+	return fmt.Sprintf("%s/%s", s.mediaURL, name)
+}
+
+func (s *sftpMediaStorage) filePath(name string) string {
+	return path.Join(defaultIfEmpty(s.path, "."), name)
+}
+
+// Runs f with the open connection, reconnects once if the connection is broken
+func (s *sftpMediaStorage) withClient(f func(c *sftp.Client) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for retry := 0; ; retry++ {
+		if s.client == nil {
+			if err := s.connect(); err != nil {
+				return err
+			}
+		}
+		err := f(s.client)
+		var statusErr *sftp.StatusError
+		if err == nil || errors.As(err, &statusErr) || retry > 0 {
+			return err
+		}
+		// Connection error, reset connection
+		s.disconnect()
+	}
+}
+
+func (s *sftpMediaStorage) connect() error {
+	conn, err := ssh.Dial("tcp", s.address, s.config)
+	if err != nil {
+		return err
+	}
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	s.conn, s.client = conn, client
+	return nil
+}
+
+func (s *sftpMediaStorage) disconnect() {
+	if s.client != nil {
+		_ = s.client.Close()
+	}
+	if s.conn != nil {
+		_ = s.conn.Close()
+	}
+	s.conn, s.client = nil, nil
+}
+
+// WebDAV
+
+type webdavMediaStorage struct {
+	url      string // required, URL of the collection
+	user     string // optional
+	password string // optional
+	mediaURL string // required
+	client   *http.Client
+}
+
+func (a *goBlog) initWebdavMediaStorage() mediaStorage {
+	config := a.cfg.Micropub.MediaStorage
+	if config == nil || config.WebDAVURL == "" || config.MediaURL == "" {
+		return nil
+	}
+	return &webdavMediaStorage{
+		url:      strings.TrimSuffix(config.WebDAVURL, "/") + "/",
+		user:     config.WebDAVUser,
+		password: config.WebDAVPassword,
+		mediaURL: config.MediaURL,
+		client:   a.httpClient,
+	}
+}
+
+func (w *webdavMediaStorage) request(name string) *requests.Builder {
+	rb := requests.URL(w.url + url.PathEscape(name)).Client(w.client)
+	if w.user != "" {
+		rb.BasicAuth(w.user, w.password)
+	}
+	return rb
+}
+
+func (w *webdavMediaStorage) save(filename string, file io.Reader) (location string, err error) {
+	// Buffer file to send the content length
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+	if _, err = io.Copy(buf, file); err != nil {
+		return "", err
+	}
+	err = w.request(filename).Put().BodyReader(bytes.NewReader(buf.Bytes())).Fetch(context.Background())
+	if err != nil {
+		return "", err
+	}
+	return w.location(filename), nil
+}
+
+func (w *webdavMediaStorage) delete(filename string) (err error) {
+	return w.request(filename).Delete().Fetch(context.Background())
+}
+
+type webdavMultistatus struct {
+	Responses []struct {
+		Href string `xml:"href"`
+		Prop struct {
+			ContentLength int64  `xml:"getcontentlength"`
+			LastModified  string `xml:"getlastmodified"`
+			ResourceType  struct {
+				Collection *struct{} `xml:"collection"`
+			} `xml:"resourcetype"`
+		} `xml:"propstat>prop"`
+	} `xml:"response"`
+}
+
+const webdavPropfindBody = `<?xml version="1.0" encoding="utf-8"?><propfind xmlns="DAV:"><prop><getcontentlength/><getlastmodified/><resourcetype/></prop></propfind>`
+
+func (w *webdavMediaStorage) files() (files []*mediaFile, err error) {
+	var result webdavMultistatus
+	err = w.request("").
+		Method("PROPFIND").
+		Header("Depth", "1").
+		ContentType("application/xml").
+		BodyReader(strings.NewReader(webdavPropfindBody)).
+		CheckStatus(http.StatusMultiStatus).
+		Handle(func(r *http.Response) error {
+			return xml.NewDecoder(r.Body).Decode(&result)
+		}).
+		Fetch(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range result.Responses {
+		if r.Prop.ResourceType.Collection != nil {
+			// Directory or the collection itself
+			continue
+		}
+		name, err := url.PathUnescape(path.Base(r.Href))
+		if err != nil {
+			continue
+		}
+		modified, _ := http.ParseTime(r.Prop.LastModified)
+		files = append(files, &mediaFile{
+			Name:     name,
+			Location: w.location(name),
+			Time:     modified,
+			Size:     r.Prop.ContentLength,
+		})
+	}
+	return files, nil
+}
+
+func (w *webdavMediaStorage) location(name string) string {
+	return fmt.Sprintf("%s/%s", w.mediaURL, name)
+}
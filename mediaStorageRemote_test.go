@@ -0,0 +1,239 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/net/webdav"
+)
+
+func Test_sftpMediaStorageConfig(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	app.cfg.Micropub.MediaStorage = &configMicropubMedia{
+		MediaURL:     "https://media.example.com",
+		SFTPAddress:  "sftp.example.com:22",
+		SFTPUser:     "user",
+		SFTPPassword: "pass",
+	}
+
+	// Host key is required
+	assert.Error(t, app.initConfig())
+
+	app.cfg.Micropub.MediaStorage.SFTPHostKey = "invalid"
+	assert.Error(t, app.initConfig())
+
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	sshPub, err := ssh.NewPublicKey(pub)
+	require.NoError(t, err)
+	app.cfg.Micropub.MediaStorage.SFTPHostKey = string(ssh.MarshalAuthorizedKey(sshPub))
+	require.NoError(t, app.initConfig())
+
+	s, ok := app.initSftpMediaStorage().(*sftpMediaStorage)
+	require.True(t, ok)
+	assert.Equal(t, "sftp.example.com:22", s.address)
+	assert.NotNil(t, s.config.HostKeyCallback)
+}
+
+// In-process SFTP server with in-memory files, closeConns closes all open connections
+func newTestSftpServer(t *testing.T, hostKey ssh.Signer) (address string, closeConns func()) {
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if c.User() == "user" && string(pass) == "pass" {
+				return nil, nil
+			}
+			return nil, ssh.ErrNoAuth
+		},
+	}
+	config.AddHostKey(hostKey)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+	handlers := sftp.InMemHandler()
+	var mu sync.Mutex
+	var conns []net.Conn
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			conns = append(conns, conn)
+			mu.Unlock()
+			go func() {
+				_, chans, reqs, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				for newChannel := range chans {
+					if newChannel.ChannelType() != "session" {
+						_ = newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+						continue
+					}
+					channel, requests, err := newChannel.Accept()
+					if err != nil {
+						return
+					}
+					go func() {
+						for req := range requests {
+							// Subsystem payload is the length prefixed name
+							ok := req.Type == "subsystem" && string(req.Payload[4:]) == "sftp"
+							_ = req.Reply(ok, nil)
+							if ok {
+								go func() {
+									server := sftp.NewRequestServer(channel, handlers)
+									_ = server.Serve()
+									_ = server.Close()
+								}()
+							}
+						}
+					}()
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String(), func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, conn := range conns {
+			_ = conn.Close()
+		}
+		conns = nil
+	}
+}
+
+func Test_sftpMediaStorage(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	hostKey, err := ssh.NewSignerFromKey(priv)
+	require.NoError(t, err)
+	address, closeConns := newTestSftpServer(t, hostKey)
+
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	app.cfg.Micropub.MediaStorage = &configMicropubMedia{
+		MediaURL:     "https://media.example.com",
+		SFTPAddress:  address,
+		SFTPUser:     "user",
+		SFTPPassword: "pass",
+		SFTPHostKey:  string(ssh.MarshalAuthorizedKey(hostKey.PublicKey())),
+		SFTPPath:     "/",
+	}
+	require.NoError(t, app.initConfig())
+	s, ok := app.initSftpMediaStorage().(*sftpMediaStorage)
+	require.True(t, ok)
+	defer s.disconnect()
+
+	// Upload and list
+	loc, err := s.save("a.jpg", strings.NewReader("image"))
+	require.NoError(t, err)
+	assert.Equal(t, "https://media.example.com/a.jpg", loc)
+	_, err = s.save("b.mp3", strings.NewReader("audio"))
+	require.NoError(t, err)
+	files, err := s.files()
+	require.NoError(t, err)
+	if assert.Len(t, files, 2) {
+		names := []string{files[0].Name, files[1].Name}
+		assert.ElementsMatch(t, []string{"a.jpg", "b.mp3"}, names)
+		for _, f := range files {
+			if f.Name == "a.jpg" {
+				assert.Equal(t, int64(5), f.Size)
+				assert.Equal(t, "https://media.example.com/a.jpg", f.Location)
+			}
+		}
+	}
+
+	// Reconnect once after the connection is closed
+	oldConn := s.conn
+	closeConns()
+	require.NoError(t, s.delete("b.mp3"))
+	assert.NotSame(t, oldConn, s.conn)
+	files, err = s.files()
+	require.NoError(t, err)
+	if assert.Len(t, files, 1) {
+		assert.Equal(t, "a.jpg", files[0].Name)
+	}
+
+	// Server errors aren't retried
+	assert.Error(t, s.delete("missing.jpg"))
+
+	// Wrong host key is rejected
+	_, otherPriv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ssh.NewSignerFromKey(otherPriv)
+	require.NoError(t, err)
+	app.cfg.Micropub.MediaStorage.SFTPHostKey = string(ssh.MarshalAuthorizedKey(otherKey.PublicKey()))
+	other, ok := app.initSftpMediaStorage().(*sftpMediaStorage)
+	require.True(t, ok)
+	_, err = other.save("c.jpg", strings.NewReader("image"))
+	assert.Error(t, err)
+	_, err = other.files()
+	assert.Error(t, err)
+}
+
+func Test_webdavMediaStorage(t *testing.T) {
+	dav := &webdav.Handler{
+		Prefix:     "/dav",
+		FileSystem: webdav.NewMemFS(),
+		LockSystem: webdav.NewMemLS(),
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		dav.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	w := &webdavMediaStorage{
+		url:      srv.URL + "/dav/",
+		user:     "user",
+		password: "pass",
+		mediaURL: "https://media.example.com",
+		client:   srv.Client(),
+	}
+
+	loc, err := w.save("a.jpg", strings.NewReader("image"))
+	require.NoError(t, err)
+	assert.Equal(t, "https://media.example.com/a.jpg", loc)
+	_, err = w.save("b c.mp3", strings.NewReader("audio"))
+	require.NoError(t, err)
+
+	files, err := w.files()
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	names := []string{files[0].Name, files[1].Name}
+	assert.ElementsMatch(t, []string{"a.jpg", "b c.mp3"}, names)
+	for _, f := range files {
+		if f.Name == "a.jpg" {
+			assert.Equal(t, int64(5), f.Size)
+			assert.False(t, f.Time.IsZero())
+		}
+	}
+
+	require.NoError(t, w.delete("a.jpg"))
+	files, err = w.files()
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	// Wrong credentials
+	w.password = "wrong"
+	_, err = w.files()
+	assert.Error(t, err)
+}
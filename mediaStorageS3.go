@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/carlmjohnson/requests"
+	"go.goblog.app/app/pkgs/bufferpool"
+)
+
+const (
+	s3DefaultRegion      = "us-east-1"
+	s3UnsignedPayload    = "UNSIGNED-PAYLOAD"
+	s3PresignedUploadTTL = 15 * time.Minute
+	s3DateFormat         = "20060102T150405Z"
+)
+
+type s3MediaStorage struct {
+	endpoint  *url.URL // required
+	bucket    string   // required
+	accessKey string   // required
+	secretKey string   // required
+	region    string   // required
+	pathStyle bool
+	presign   bool
+	mediaURL  string // optional
+	client    *http.Client
+}
+
+func (a *goBlog) initS3MediaStorage() mediaStorage {
+	config := a.cfg.Micropub.MediaStorage
+	if config == nil || config.S3Endpoint == "" || config.S3Bucket == "" || config.S3AccessKey == "" || config.S3SecretKey == "" {
+		return nil
+	}
+	endpoint, err := url.Parse(config.S3Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil
+	}
+	return &s3MediaStorage{
+		endpoint:  endpoint,
+		bucket:    config.S3Bucket,
+		accessKey: config.S3AccessKey,
+		secretKey: config.S3SecretKey,
+		region:    defaultIfEmpty(config.S3Region, s3DefaultRegion),
+		pathStyle: config.S3PathStyle,
+		presign:   config.S3PresignedUploads,
+		mediaURL:  config.MediaURL,
+		client:    a.httpClient,
+	}
+}
+
+func (s *s3MediaStorage) save(filename string, file io.Reader) (location string, err error) {
+	// S3 needs the content length, so buffer the file
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+	if _, err = io.Copy(buf, file); err != nil {
+		return "", err
+	}
+	rb := requests.URL(s.objectURL(filename).String()).
+		Put().
+		BodyReader(bytes.NewReader(buf.Bytes()))
+	if ct := mime.TypeByExtension(path.Ext(filename)); ct != "" {
+		rb.ContentType(ct)
+	}
+	if err = s.do(rb); err != nil {
+		return "", err
+	}
+	return s.location(filename), nil
+}
+
+func (s *s3MediaStorage) delete(filename string) (err error) {
+	return s.do(requests.URL(s.objectURL(filename).String()).Delete())
+}
+
+type s3ListBucketResult struct {
+	Contents []struct {
+		Key          string `xml:"Key"`
+		LastModified string `xml:"LastModified"`
+		Size         int64  `xml:"Size"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func (s *s3MediaStorage) files() (files []*mediaFile, err error) {
+	token := ""
+	for {
+		rb := requests.URL(s.objectURL("").String()).Param("list-type", "2")
+		if token != "" {
+			rb.Param("continuation-token", token)
+		}
+		var result s3ListBucketResult
+		err = s.do(rb.Handle(func(r *http.Response) error {
+			return xml.NewDecoder(r.Body).Decode(&result)
+		}))
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range result.Contents {
+			if strings.Contains(c.Key, "/") {
+				// Only files in the root of the bucket
+				continue
+			}
+			modified, _ := time.Parse(time.RFC3339, c.LastModified)
+			files = append(files, &mediaFile{
+				Name:     c.Key,
+				Location: s.location(c.Key),
+				Time:     modified,
+				Size:     c.Size,
+			})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return files, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+func (s *s3MediaStorage) location(name string) string {
+	if s.mediaURL != "" {
+		return fmt.Sprintf("%s/%s", s.mediaURL, name)
+	}
+	return s.objectURL(name).String()
+}
+
+// Presigned URL to upload a file directly to the bucket
+func (s *s3MediaStorage) presignedUpload(filename string) (string, error) {
+	if !s.presign {
+		return "", errors.New("presigned uploads not enabled")
+	}
+	return s.presignURL(http.MethodPut, filename, s3PresignedUploadTTL, time.Now()), nil
+}
+
+func (s *s3MediaStorage) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	basePath := strings.TrimSuffix(u.Path, "/")
+	if s.pathStyle {
+		u.Path = basePath + "/" + s.bucket + "/" + key
+	} else {
+		u.Host = s.bucket + "." + u.Host
+		u.Path = basePath + "/" + key
+	}
+	u.RawPath = ""
+	return &u
+}
+
+func (s *s3MediaStorage) do(rb *requests.Builder) error {
+	req, err := rb.Client(s.client).Request(context.Background())
+	if err != nil {
+		return err
+	}
+	s.sign(req, time.Now())
+	return rb.Do(req)
+}
+
+// AWS Signature Version 4 with unsigned payload
+func (s *s3MediaStorage) sign(req *http.Request, now time.Time) {
+	amzDate := now.UTC().Format(s3DateFormat)
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", s3UnsignedPayload)
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + s3UnsignedPayload + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+	signature := s.signature(req.Method, req.URL, req.URL.Query(), canonicalHeaders, signedHeaders, now)
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, s.scope(now), signedHeaders, signature,
+	))
+}
+
+func (s *s3MediaStorage) presignURL(method, key string, expires time.Duration, now time.Time) string {
+	u := s.objectURL(key)
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", s.accessKey+"/"+s.scope(now))
+	query.Set("X-Amz-Date", now.UTC().Format(s3DateFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expires.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+	signature := s.signature(method, u, query, "host:"+u.Host+"\n", "host", now)
+	u.RawQuery = s3CanonicalQuery(query) + "&X-Amz-Signature=" + signature
+	return u.String()
+}
+
+func (s *s3MediaStorage) scope(now time.Time) string {
+	return now.UTC().Format("20060102") + "/" + s.region + "/s3/aws4_request"
+}
+
+func (s *s3MediaStorage) signature(method string, u *url.URL, query url.Values, canonicalHeaders, signedHeaders string, now time.Time) string {
+	canonicalRequest := strings.Join([]string{
+		method,
+		s3URIEncode(u.Path, false),
+		s3CanonicalQuery(query),
+		canonicalHeaders,
+		signedHeaders,
+		s3UnsignedPayload,
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		now.UTC().Format(s3DateFormat),
+		s.scope(now),
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+	key := []byte("AWS4" + s.secretKey)
+	for _, part := range []string{now.UTC().Format("20060102"), s.region, "s3", "aws4_request", stringToSign} {
+		key = s3HMAC(key, part)
+	}
+	return hex.EncodeToString(key)
+}
+
+func s3HMAC(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	_, _ = h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		values := append([]string{}, query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, s3URIEncode(k, true)+"="+s3URIEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// URI encoding as specified by AWS, unreserved characters are not encoded
+func s3URIEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !encodeSlash) {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
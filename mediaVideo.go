@@ -0,0 +1,299 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	videoPosterParam = "videoposter"
+	videoQueueName   = "video"
+	videoAudioKbps   = 128
+)
+
+var defaultVideoHeights = []int{360, 720}
+
+func (a *goBlog) videoTranscodingEnabled() bool {
+	if a.cfg.Micropub == nil || a.cfg.Micropub.MediaStorage == nil {
+		return false
+	}
+	return a.cfg.Micropub.MediaStorage.FFmpeg != "" && a.mediaStorageEnabled()
+}
+
+func (a *goBlog) initVideoTranscoding() {
+	if !a.videoTranscodingEnabled() {
+		return
+	}
+	a.listenOnQueue(videoQueueName, 30*time.Second, func(qi *queueItem, dequeue func(), _ func(time.Duration)) {
+		location := string(qi.content)
+		if err := a.transcodeVideo(location); err != nil {
+			log.Printf("Failed to transcode video %s: %v", location, err)
+		}
+		dequeue()
+	})
+}
+
+func (a *goBlog) queueVideoTranscoding(location string) error {
+	return a.enqueue(videoQueueName, []byte(location), time.Now())
+}
+
+func (a *goBlog) videoHeights() []int {
+	if heights := a.cfg.Micropub.MediaStorage.VideoHeights; len(heights) > 0 {
+		return heights
+	}
+	return defaultVideoHeights
+}
+
+// Video bitrate in kbps for the rendition height
+func videoKbps(height int) int {
+	return height * height / 200
+}
+
+type mediaVideo struct {
+	location string
+	playlist string
+	poster   string
+}
+
+// Create HLS renditions and a poster of the uploaded video and add the playlist to posts using the video
+func (a *goBlog) transcodeVideo(location string) error {
+	a.initMediaStorage()
+	if a.mediaStorage == nil {
+		return errors.New("no media storage configured")
+	}
+	dir, err := os.MkdirTemp("", "goblog-video-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	// Get the uploaded file
+	input := "input" + strings.ToLower(path.Ext(location))
+	inputFile, err := os.Create(filepath.Join(dir, input))
+	if err != nil {
+		return err
+	}
+	err = a.readMediaFile(a.mediaStorage, path.Base(location), inputFile)
+	_ = inputFile.Close()
+	if err != nil {
+		return err
+	}
+	// Transcode, the files are named after the original file, media storages don't support directories
+	prefix := strings.TrimSuffix(path.Base(location), path.Ext(location))
+	heights := append([]int{}, a.videoHeights()...)
+	sort.Ints(heights)
+	master := bytes.NewBufferString("#EXTM3U\n#EXT-X-VERSION:3\n")
+	for _, height := range heights {
+		rendition := fmt.Sprintf("%s-%dp", prefix, height)
+		kbps := videoKbps(height)
+		err = a.runFFmpeg(
+			dir, "-y", "-i", input,
+			"-vf", fmt.Sprintf("scale=-2:min(%d\\,ih)", height),
+			"-c:v", "libx264", "-preset", "veryfast", "-profile:v", "main",
+			"-b:v", fmt.Sprintf("%dk", kbps), "-maxrate", fmt.Sprintf("%dk", kbps), "-bufsize", fmt.Sprintf("%dk", 2*kbps),
+			"-c:a", "aac", "-b:a", fmt.Sprintf("%dk", videoAudioKbps), "-ac", "2",
+			"-f", "hls", "-hls_time", "6", "-hls_playlist_type", "vod",
+			"-hls_segment_filename", rendition+"-%03d.ts",
+			rendition+".m3u8",
+		)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(master, "#EXT-X-STREAM-INF:BANDWIDTH=%d\n%s.m3u8\n", (kbps+videoAudioKbps)*1000, rendition)
+	}
+	posterName := prefix + "-poster.jpg"
+	err = a.runFFmpeg(
+		dir, "-y", "-i", input,
+		"-vf", fmt.Sprintf("thumbnail,scale=-2:min(%d\\,ih)", heights[len(heights)-1]),
+		"-frames:v", "1", posterName,
+	)
+	if err != nil {
+		return err
+	}
+	masterName := prefix + ".m3u8"
+	if err = os.WriteFile(filepath.Join(dir, masterName), master.Bytes(), 0644); err != nil {
+		return err
+	}
+	// Upload segments first, so the playlists are only available when complete
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	uploadOrder := func(name string) int {
+		switch {
+		case name == masterName:
+			return 3
+		case strings.HasSuffix(name, ".m3u8"):
+			return 2
+		case strings.HasSuffix(name, ".ts"):
+			return 0
+		}
+		return 1
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return uploadOrder(entries[i].Name()) < uploadOrder(entries[j].Name())
+	})
+	video := &mediaVideo{location: location}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || name == input {
+			continue
+		}
+		file, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		loc, err := a.saveMediaFile(name, file)
+		_ = file.Close()
+		if err != nil {
+			return err
+		}
+		switch name {
+		case masterName:
+			video.playlist = loc
+		case posterName:
+			video.poster = loc
+		}
+	}
+	if err = a.db.saveMediaVideo(video); err != nil {
+		return err
+	}
+	return a.addVideoPlaylistToPosts(video)
+}
+
+func (a *goBlog) runFFmpeg(dir string, args ...string) error {
+	cmd := exec.Command(a.cfg.Micropub.MediaStorage.FFmpeg, append([]string{"-hide_banner", "-loglevel", "error"}, args...)...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("ffmpeg failed: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (db *database) saveMediaVideo(v *mediaVideo) error {
+	_, err := db.exec(
+		"insert or replace into media_videos (location, playlist, poster) values (@location, @playlist, @poster)",
+		sql.Named("location", v.location), sql.Named("playlist", v.playlist), sql.Named("poster", v.poster),
+	)
+	return err
+}
+
+func (db *database) getMediaVideos() ([]*mediaVideo, error) {
+	rows, err := db.query("select location, playlist, poster from media_videos")
+	if err != nil {
+		return nil, err
+	}
+	var videos []*mediaVideo
+	for rows.Next() {
+		v := &mediaVideo{}
+		if err = rows.Scan(&v.location, &v.playlist, &v.poster); err != nil {
+			return nil, err
+		}
+		videos = append(videos, v)
+	}
+	return videos, nil
+}
+
+// Paths of posts that contain the media file in the content or parameters
+func (db *database) postsUsingMedia(name string) ([]string, error) {
+	rows, err := db.query(
+		"select distinct path from post_parameters where instr(value, @name) > 0 union select distinct path from posts_fts where content match '\"' || @name || '\"'",
+		dbNoCache, sql.Named("name", name),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	var p string
+	for rows.Next() {
+		if err = rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		paths = append(paths, p)
+	}
+	return paths, nil
+}
+
+// Set the playlist of a video transcoded after the post was created
+func (a *goBlog) addVideoPlaylistToPosts(v *mediaVideo) error {
+	paths, err := a.db.postsUsingMedia(path.Base(v.location))
+	if err != nil {
+		return err
+	}
+	for _, p := range paths {
+		post, err := a.getPost(p)
+		if err != nil {
+			return err
+		}
+		if post.hasVideoPlaylist() || !a.postUsesMedia(post, v.location) {
+			continue
+		}
+		if err = a.db.replacePostParam(p, videoPlaylistParam, []string{v.playlist}); err != nil {
+			return err
+		}
+		if v.poster != "" {
+			if err = a.db.replacePostParam(p, videoPosterParam, []string{v.poster}); err != nil {
+				return err
+			}
+		}
+	}
+	if len(paths) > 0 {
+		a.cache.purge()
+	}
+	return nil
+}
+
+// Use the playlist of an already transcoded video for a new post
+func (a *goBlog) addVideoPlaylistParameters(p *post) {
+	if p.hasVideoPlaylist() || a.db == nil {
+		return
+	}
+	videos, err := a.db.getMediaVideos()
+	if err != nil {
+		return
+	}
+	for _, v := range videos {
+		if a.postUsesMedia(p, v.location) {
+			p.Parameters[videoPlaylistParam] = []string{v.playlist}
+			if v.poster != "" {
+				p.Parameters[videoPosterParam] = []string{v.poster}
+			}
+			return
+		}
+	}
+}
+
+func (a *goBlog) postUsesMedia(p *post, location string) bool {
+	relative := a.relativeMediaLocation(location)
+	contains := func(s string) bool {
+		return strings.Contains(s, location) || strings.Contains(s, relative)
+	}
+	if contains(p.Content) {
+		return true
+	}
+	for _, values := range p.Parameters {
+		for _, v := range values {
+			if contains(v) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Media location without the blog address, posts can use relative URLs
+func (a *goBlog) relativeMediaLocation(location string) string {
+	if rel := strings.TrimPrefix(location, a.getFullAddress("/")); rel != location {
+		return "/" + strings.TrimPrefix(rel, "/")
+	}
+	return location
+}
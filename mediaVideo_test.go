@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Writes the output files like ffmpeg would
+const fakeFFmpeg = `#!/bin/sh
+for last; do :; done
+segment=""
+while [ $# -gt 0 ]; do
+	if [ "$1" = "-hls_segment_filename" ]; then segment="$2"; fi
+	shift
+done
+case "$last" in
+	*.m3u8)
+		name=$(printf "$segment" 0)
+		echo "segment" > "$name"
+		printf "#EXTM3U\n#EXTINF:6.0,\n%s\n#EXT-X-ENDLIST\n" "$name" > "$last"
+		;;
+	*) echo "poster" > "$last" ;;
+esac
+`
+
+func Test_mediaVideo(t *testing.T) {
+	ffmpeg := filepath.Join(t.TempDir(), "ffmpeg")
+	require.NoError(t, os.WriteFile(ffmpeg, []byte(fakeFFmpeg), 0755))
+
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	app.cfg.Micropub.MediaStorage = &configMicropubMedia{
+		FFmpeg:       ffmpeg,
+		VideoHeights: []int{720, 360},
+	}
+	mediaDir := t.TempDir()
+	app.mediaStorageInit.Do(func() {
+		app.mediaStorage = &localMediaStorage{path: mediaDir}
+	})
+	_ = app.initConfig()
+	_ = app.initDatabase(false)
+	defer app.db.close()
+	app.initComponents(false)
+	require.True(t, app.videoTranscodingEnabled())
+	require.NoError(t, os.WriteFile(filepath.Join(mediaDir, "abc.mp4"), []byte("video"), 0644))
+
+	// Post created before the video is transcoded
+	require.NoError(t, app.createPost(&post{
+		Path:    "/test/video",
+		Content: "[Video](/m/abc.mp4)",
+	}))
+
+	require.NoError(t, app.transcodeVideo("http://localhost:8080/m/abc.mp4"))
+
+	for _, name := range []string{"abc.m3u8", "abc-360p.m3u8", "abc-360p-000.ts", "abc-720p.m3u8", "abc-720p-000.ts", "abc-poster.jpg"} {
+		assert.FileExists(t, filepath.Join(mediaDir, name))
+	}
+	master, err := os.ReadFile(filepath.Join(mediaDir, "abc.m3u8"))
+	require.NoError(t, err)
+	assert.Equal(t, "#EXTM3U\n#EXT-X-VERSION:3\n#EXT-X-STREAM-INF:BANDWIDTH=776000\nabc-360p.m3u8\n#EXT-X-STREAM-INF:BANDWIDTH=2720000\nabc-720p.m3u8\n", string(master))
+
+	p, err := app.getPost("/test/video")
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8080/m/abc.m3u8", p.firstParameter(videoPlaylistParam))
+	assert.Equal(t, "http://localhost:8080/m/abc-poster.jpg", p.firstParameter(videoPosterParam))
+
+	// Post created after the video is transcoded
+	p = &post{
+		Content: "---\nvideo: http://localhost:8080/m/abc.mp4\n---\nTest",
+	}
+	require.NoError(t, app.computeExtraPostParameters(p))
+	assert.Equal(t, "http://localhost:8080/m/abc.m3u8", p.firstParameter(videoPlaylistParam))
+
+	// Other post
+	p = &post{
+		Content: "No video",
+	}
+	require.NoError(t, app.computeExtraPostParameters(p))
+	assert.False(t, p.hasVideoPlaylist())
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -22,12 +23,23 @@ const micropubPath = "/micropub"
 
 func (a *goBlog) serveMicropubQuery(w http.ResponseWriter, r *http.Request) {
 	var result any
-	switch query := r.URL.Query(); query.Get("q") {
+	query := r.URL.Query()
+	// Channel to filter by, empty means all blogs
+	channelBlog, channelSection := a.micropubParseChannel(query.Get("mp-channel"))
+	switch query.Get("q") {
 	case "config":
 		type micropubConfig struct {
-			MediaEndpoint string `json:"media-endpoint"`
+			MediaEndpoint string                       `json:"media-endpoint"`
+			SyndicateTo   []*micropubSyndicationTarget `json:"syndicate-to"`
+			Channels      []*micropubChannel           `json:"channels"`
+			PostTypes     []*micropubPostType          `json:"post-types"`
+		}
+		result = micropubConfig{
+			MediaEndpoint: a.getFullAddress(micropubPath + micropubMediaSubPath),
+			SyndicateTo:   a.micropubSyndicationTargets(channelBlog),
+			Channels:      a.micropubChannels(),
+			PostTypes:     micropubPostTypes,
 		}
-		result = micropubConfig{MediaEndpoint: a.getFullAddress(micropubPath + micropubMediaSubPath)}
 	case "source":
 		if urlString := query.Get("url"); urlString != "" {
 			u, err := url.Parse(query.Get("url"))
@@ -42,10 +54,23 @@ func (a *goBlog) serveMicropubQuery(w http.ResponseWriter, r *http.Request) {
 			}
 			result = a.postToMfItem(p)
 		} else {
-			posts, err := a.getPosts(&postsRequestConfig{
+			prc := &postsRequestConfig{
 				limit:  stringToInt(query.Get("limit")),
 				offset: stringToInt(query.Get("offset")),
-			})
+				search: query.Get("search"),
+				blog:   channelBlog,
+				status: micropubStatus(statusNil, query.Get("post-status"), query.Get("visibility")),
+			}
+			if channelSection != "" {
+				prc.sections = []string{channelSection}
+			}
+			if postType := query.Get("post-type"); postType != "" {
+				if !a.micropubFilterPostType(prc, postType) {
+					a.serveError(w, r, "Unknown post type", http.StatusBadRequest)
+					return
+				}
+			}
+			posts, err := a.getPosts(prc)
 			if err != nil {
 				a.serveError(w, r, err.Error(), http.StatusInternalServerError)
 				return
@@ -59,6 +84,9 @@ func (a *goBlog) serveMicropubQuery(w http.ResponseWriter, r *http.Request) {
 	case "category":
 		allCategories := []string{}
 		for blog := range a.cfg.Blogs {
+			if channelBlog != "" && blog != channelBlog {
+				continue
+			}
 			values, err := a.db.allTaxonomyValues(blog, a.cfg.Micropub.CategoryParam)
 			if err != nil {
 				a.serveError(w, r, err.Error(), http.StatusInternalServerError)
@@ -67,6 +95,12 @@ func (a *goBlog) serveMicropubQuery(w http.ResponseWriter, r *http.Request) {
 			allCategories = append(allCategories, values...)
 		}
 		result = map[string]any{"categories": allCategories}
+	case "syndicate-to":
+		result = map[string]any{"syndicate-to": a.micropubSyndicationTargets(channelBlog)}
+	case "channel":
+		result = map[string]any{"channels": a.micropubChannels()}
+	case "post-types":
+		result = map[string]any{"post-types": micropubPostTypes}
 	default:
 		a.serve404(w, r)
 		return
@@ -81,6 +115,119 @@ func (a *goBlog) serveMicropubQuery(w http.ResponseWriter, r *http.Request) {
 	_ = a.min.Get().Minify(contenttype.JSON, w, buf)
 }
 
+type micropubChannel struct {
+	UID  string `json:"uid"`
+	Name string `json:"name"`
+}
+
+// Channels are blogs ("blog") and their sections ("blog/section")
+func (a *goBlog) micropubChannels() []*micropubChannel {
+	channels := []*micropubChannel{}
+	blogs := lo.Keys(a.cfg.Blogs)
+	sort.Strings(blogs)
+	for _, blog := range blogs {
+		bc := a.cfg.Blogs[blog]
+		channels = append(channels, &micropubChannel{UID: blog, Name: defaultIfEmpty(bc.Title, blog)})
+		sections := lo.Keys(bc.Sections)
+		sort.Strings(sections)
+		for _, section := range sections {
+			channels = append(channels, &micropubChannel{
+				UID:  blog + "/" + section,
+				Name: defaultIfEmpty(bc.Title, blog) + " - " + defaultIfEmpty(bc.Sections[section].Title, section),
+			})
+		}
+	}
+	return channels
+}
+
+func (a *goBlog) micropubParseChannel(channel string) (blog, section string) {
+	blog, section, _ = strings.Cut(channel, "/")
+	if _, ok := a.cfg.Blogs[blog]; !ok {
+		return "", ""
+	}
+	if _, ok := a.cfg.Blogs[blog].Sections[section]; !ok {
+		section = ""
+	}
+	return blog, section
+}
+
+// Set blog and section parameters from the channel, computeExtraPostParameters applies them
+func (a *goBlog) micropubSetChannel(entry *post, channel string) {
+	blog, section := a.micropubParseChannel(channel)
+	if blog != "" {
+		entry.Parameters["blog"] = []string{blog}
+	}
+	if section != "" {
+		entry.Parameters["section"] = []string{section}
+	}
+}
+
+const (
+	syndicationTelegram    = "telegram"
+	syndicationActivityPub = "activitypub"
+)
+
+type micropubSyndicationTarget struct {
+	UID  string `json:"uid"`
+	Name string `json:"name"`
+}
+
+// Get the syndication targets available for a blog, or for any blog if blog is empty
+func (a *goBlog) micropubSyndicationTargets(blog string) []*micropubSyndicationTarget {
+	targets := []*micropubSyndicationTarget{}
+	for name, bc := range a.cfg.Blogs {
+		if (blog == "" || name == blog) && bc.Telegram.enabled() {
+			targets = append(targets, &micropubSyndicationTarget{UID: syndicationTelegram, Name: "Telegram"})
+			break
+		}
+	}
+	if a.apEnabled() {
+		targets = append(targets, &micropubSyndicationTarget{UID: syndicationActivityPub, Name: "ActivityPub"})
+	}
+	return targets
+}
+
+type micropubPostType struct {
+	Type string `json:"type"`
+	Name string `json:"name"`
+}
+
+var micropubPostTypes = []*micropubPostType{
+	{Type: "note", Name: "Note"},
+	{Type: "article", Name: "Article"},
+	{Type: "reply", Name: "Reply"},
+	{Type: "like", Name: "Like"},
+	{Type: "bookmark", Name: "Bookmark"},
+	{Type: "photo", Name: "Photo"},
+	{Type: "audio", Name: "Audio"},
+}
+
+// Add a filter for the post type to the posts request config, returns false for unknown types
+func (a *goBlog) micropubFilterPostType(c *postsRequestConfig, postType string) bool {
+	switch postType {
+	case "article":
+		c.parameter = "title"
+	case "reply":
+		c.parameter = a.cfg.Micropub.ReplyParam
+	case "like":
+		c.parameter = a.cfg.Micropub.LikeParam
+	case "bookmark":
+		c.parameter = a.cfg.Micropub.BookmarkParam
+	case "photo":
+		c.parameter = a.cfg.Micropub.PhotoParam
+	case "audio":
+		c.parameter = a.cfg.Micropub.AudioParam
+	case "note":
+		c.excludedParameters = []string{
+			"title", a.cfg.Micropub.ReplyParam, a.cfg.Micropub.LikeParam, a.cfg.Micropub.BookmarkParam,
+			a.cfg.Micropub.PhotoParam, a.cfg.Micropub.AudioParam,
+		}
+	default:
+		return false
+	}
+	return true
+}
+
 func (a *goBlog) serveMicropubPost(w http.ResponseWriter, r *http.Request) {
 	defer r.Body.Close()
 	switch mt, _, _ := mime.ParseMediaType(r.Header.Get(contentType)); mt {
@@ -150,6 +297,17 @@ func (a *goBlog) micropubParseValuePostParamsValueMap(entry *post, values map[st
 		entry.Slug = slug[0]
 		delete(values, "mp-slug")
 	}
+	if channel, ok := values["mp-channel"]; ok && len(channel) > 0 {
+		a.micropubSetChannel(entry, channel[0])
+		delete(values, "mp-channel")
+	}
+	if syndicateTo, ok := values["mp-syndicate-to"]; ok {
+		entry.Parameters[syndicateToParam] = syndicateTo
+		delete(values, "mp-syndicate-to")
+	} else if syndicateTos, ok := values["mp-syndicate-to[]"]; ok {
+		entry.Parameters[syndicateToParam] = syndicateTos
+		delete(values, "mp-syndicate-to[]")
+	}
 	// Status
 	statusStr := ""
 	if status, ok := values["post-status"]; ok && len(status) > 0 {
@@ -238,20 +396,24 @@ type microformatItem struct {
 }
 
 type microformatProperties struct {
-	Name       []string `json:"name,omitempty"`
-	Published  []string `json:"published,omitempty"`
-	Updated    []string `json:"updated,omitempty"`
-	PostStatus []string `json:"post-status,omitempty"`
-	Visibility []string `json:"visibility,omitempty"`
-	Category   []string `json:"category,omitempty"`
-	Content    []string `json:"content,omitempty"`
-	URL        []string `json:"url,omitempty"`
-	InReplyTo  []string `json:"in-reply-to,omitempty"`
-	LikeOf     []string `json:"like-of,omitempty"`
-	BookmarkOf []string `json:"bookmark-of,omitempty"`
-	MpSlug     []string `json:"mp-slug,omitempty"`
-	Photo      []any    `json:"photo,omitempty"`
-	Audio      []string `json:"audio,omitempty"`
+	Name          []string `json:"name,omitempty"`
+	Published     []string `json:"published,omitempty"`
+	Updated       []string `json:"updated,omitempty"`
+	PostStatus    []string `json:"post-status,omitempty"`
+	Visibility    []string `json:"visibility,omitempty"`
+	Category      []string `json:"category,omitempty"`
+	Content       []string `json:"content,omitempty"`
+	URL           []string `json:"url,omitempty"`
+	InReplyTo     []string `json:"in-reply-to,omitempty"`
+	LikeOf        []string `json:"like-of,omitempty"`
+	BookmarkOf    []string `json:"bookmark-of,omitempty"`
+	MpSlug        []string `json:"mp-slug,omitempty"`
+	MpChannel     []string `json:"mp-channel,omitempty"`
+	MpSyndicateTo []string `json:"mp-syndicate-to,omitempty"`
+	Photo         []any    `json:"photo,omitempty"`
+	Audio         []string `json:"audio,omitempty"`
+	Syndication   []string `json:"syndication,omitempty"`
+	Series        []string `json:"series,omitempty"`
 }
 
 func (a *goBlog) micropubParsePostParamsMfItem(entry *post, mf *microformatItem) error {
@@ -275,6 +437,12 @@ func (a *goBlog) micropubParsePostParamsMfItem(entry *post, mf *microformatItem)
 	if len(mf.Properties.MpSlug) > 0 {
 		entry.Slug = mf.Properties.MpSlug[0]
 	}
+	if len(mf.Properties.MpChannel) > 0 {
+		a.micropubSetChannel(entry, mf.Properties.MpChannel[0])
+	}
+	if len(mf.Properties.MpSyndicateTo) > 0 {
+		entry.Parameters[syndicateToParam] = mf.Properties.MpSyndicateTo
+	}
 	// Status
 	status := ""
 	if len(mf.Properties.PostStatus) > 0 {
@@ -306,6 +474,12 @@ func (a *goBlog) micropubParsePostParamsMfItem(entry *post, mf *microformatItem)
 	if len(mf.Properties.Audio) > 0 {
 		entry.Parameters[a.cfg.Micropub.AudioParam] = mf.Properties.Audio
 	}
+	if len(mf.Properties.Syndication) > 0 {
+		entry.Parameters[syndicationParam] = mf.Properties.Syndication
+	}
+	if len(mf.Properties.Series) > 0 {
+		entry.Parameters[seriesParam] = mf.Properties.Series
+	}
 	if len(mf.Properties.Photo) > 0 {
 		for _, photo := range mf.Properties.Photo {
 			if theString, justString := photo.(string); justString {
@@ -391,6 +565,10 @@ func (a *goBlog) computeExtraPostParameters(p *post) error {
 		// Has no published date, but section -> published now
 		p.Published = time.Now().Local().Format(time.RFC3339)
 	}
+	// Use metadata of uploaded photos
+	a.addPhotoMetadataParameters(p)
+	// Use the playlist of uploaded videos
+	a.addVideoPlaylistParameters(p)
 	// Add images not in content
 	images := p.Parameters[a.cfg.Micropub.PhotoParam]
 	imageAlts := p.Parameters[a.cfg.Micropub.PhotoDescriptionParam]
@@ -572,6 +750,9 @@ func (a *goBlog) micropubUpdateReplace(p *post, replace map[string][]any) {
 	if audio, ok := replace["audio"]; ok && audio != nil {
 		p.Parameters[a.cfg.Micropub.AudioParam] = cast.ToStringSlice(audio)
 	}
+	if series, ok := replace["series"]; ok && series != nil {
+		p.Parameters[seriesParam] = cast.ToStringSlice(series)
+	}
 	// TODO: photos
 }
 
@@ -594,6 +775,8 @@ func (a *goBlog) micropubUpdateAdd(p *post, add map[string][]any) {
 			p.Parameters[a.cfg.Micropub.BookmarkParam] = cast.ToStringSlice(value)
 		case "audio":
 			p.Parameters[a.cfg.Micropub.AudioParam] = append(p.Parameters[a.cfg.Micropub.AudioParam], cast.ToStringSlice(value)...)
+		case "series":
+			p.Parameters[seriesParam] = cast.ToStringSlice(value)
 			// TODO: photo
 		}
 	}
@@ -626,6 +809,8 @@ func (a *goBlog) micropubUpdateDelete(p *post, del any) {
 				delete(p.Parameters, a.cfg.Micropub.BookmarkParam)
 			case "audio":
 				delete(p.Parameters, a.cfg.Micropub.AudioParam)
+			case "series":
+				delete(p.Parameters, seriesParam)
 			case "photo":
 				delete(p.Parameters, a.cfg.Micropub.PhotoParam)
 				delete(p.Parameters, a.cfg.Micropub.PhotoDescriptionParam)
@@ -654,6 +839,8 @@ func (a *goBlog) micropubUpdateDelete(p *post, del any) {
 				delete(p.Parameters, a.cfg.Micropub.LikeTitleParam)
 			case "bookmark-of":
 				delete(p.Parameters, a.cfg.Micropub.BookmarkParam)
+			case "series":
+				delete(p.Parameters, seriesParam)
 			// Properties to delete part of
 			// TODO: Support partial deletes of more properties
 			case "category":
@@ -2,46 +2,183 @@ package main
 
 import (
 	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"mime"
 	"net/http"
+	"net/url"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/samber/lo"
 	"go.goblog.app/app/pkgs/bufferpool"
 	"go.goblog.app/app/pkgs/contenttype"
 )
 
 const micropubMediaSubPath = "/media"
 
+func (a *goBlog) serveMicropubMediaQuery(w http.ResponseWriter, r *http.Request) {
+	// Check scope
+	if !a.micropubCheckScope(w, r, "media") {
+		return
+	}
+	var result any
+	switch query := r.URL.Query(); query.Get("q") {
+	case "source":
+		files, err := a.mediaFiles()
+		if err != nil {
+			a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// Sort files time desc
+		sort.Slice(files, func(i, j int) bool {
+			return files[i].Time.After(files[j].Time)
+		})
+		// Apply offset and limit
+		if offset := stringToInt(query.Get("offset")); offset > 0 {
+			files = lo.Drop(files, offset)
+		}
+		if limit := stringToInt(query.Get("limit")); limit > 0 && limit < len(files) {
+			files = files[:limit]
+		}
+		type micropubMediaItem struct {
+			URL       string `json:"url"`
+			Published string `json:"published"`
+			MimeType  string `json:"mime_type,omitempty"`
+		}
+		items := []*micropubMediaItem{}
+		for _, f := range files {
+			items = append(items, &micropubMediaItem{
+				URL:       a.getFullAddress(f.Location),
+				Published: f.Time.Format(time.RFC3339),
+				MimeType:  mime.TypeByExtension(path.Ext(f.Name)),
+			})
+		}
+		result = map[string]any{"items": items}
+	case "upload-url":
+		a.initMediaStorage()
+		presigner, ok := a.mediaStorage.(mediaStoragePresigner)
+		if !ok {
+			a.serveError(w, r, "media storage doesn't support direct uploads", http.StatusNotImplemented)
+			return
+		}
+		fileName := strings.ToLower(randomString(32)) + strings.ToLower(path.Ext(query.Get("filename")))
+		uploadURL, err := presigner.presignedUpload(fileName)
+		if err != nil {
+			a.serveError(w, r, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		result = map[string]any{
+			"upload-url": uploadURL,
+			"url":        a.getFullAddress(a.mediaFileLocation(fileName)),
+		}
+	default:
+		a.serve404(w, r)
+		return
+	}
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+	if err := json.NewEncoder(buf).Encode(result); err != nil {
+		a.serveError(w, r, "Failed to encode json", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(contentType, contenttype.JSONUTF8)
+	_ = a.min.Get().Minify(contenttype.JSON, w, buf)
+}
+
 func (a *goBlog) serveMicropubMedia(w http.ResponseWriter, r *http.Request) {
 	// Check scope
 	if !a.micropubCheckScope(w, r, "media") {
 		return
 	}
-	// Check if request is multipart
-	if ct := r.Header.Get(contentType); !strings.Contains(ct, contenttype.MultipartForm) {
+	switch mt, _, _ := mime.ParseMediaType(r.Header.Get(contentType)); mt {
+	case contenttype.MultipartForm:
+		// Parse multipart form
+		if err := r.ParseMultipartForm(0); err != nil {
+			a.serveError(w, r, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if action := micropubAction(r.Form.Get("action")); action != "" {
+			a.micropubMediaAction(w, r, action, r.Form.Get("url"), r.Form.Get("force") == "true")
+			return
+		}
+		a.micropubMediaUpload(w, r)
+	case contenttype.WWWForm:
+		if err := r.ParseForm(); err != nil {
+			a.serveError(w, r, err.Error(), http.StatusBadRequest)
+			return
+		}
+		a.micropubMediaAction(w, r, micropubAction(r.Form.Get("action")), r.Form.Get("url"), r.Form.Get("force") == "true")
+	case contenttype.JSON:
+		parsedAction := &struct {
+			Action micropubAction `json:"action"`
+			URL    string         `json:"url"`
+			Force  bool           `json:"force"`
+		}{}
+		if err := json.NewDecoder(io.LimitReader(r.Body, 100000)).Decode(parsedAction); err != nil {
+			a.serveError(w, r, err.Error(), http.StatusBadRequest)
+			return
+		}
+		a.micropubMediaAction(w, r, parsedAction.Action, parsedAction.URL, parsedAction.Force)
+	default:
 		a.serveError(w, r, "wrong content-type", http.StatusBadRequest)
+	}
+}
+
+func (a *goBlog) micropubMediaAction(w http.ResponseWriter, r *http.Request, action micropubAction, u string, force bool) {
+	if action != actionDelete {
+		a.serveError(w, r, "Action not supported", http.StatusNotImplemented)
 		return
 	}
-	// Parse multipart form
-	err := r.ParseMultipartForm(0)
-	if err != nil {
-		a.serveError(w, r, err.Error(), http.StatusBadRequest)
+	if !a.micropubCheckScope(w, r, "delete") {
+		return
+	}
+	parsedURL, err := url.Parse(u)
+	if err != nil || u == "" {
+		a.serveError(w, r, "invalid url", http.StatusBadRequest)
+		return
+	}
+	// Check if the URL belongs to the media storage
+	fileName := path.Base(parsedURL.Path)
+	if a.getFullAddress(a.mediaFileLocation(fileName)) != a.getFullAddress(u) {
+		a.serveError(w, r, "url is not a media file", http.StatusBadRequest)
+		return
+	}
+	// Check if the file is still used
+	if !force {
+		uses, err := a.db.usesOfMediaFile(fileName)
+		if err != nil {
+			a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if len(uses) > 0 && uses[0] > 0 {
+			a.serveError(w, r, fmt.Sprintf("media file is used by %d posts, set force to delete anyway", uses[0]), http.StatusConflict)
+			return
+		}
+	}
+	if err := a.deleteMedia(fileName); err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (a *goBlog) micropubMediaUpload(w http.ResponseWriter, r *http.Request) {
 	// Get file
 	file, header, err := r.FormFile("file")
 	if err != nil {
 		a.serveError(w, r, err.Error(), http.StatusBadRequest)
 		return
 	}
-	// Read the file into temporary buffer and generate sha256 hash
-	hash := sha256.New()
+	// Read the file into temporary buffer
 	buffer := bufferpool.Get()
 	defer bufferpool.Put(buffer)
-	_, _ = io.Copy(buffer, io.TeeReader(file, hash))
+	_, _ = io.Copy(buffer, file)
 	_ = file.Close()
 	_ = r.Body.Close()
 	// Get file extension
@@ -56,8 +193,16 @@ func (a *goBlog) serveMicropubMedia(w http.ResponseWriter, r *http.Request) {
 			}
 		}
 	}
+	// Remove sensitive metadata
+	metadata, err := a.processMediaExif(fileExtension, buffer)
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// Keep the data, saving reads the buffer
+	data := buffer.Bytes()
 	// Generate the file name
-	fileName := fmt.Sprintf("%x%s", hash.Sum(nil), fileExtension)
+	fileName := fmt.Sprintf("%x%s", sha256.Sum256(data), fileExtension)
 	// Save file
 	location, err := a.saveMediaFile(fileName, buffer)
 	if err != nil {
@@ -65,6 +210,7 @@ func (a *goBlog) serveMicropubMedia(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	// Try to compress file (only when not in private mode)
+	originalLocation := location
 	if !a.isPrivate() {
 		compressedLocation, compressionErr := a.compressMediaFile(location)
 		if compressionErr != nil {
@@ -75,6 +221,25 @@ func (a *goBlog) serveMicropubMedia(w http.ResponseWriter, r *http.Request) {
 		if compressedLocation != "" {
 			location = compressedLocation
 		}
+		// Create responsive derivatives
+		if err = a.createMediaDerivatives(originalLocation, location); err != nil {
+			log.Println("Failed to create media derivatives:", err.Error())
+		}
+	}
+	// Save metadata
+	if err = a.db.saveMediaExif(lo.Uniq([]string{originalLocation, location}), metadata); err != nil {
+		log.Println("Failed to save media metadata:", err.Error())
+	}
+	a.saveMediaUpload(r, header.Filename, originalLocation, location, data, metadata)
+	// Audio size and duration for podcast feeds
+	if strings.HasPrefix(mime.TypeByExtension(fileExtension), "audio/") {
+		a.saveMediaAudioInfo(location, data)
+	}
+	// Transcode videos in the background
+	if a.videoTranscodingEnabled() && strings.HasPrefix(mime.TypeByExtension(fileExtension), "video/") {
+		if err = a.queueVideoTranscoding(location); err != nil {
+			log.Println("Failed to queue video transcoding:", err.Error())
+		}
 	}
 	http.Redirect(w, r, location, http.StatusCreated)
 }
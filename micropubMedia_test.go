@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.goblog.app/app/pkgs/contenttype"
+)
+
+func Test_micropubMedia(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	_ = app.initConfig()
+	_ = app.initDatabase(false)
+	defer app.db.close()
+	app.initComponents(false)
+
+	// Use temporary media storage
+	mediaDir := t.TempDir()
+	app.mediaStorageInit.Do(func() {
+		app.mediaStorage = &localMediaStorage{path: mediaDir}
+	})
+	for _, name := range []string{"used.jpg", "unused.jpg"} {
+		require.NoError(t, os.WriteFile(filepath.Join(mediaDir, name), []byte("test"), 0644))
+	}
+
+	// Create a post using one of the files
+	err := app.createPost(&post{
+		Path:    "/test/post",
+		Content: "![](http://localhost:8080/m/used.jpg)",
+	})
+	require.NoError(t, err)
+
+	withScope := func(r *http.Request, scope string) *http.Request {
+		return r.WithContext(context.WithValue(r.Context(), indieAuthScope, scope))
+	}
+
+	t.Run("Source", func(t *testing.T) {
+		req := withScope(httptest.NewRequest(http.MethodGet, "http://localhost:8080/micropub/media?q=source&limit=1", nil), "media")
+		rec := httptest.NewRecorder()
+		app.serveMicropubMediaQuery(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "\"mime_type\":\"image/jpeg\"")
+		assert.Equal(t, 1, strings.Count(rec.Body.String(), "\"url\":"))
+	})
+
+	t.Run("Upload URL not supported", func(t *testing.T) {
+		req := withScope(httptest.NewRequest(http.MethodGet, "http://localhost:8080/micropub/media?q=upload-url&filename=test.jpg", nil), "media")
+		rec := httptest.NewRecorder()
+		app.serveMicropubMediaQuery(rec, req)
+
+		assert.Equal(t, http.StatusNotImplemented, rec.Code)
+	})
+
+	deleteReq := func(u string, force bool) *http.Request {
+		values := url.Values{"action": {"delete"}, "url": {u}}
+		if force {
+			values.Set("force", "true")
+		}
+		req := httptest.NewRequest(http.MethodPost, "http://localhost:8080/micropub/media", strings.NewReader(values.Encode()))
+		req.Header.Set(contentType, contenttype.WWWForm)
+		return req
+	}
+
+	t.Run("Delete without scope", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		app.serveMicropubMedia(rec, withScope(deleteReq("http://localhost:8080/m/unused.jpg", false), "media"))
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("Delete unused file", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		app.serveMicropubMedia(rec, withScope(deleteReq("http://localhost:8080/m/unused.jpg", false), "media delete"))
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.NoFileExists(t, filepath.Join(mediaDir, "unused.jpg"))
+	})
+
+	t.Run("Delete used file", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		app.serveMicropubMedia(rec, withScope(deleteReq("http://localhost:8080/m/used.jpg", false), "media delete"))
+		assert.Equal(t, http.StatusConflict, rec.Code)
+		assert.FileExists(t, filepath.Join(mediaDir, "used.jpg"))
+
+		rec = httptest.NewRecorder()
+		app.serveMicropubMedia(rec, withScope(deleteReq("http://localhost:8080/m/used.jpg", true), "media delete"))
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.NoFileExists(t, filepath.Join(mediaDir, "used.jpg"))
+	})
+
+	t.Run("Delete foreign file", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		app.serveMicropubMedia(rec, withScope(deleteReq("https://example.com/test.jpg", true), "media delete"))
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
@@ -38,7 +38,7 @@ func Test_micropubQuery(t *testing.T) {
 	testCases := []testCase{
 		{
 			query:      "config",
-			want:       "{\"media-endpoint\":\"http://localhost:8080/micropub/media\"}",
+			want:       "{\"media-endpoint\":\"http://localhost:8080/micropub/media\",\"syndicate-to\":[],\"channels\":[{\"uid\":\"default\",\"name\":\"My Blog\"},{\"uid\":\"default/posts\",\"name\":\"My Blog - Posts\"}],\"post-types\":[{\"type\":\"note\",\"name\":\"Note\"},{\"type\":\"article\",\"name\":\"Article\"},{\"type\":\"reply\",\"name\":\"Reply\"},{\"type\":\"like\",\"name\":\"Like\"},{\"type\":\"bookmark\",\"name\":\"Bookmark\"},{\"type\":\"photo\",\"name\":\"Photo\"},{\"type\":\"audio\",\"name\":\"Audio\"}]}",
 			wantStatus: http.StatusOK,
 		},
 		{
@@ -56,6 +56,40 @@ func Test_micropubQuery(t *testing.T) {
 			want:       "{\"categories\":[\"test\",\"test2\"]}",
 			wantStatus: http.StatusOK,
 		},
+		{
+			query:      "source&post-type=note&search=test",
+			want:       "{\"items\":[{\"type\":[\"h-entry\"],\"properties\":{\"published\":[\"\"],\"updated\":[\"\"],\"post-status\":[\"published\"],\"visibility\":[\"public\"],\"category\":[\"test\",\"test2\"],\"content\":[\"---\\nblog: default\\npath: /test/post\\npriority: 0\\npublished: \\\"\\\"\\nsection: \\\"\\\"\\nstatus: published\\ntags:\\n    - test\\n    - test2\\nupdated: \\\"\\\"\\n---\\nTest post\"],\"url\":[\"http://localhost:8080/test/post\"],\"mp-slug\":[\"\"]}}]}",
+			wantStatus: http.StatusOK,
+		},
+		{
+			query:      "source&post-type=reply",
+			want:       "{}",
+			wantStatus: http.StatusOK,
+		},
+		{
+			query:      "source&post-status=draft",
+			want:       "{}",
+			wantStatus: http.StatusOK,
+		},
+		{
+			query:      "source&mp-channel=default/posts",
+			want:       "{}",
+			wantStatus: http.StatusOK,
+		},
+		{
+			query:      "source&post-type=unknown",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			query:      "syndicate-to",
+			want:       "{\"syndicate-to\":[]}",
+			wantStatus: http.StatusOK,
+		},
+		{
+			query:      "channel",
+			want:       "{\"channels\":[{\"uid\":\"default\",\"name\":\"My Blog\"},{\"uid\":\"default/posts\",\"name\":\"My Blog - Posts\"}]}",
+			wantStatus: http.StatusOK,
+		},
 		{
 			query:      "somethingelse",
 			wantStatus: http.StatusNotFound,
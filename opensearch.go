@@ -9,12 +9,12 @@ import (
 )
 
 type openSearchDescription struct {
-	XMLName     xml.Name                  `xml:"http://a9.com/-/spec/opensearch/1.1/ OpenSearchDescription"`
-	Text        string                    `xml:",chardata"`
-	ShortName   string                    `xml:"ShortName"`
-	Description string                    `xml:"Description"`
-	URL         *openSearchDescriptionUrl `xml:"Url"`
-	SearchForm  string                    `xml:"http://www.mozilla.org/2006/browser/search/ SearchForm"`
+	XMLName     xml.Name                    `xml:"http://a9.com/-/spec/opensearch/1.1/ OpenSearchDescription"`
+	Text        string                      `xml:",chardata"`
+	ShortName   string                      `xml:"ShortName"`
+	Description string                      `xml:"Description"`
+	URLs        []*openSearchDescriptionUrl `xml:"Url"`
+	SearchForm  string                      `xml:"http://www.mozilla.org/2006/browser/search/ SearchForm"`
 }
 
 type openSearchDescriptionUrl struct {
@@ -22,7 +22,7 @@ type openSearchDescriptionUrl struct {
 	Type     string                         `xml:"type,attr"`
 	Method   string                         `xml:"method,attr"`
 	Template string                         `xml:"template,attr"`
-	Param    *openSearchDescriptionUrlParam `xml:"Param"`
+	Param    *openSearchDescriptionUrlParam `xml:"Param,omitempty"`
 }
 
 type openSearchDescriptionUrlParam struct {
@@ -38,13 +38,20 @@ func (a *goBlog) serveOpenSearch(w http.ResponseWriter, r *http.Request) {
 	openSearch := &openSearchDescription{
 		ShortName:   title,
 		Description: title,
-		URL: &openSearchDescriptionUrl{
-			Type:     "text/html",
-			Method:   "post",
-			Template: sURL,
-			Param: &openSearchDescriptionUrlParam{
-				Name:  "q",
-				Value: "{searchTerms}",
+		URLs: []*openSearchDescriptionUrl{
+			{
+				Type:     "text/html",
+				Method:   "post",
+				Template: sURL,
+				Param: &openSearchDescriptionUrlParam{
+					Name:  "q",
+					Value: "{searchTerms}",
+				},
+			},
+			{
+				Type:     "application/x-suggestions+json",
+				Method:   "get",
+				Template: sURL + "/suggest?q={searchTerms}",
 			},
 		},
 		SearchForm: sURL,
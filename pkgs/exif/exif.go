@@ -0,0 +1,385 @@
+// Package exif reads and removes the EXIF metadata of JPEG and PNG images.
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"math"
+	"strings"
+	"time"
+)
+
+var ErrNoExif = errors.New("no exif data found")
+
+// Metadata contains the interesting parts of the EXIF data.
+type Metadata struct {
+	Orientation int
+	Time        time.Time
+	Make        string
+	Model       string
+	Description string
+	HasLocation bool
+	Latitude    float64
+	Longitude   float64
+}
+
+// Camera returns make and model, without repeating the make.
+func (m *Metadata) Camera() string {
+	if strings.HasPrefix(strings.ToLower(m.Model), strings.ToLower(m.Make)) {
+		return m.Model
+	}
+	return strings.TrimSpace(m.Make + " " + m.Model)
+}
+
+var (
+	jpegExifHeader = []byte("Exif\x00\x00")
+	pngSignature   = []byte("\x89PNG\r\n\x1a\n")
+)
+
+// Read parses the EXIF data of a JPEG or PNG image.
+func Read(data []byte) (*Metadata, error) {
+	tiff, err := findTiff(data)
+	if err != nil {
+		return nil, err
+	}
+	return parseTiff(tiff)
+}
+
+func findTiff(data []byte) ([]byte, error) {
+	if bytes.HasPrefix(data, pngSignature) {
+		var tiff []byte
+		err := walkPngChunks(data, func(typ string, chunk, _ []byte) bool {
+			if typ == "eXIf" {
+				tiff = chunk
+				return false
+			}
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+		if tiff == nil {
+			return nil, ErrNoExif
+		}
+		return tiff, nil
+	}
+	var tiff []byte
+	err := walkJpegSegments(data, func(marker byte, segment, _ []byte) bool {
+		if marker == 0xE1 && bytes.HasPrefix(segment, jpegExifHeader) {
+			tiff = segment[len(jpegExifHeader):]
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if tiff == nil {
+		return nil, ErrNoExif
+	}
+	return tiff, nil
+}
+
+// Strip removes EXIF, XMP and IPTC metadata. If description is not empty,
+// a new EXIF segment containing only the image description is added to JPEG images.
+func Strip(data []byte, description string) ([]byte, error) {
+	var buf bytes.Buffer
+	if bytes.HasPrefix(data, pngSignature) {
+		buf.Write(pngSignature)
+		err := walkPngChunks(data, func(typ string, _, raw []byte) bool {
+			switch typ {
+			case "eXIf", "iTXt", "tEXt", "zTXt":
+				// EXIF and text chunks (XMP is stored in iTXt)
+			default:
+				buf.Write(raw)
+			}
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	buf.Write([]byte{0xFF, 0xD8})
+	written := false
+	err := walkJpegSegments(data, func(marker byte, segment, raw []byte) bool {
+		// APP1 (EXIF and XMP) and APP13 (IPTC)
+		if marker == 0xE1 || marker == 0xED {
+			return true
+		}
+		if !written && description != "" && marker != 0xE0 {
+			// Insert after JFIF header
+			writeDescriptionSegment(&buf, description)
+			written = true
+		}
+		buf.Write(raw)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeDescriptionSegment(buf *bytes.Buffer, description string) {
+	value := append([]byte(description), 0)
+	var tiff bytes.Buffer
+	tiff.WriteString("MM\x00\x2a")
+	_ = binary.Write(&tiff, binary.BigEndian, uint32(8))
+	// IFD0 with one entry
+	_ = binary.Write(&tiff, binary.BigEndian, uint16(1))
+	_ = binary.Write(&tiff, binary.BigEndian, uint16(0x010E))
+	_ = binary.Write(&tiff, binary.BigEndian, uint16(2))
+	_ = binary.Write(&tiff, binary.BigEndian, uint32(len(value)))
+	if len(value) <= 4 {
+		padded := make([]byte, 4)
+		copy(padded, value)
+		tiff.Write(padded)
+		_ = binary.Write(&tiff, binary.BigEndian, uint32(0))
+	} else {
+		_ = binary.Write(&tiff, binary.BigEndian, uint32(8+2+12+4))
+		_ = binary.Write(&tiff, binary.BigEndian, uint32(0))
+		tiff.Write(value)
+	}
+	buf.Write([]byte{0xFF, 0xE1})
+	_ = binary.Write(buf, binary.BigEndian, uint16(2+len(jpegExifHeader)+tiff.Len()))
+	buf.Write(jpegExifHeader)
+	buf.Write(tiff.Bytes())
+}
+
+// Calls f for each segment until the image data starts, the rest is passed as raw with marker 0.
+func walkJpegSegments(data []byte, f func(marker byte, segment, raw []byte) bool) error {
+	if len(data) < 2 || data[0] != 0xFF || data[1] != 0xD8 {
+		return errors.New("not a jpeg image")
+	}
+	pos := 2
+	for pos < len(data) {
+		if data[pos] != 0xFF || pos+1 >= len(data) {
+			return errors.New("invalid jpeg segment")
+		}
+		marker := data[pos+1]
+		if marker == 0xDA || marker == 0xD9 {
+			// Start of scan or end of image, keep the rest
+			f(0, nil, data[pos:])
+			return nil
+		}
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) || marker == 0xFF {
+			// No length
+			if !f(marker, nil, data[pos:pos+2]) {
+				return nil
+			}
+			pos += 2
+			continue
+		}
+		if pos+4 > len(data) {
+			return errors.New("invalid jpeg segment")
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return errors.New("invalid jpeg segment length")
+		}
+		if !f(marker, data[pos+4:end], data[pos:end]) {
+			return nil
+		}
+		pos = end
+	}
+	return nil
+}
+
+func walkPngChunks(data []byte, f func(typ string, chunk, raw []byte) bool) error {
+	pos := len(pngSignature)
+	for pos < len(data) {
+		if pos+12 > len(data) {
+			return errors.New("invalid png chunk")
+		}
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		end := pos + 12 + length
+		if length < 0 || end > len(data) {
+			return errors.New("invalid png chunk length")
+		}
+		typ := string(data[pos+4 : pos+8])
+		chunk := data[pos+8 : pos+8+length]
+		if crc32.ChecksumIEEE(data[pos+4:pos+8+length]) != binary.BigEndian.Uint32(data[pos+8+length:end]) {
+			return errors.New("invalid png chunk checksum")
+		}
+		if !f(typ, chunk, data[pos:end]) {
+			return nil
+		}
+		pos = end
+	}
+	return nil
+}
+
+const (
+	tagImageDescription   = 0x010E
+	tagMake               = 0x010F
+	tagModel              = 0x0110
+	tagOrientation        = 0x0112
+	tagDateTime           = 0x0132
+	tagExifIFD            = 0x8769
+	tagGPSIFD             = 0x8825
+	tagDateTimeOriginal   = 0x9003
+	tagOffsetTimeOriginal = 0x9011
+	tagGPSLatitudeRef     = 0x0001
+	tagGPSLatitude        = 0x0002
+	tagGPSLongitudeRef    = 0x0003
+	tagGPSLongitude       = 0x0004
+)
+
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+type ifdEntry struct {
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+var typeSizes = map[uint16]int{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 7: 1, 9: 4, 10: 8}
+
+func parseTiff(data []byte) (*Metadata, error) {
+	if len(data) < 8 {
+		return nil, ErrNoExif
+	}
+	t := &tiffReader{data: data}
+	switch string(data[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return nil, errors.New("invalid tiff header")
+	}
+	ifd0, err := t.readIFD(t.order.Uint32(data[4:8]))
+	if err != nil {
+		return nil, err
+	}
+	m := &Metadata{
+		Orientation: 1,
+		Make:        t.string(ifd0[tagMake]),
+		Model:       t.string(ifd0[tagModel]),
+		Description: t.string(ifd0[tagImageDescription]),
+	}
+	if o := t.uint(ifd0[tagOrientation]); o >= 1 && o <= 8 {
+		m.Orientation = int(o)
+	}
+	dateTime, offset := t.string(ifd0[tagDateTime]), ""
+	if e := ifd0[tagExifIFD]; e != nil {
+		if exifIFD, err := t.readIFD(t.uint(e)); err == nil {
+			if dto := t.string(exifIFD[tagDateTimeOriginal]); dto != "" {
+				dateTime = dto
+				offset = t.string(exifIFD[tagOffsetTimeOriginal])
+			}
+		}
+	}
+	if dateTime != "" {
+		if offset != "" {
+			m.Time, _ = time.Parse("2006:01:02 15:04:05-07:00", dateTime+offset)
+		} else {
+			m.Time, _ = time.ParseInLocation("2006:01:02 15:04:05", dateTime, time.Local)
+		}
+	}
+	if g := ifd0[tagGPSIFD]; g != nil {
+		if gpsIFD, err := t.readIFD(t.uint(g)); err == nil {
+			lat, latOk := t.coordinate(gpsIFD[tagGPSLatitude])
+			lon, lonOk := t.coordinate(gpsIFD[tagGPSLongitude])
+			if latOk && lonOk {
+				if t.string(gpsIFD[tagGPSLatitudeRef]) == "S" {
+					lat = -lat
+				}
+				if t.string(gpsIFD[tagGPSLongitudeRef]) == "W" {
+					lon = -lon
+				}
+				m.HasLocation, m.Latitude, m.Longitude = true, lat, lon
+			}
+		}
+	}
+	return m, nil
+}
+
+func (t *tiffReader) readIFD(offset uint32) (map[uint16]*ifdEntry, error) {
+	pos := int(offset)
+	if pos < 8 || pos+2 > len(t.data) {
+		return nil, errors.New("invalid ifd offset")
+	}
+	count := int(t.order.Uint16(t.data[pos : pos+2]))
+	pos += 2
+	entries := map[uint16]*ifdEntry{}
+	for i := 0; i < count; i++ {
+		if pos+12 > len(t.data) {
+			return nil, errors.New("invalid ifd entry")
+		}
+		e := t.data[pos : pos+12]
+		pos += 12
+		typ := t.order.Uint16(e[2:4])
+		size, ok := typeSizes[typ]
+		if !ok {
+			continue
+		}
+		valueCount := t.order.Uint32(e[4:8])
+		total := int(valueCount) * size
+		if total < 0 || total > len(t.data) {
+			continue
+		}
+		var value []byte
+		if total <= 4 {
+			value = e[8 : 8+total]
+		} else {
+			valueOffset := int(t.order.Uint32(e[8:12]))
+			if valueOffset < 0 || valueOffset+total > len(t.data) {
+				continue
+			}
+			value = t.data[valueOffset : valueOffset+total]
+		}
+		entries[t.order.Uint16(e[0:2])] = &ifdEntry{typ: typ, count: valueCount, value: value}
+	}
+	return entries, nil
+}
+
+func (t *tiffReader) string(e *ifdEntry) string {
+	if e == nil || e.typ != 2 {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimRight(string(e.value), "\x00"))
+}
+
+func (t *tiffReader) uint(e *ifdEntry) uint32 {
+	if e == nil || e.count < 1 {
+		return 0
+	}
+	switch e.typ {
+	case 3:
+		return uint32(t.order.Uint16(e.value))
+	case 4:
+		return t.order.Uint32(e.value)
+	}
+	return 0
+}
+
+// Degrees, minutes and seconds as rationals
+func (t *tiffReader) coordinate(e *ifdEntry) (float64, bool) {
+	if e == nil || e.typ != 5 || e.count != 3 {
+		return 0, false
+	}
+	result := 0.0
+	for i, div := range []float64{1, 60, 3600} {
+		num := t.order.Uint32(e.value[i*8:])
+		den := t.order.Uint32(e.value[i*8+4:])
+		if den == 0 {
+			if num == 0 {
+				continue
+			}
+			return 0, false
+		}
+		result += float64(num) / float64(den) / div
+	}
+	if math.IsNaN(result) || result > 180 {
+		return 0, false
+	}
+	return result, true
+}
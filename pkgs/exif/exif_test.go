@@ -0,0 +1,185 @@
+package exif
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testEntry struct {
+	tag, typ uint16
+	count    uint32
+	value    []byte
+}
+
+// Big endian IFD at offset base, values are appended after the IFD
+func buildIFD(base int, entries []testEntry) []byte {
+	var ifd, values bytes.Buffer
+	valuesOffset := base + 2 + 12*len(entries) + 4
+	_ = binary.Write(&ifd, binary.BigEndian, uint16(len(entries)))
+	for _, e := range entries {
+		_ = binary.Write(&ifd, binary.BigEndian, e.tag)
+		_ = binary.Write(&ifd, binary.BigEndian, e.typ)
+		_ = binary.Write(&ifd, binary.BigEndian, e.count)
+		if len(e.value) <= 4 {
+			padded := make([]byte, 4)
+			copy(padded, e.value)
+			ifd.Write(padded)
+		} else {
+			_ = binary.Write(&ifd, binary.BigEndian, uint32(valuesOffset+values.Len()))
+			values.Write(e.value)
+		}
+	}
+	_ = binary.Write(&ifd, binary.BigEndian, uint32(0))
+	return append(ifd.Bytes(), values.Bytes()...)
+}
+
+func u32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	return b
+}
+
+func rationals(values ...uint32) []byte {
+	b := make([]byte, 0, len(values)*8)
+	for _, v := range values {
+		b = append(b, u32(v)...)
+		b = append(b, u32(1)...)
+	}
+	return b
+}
+
+func testTiff() []byte {
+	gpsIFD := func(base int) []byte {
+		return buildIFD(base, []testEntry{
+			{tagGPSLatitudeRef, 2, 2, []byte("N\x00")},
+			{tagGPSLatitude, 5, 3, rationals(52, 30, 0)},
+			{tagGPSLongitudeRef, 2, 2, []byte("W\x00")},
+			{tagGPSLongitude, 5, 3, rationals(13, 15, 0)},
+		})
+	}
+	ifd0 := func(gpsOffset int) []byte {
+		return buildIFD(8, []testEntry{
+			{tagImageDescription, 2, 6, []byte("Beach\x00")},
+			{tagMake, 2, 6, []byte("Canon\x00")},
+			{tagModel, 2, 12, []byte("Canon EOS R\x00")},
+			{tagOrientation, 3, 1, []byte{0, 6}},
+			{tagDateTime, 2, 20, []byte("2022:05:01 10:30:00\x00")},
+			{tagGPSIFD, 4, 1, u32(uint32(gpsOffset))},
+		})
+	}
+	gpsOffset := 8 + len(ifd0(0))
+	tiff := append([]byte("MM\x00\x2a\x00\x00\x00\x08"), ifd0(gpsOffset)...)
+	return append(tiff, gpsIFD(gpsOffset)...)
+}
+
+func testJpeg(t *testing.T) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 4, 4)), nil))
+	data := buf.Bytes()
+	tiff := testTiff()
+	var segment bytes.Buffer
+	segment.Write([]byte{0xFF, 0xE1})
+	_ = binary.Write(&segment, binary.BigEndian, uint16(2+len(jpegExifHeader)+len(tiff)))
+	segment.Write(jpegExifHeader)
+	segment.Write(tiff)
+	return append(append([]byte{0xFF, 0xD8}, segment.Bytes()...), data[2:]...)
+}
+
+func TestRead(t *testing.T) {
+	m, err := Read(testJpeg(t))
+	require.NoError(t, err)
+
+	assert.Equal(t, 6, m.Orientation)
+	assert.Equal(t, "Beach", m.Description)
+	assert.Equal(t, "Canon EOS R", m.Camera())
+	assert.Equal(t, 2022, m.Time.Year())
+	assert.Equal(t, 30, m.Time.Minute())
+	assert.True(t, m.HasLocation)
+	assert.InDelta(t, 52.5, m.Latitude, 0.0001)
+	assert.InDelta(t, -13.25, m.Longitude, 0.0001)
+
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, image.NewGray(image.Rect(0, 0, 4, 4)), nil))
+	_, err = Read(buf.Bytes())
+	assert.ErrorIs(t, err, ErrNoExif)
+}
+
+func TestStrip(t *testing.T) {
+	t.Run("JPEG", func(t *testing.T) {
+		stripped, err := Strip(testJpeg(t), "")
+		require.NoError(t, err)
+
+		_, err = Read(stripped)
+		assert.ErrorIs(t, err, ErrNoExif)
+		_, err = jpeg.Decode(bytes.NewReader(stripped))
+		assert.NoError(t, err)
+	})
+
+	t.Run("JPEG with description", func(t *testing.T) {
+		stripped, err := Strip(testJpeg(t), "Beach at sunset")
+		require.NoError(t, err)
+
+		m, err := Read(stripped)
+		require.NoError(t, err)
+		assert.Equal(t, "Beach at sunset", m.Description)
+		assert.False(t, m.HasLocation)
+		assert.Equal(t, "", m.Make)
+		_, err = jpeg.Decode(bytes.NewReader(stripped))
+		assert.NoError(t, err)
+	})
+
+	t.Run("PNG", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 4, 4))))
+		data := buf.Bytes()
+		// Insert eXIf chunk after IHDR
+		ihdrEnd := len(pngSignature) + 12 + 13
+		tiff := testTiff()
+		chunk := u32(uint32(len(tiff)))
+		chunk = append(chunk, "eXIf"...)
+		chunk = append(chunk, tiff...)
+		chunk = append(chunk, u32(crc32.ChecksumIEEE(chunk[4:]))...)
+		withExif := append(append(append([]byte{}, data[:ihdrEnd]...), chunk...), data[ihdrEnd:]...)
+
+		m, err := Read(withExif)
+		require.NoError(t, err)
+		assert.True(t, m.HasLocation)
+
+		stripped, err := Strip(withExif, "")
+		require.NoError(t, err)
+		assert.Equal(t, data, stripped)
+	})
+
+	t.Run("PNG XMP", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, 4, 4))))
+		data := buf.Bytes()
+		// Insert iTXt chunk with XMP location and tEXt comment after IHDR
+		ihdrEnd := len(pngSignature) + 12 + 13
+		pngChunk := func(typ string, content []byte) []byte {
+			chunk := u32(uint32(len(content)))
+			chunk = append(chunk, typ...)
+			chunk = append(chunk, content...)
+			return append(chunk, u32(crc32.ChecksumIEEE(chunk[4:]))...)
+		}
+		xmp := `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">` +
+			`<rdf:Description xmlns:exif="http://ns.adobe.com/exif/1.0/" exif:GPSLatitude="52,30.0N" exif:GPSLongitude="13,15.0E"/>` +
+			`</rdf:RDF></x:xmpmeta>`
+		itxt := append([]byte("XML:com.adobe.xmp\x00\x00\x00\x00\x00"), xmp...)
+		chunks := append(pngChunk("iTXt", itxt), pngChunk("tEXt", []byte("Comment\x00Home"))...)
+		withXmp := append(append(append([]byte{}, data[:ihdrEnd]...), chunks...), data[ihdrEnd:]...)
+
+		stripped, err := Strip(withXmp, "")
+		require.NoError(t, err)
+		assert.Equal(t, data, stripped)
+		assert.NotContains(t, string(stripped), "GPSLatitude")
+	})
+}
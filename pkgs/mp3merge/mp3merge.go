@@ -3,6 +3,7 @@ package mp3merge
 import (
 	"errors"
 	"io"
+	"time"
 
 	"github.com/dmulholl/mp3lib"
 	"go.goblog.app/app/pkgs/bufferpool"
@@ -77,3 +78,32 @@ func MergeMP3(out io.Writer, in ...io.Reader) error {
 	_, err := io.Copy(out, tmpOut)
 	return err
 }
+
+// Duration calculates the playing time of an mp3 from its frames.
+func Duration(in io.Reader) (time.Duration, error) {
+	if in == nil {
+		return 0, errors.New("nil input")
+	}
+	var duration time.Duration
+	isFirstFrame := true
+	for {
+		frame := mp3lib.NextFrame(in)
+		if frame == nil {
+			break
+		}
+		// Skip the VBR header, it contains no audio
+		if isFirstFrame {
+			isFirstFrame = false
+			if mp3lib.IsXingHeader(frame) || mp3lib.IsVbriHeader(frame) {
+				continue
+			}
+		}
+		if frame.SamplingRate > 0 {
+			duration += time.Duration(float64(frame.SampleCount) / float64(frame.SamplingRate) * float64(time.Second))
+		}
+	}
+	if isFirstFrame {
+		return 0, errors.New("no mp3 frames found")
+	}
+	return duration, nil
+}
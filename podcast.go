@@ -0,0 +1,348 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/xml"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/araddon/dateparse"
+	"go.goblog.app/app/pkgs/bufferpool"
+	"go.goblog.app/app/pkgs/contenttype"
+	"go.goblog.app/app/pkgs/mp3merge"
+)
+
+const (
+	podcastTranscriptParam = "transcript"
+	podcastChaptersParam   = "chapters"
+	podcastFeedLimit       = 1000
+)
+
+type podcastRss struct {
+	XMLName   xml.Name        `xml:"rss"`
+	Version   string          `xml:"version,attr"`
+	NSItunes  string          `xml:"xmlns:itunes,attr"`
+	NSPodcast string          `xml:"xmlns:podcast,attr"`
+	NSPsc     string          `xml:"xmlns:psc,attr"`
+	NSContent string          `xml:"xmlns:content,attr"`
+	Channel   *podcastChannel `xml:"channel"`
+}
+
+type podcastChannel struct {
+	Title         string           `xml:"title"`
+	Link          string           `xml:"link"`
+	Description   string           `xml:"description"`
+	Language      string           `xml:"language,omitempty"`
+	LastBuildDate string           `xml:"lastBuildDate"`
+	Author        string           `xml:"itunes:author,omitempty"`
+	Owner         *podcastOwner    `xml:"itunes:owner,omitempty"`
+	Image         *podcastImage    `xml:"itunes:image,omitempty"`
+	Category      *podcastCategory `xml:"itunes:category,omitempty"`
+	Explicit      bool             `xml:"itunes:explicit"`
+	Items         []*podcastItem   `xml:"item"`
+}
+
+type podcastOwner struct {
+	Name  string `xml:"itunes:name,omitempty"`
+	Email string `xml:"itunes:email,omitempty"`
+}
+
+type podcastImage struct {
+	Href string `xml:"href,attr"`
+}
+
+type podcastCategory struct {
+	Text string `xml:"text,attr"`
+}
+
+type podcastItem struct {
+	Title       string               `xml:"title"`
+	Link        string               `xml:"link"`
+	GUID        string               `xml:"guid"`
+	PubDate     string               `xml:"pubDate,omitempty"`
+	Description string               `xml:"description"`
+	Content     *podcastCData        `xml:"content:encoded,omitempty"`
+	Enclosure   *podcastEnclosure    `xml:"enclosure"`
+	Duration    int                  `xml:"itunes:duration,omitempty"`
+	Image       *podcastImage        `xml:"itunes:image,omitempty"`
+	Transcripts []*podcastTranscript `xml:"podcast:transcript"`
+	Chapters    *podcastChapters     `xml:"podcast:chapters,omitempty"`
+	PscChapters *pscChapters         `xml:"psc:chapters,omitempty"`
+}
+
+type podcastCData struct {
+	Text string `xml:",cdata"`
+}
+
+type podcastEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type podcastTranscript struct {
+	URL  string `xml:"url,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type podcastChapters struct {
+	URL  string `xml:"url,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type pscChapters struct {
+	Version  string        `xml:"version,attr"`
+	Chapters []*pscChapter `xml:"psc:chapter"`
+}
+
+type pscChapter struct {
+	Start string `xml:"start,attr"`
+	Title string `xml:"title,attr"`
+}
+
+// Audio file of the post, prefers the audio parameter over TTS audio
+func (a *goBlog) podcastAudio(p *post) (audio string, tts bool) {
+	if mp := a.cfg.Micropub; mp != nil {
+		if audio = p.firstParameter(mp.AudioParam); audio != "" {
+			return audio, false
+		}
+	}
+	if audio = p.firstParameter(ttsParameter); audio != "" {
+		return audio, true
+	}
+	return "", false
+}
+
+// Copy of the posts request of an index, limited to posts with audio
+func (a *goBlog) podcastPostsConfig(prc *postsRequestConfig, limit int) *postsRequestConfig {
+	c := *prc
+	if c.parameter != "" && c.parameterValue == "" {
+		// The parameters filter is ignored when a single parameter is set
+		c.requiredParameters = append(append([]string{}, c.requiredParameters...), c.parameter)
+		c.parameter = ""
+	}
+	c.parameters = []string{ttsParameter}
+	if mp := a.cfg.Micropub; mp != nil && mp.AudioParam != "" {
+		c.parameters = append(c.parameters, mp.AudioParam)
+	}
+	c.limit, c.offset = limit, 0
+	return &c
+}
+
+func (a *goBlog) generatePodcastFeed(blog string, w http.ResponseWriter, r *http.Request, posts []*post, title, description string) {
+	bc := a.cfg.Blogs[blog]
+	channel := &podcastChannel{
+		Title:         a.renderMdTitle(defaultIfEmpty(title, bc.Title)),
+		Link:          a.getFullAddress(strings.TrimSuffix(r.URL.Path, "."+string(podcastFeed))),
+		Description:   defaultIfEmpty(description, bc.Description),
+		Language:      bc.Lang,
+		LastBuildDate: time.Now().Format(time.RFC1123Z),
+		Author:        a.cfg.User.Name,
+		Owner:         &podcastOwner{Name: a.cfg.User.Name, Email: a.cfg.User.Email},
+	}
+	image := a.cfg.User.Picture
+	if pc := bc.Podcast; pc != nil {
+		image = defaultIfEmpty(pc.Image, image)
+		if pc.Category != "" {
+			channel.Category = &podcastCategory{Text: pc.Category}
+		}
+		channel.Explicit = pc.Explicit
+	}
+	if image != "" {
+		channel.Image = &podcastImage{Href: a.getFullAddress(image)}
+	}
+	for _, p := range posts {
+		if item := a.podcastItem(p); item != nil {
+			channel.Items = append(channel.Items, item)
+		}
+	}
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+	buf.WriteString(xml.Header)
+	err := xml.NewEncoder(buf).Encode(&podcastRss{
+		Version:   "2.0",
+		NSItunes:  "http://www.itunes.com/dtds/podcast-1.0.dtd",
+		NSPodcast: "https://podcastindex.org/namespace/1.0",
+		NSPsc:     "http://podlove.org/simple-chapters",
+		NSContent: "http://purl.org/rss/1.0/modules/content/",
+		Channel:   channel,
+	})
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(contentType, contenttype.RSS+contenttype.CharsetUtf8Suffix)
+	_ = a.min.Get().Minify(contenttype.RSS, w, buf)
+}
+
+func (a *goBlog) podcastItem(p *post) *podcastItem {
+	audio, tts := a.podcastAudio(p)
+	if audio == "" {
+		return nil
+	}
+	audio = a.getFullAddress(audio)
+	postURL := a.fullPostURL(p)
+	summary := a.postSummary(p)
+	content := bufferpool.Get()
+	defer bufferpool.Put(content)
+	a.feedHtml(content, p)
+	item := &podcastItem{
+		Title:       p.RenderedTitle,
+		Link:        postURL,
+		GUID:        postURL,
+		Description: summary,
+		Content:     &podcastCData{Text: content.String()},
+		Enclosure: &podcastEnclosure{
+			URL:  audio,
+			Type: defaultIfEmpty(mime.TypeByExtension(urlExt(audio)), "audio/mpeg"),
+		},
+	}
+	if item.Title == "" {
+		// Episodes need a title
+		item.Title = summary
+		if runes := []rune(summary); len(runes) > 100 {
+			item.Title = string(runes[:99]) + "…"
+		}
+	}
+	if published := timeNoErr(dateparse.ParseLocal(p.Published)); !published.IsZero() {
+		item.PubDate = published.Format(time.RFC1123Z)
+	}
+	if size, duration := a.mediaAudioInfo(audio); size > 0 {
+		item.Enclosure.Length = size
+		item.Duration = int(duration.Round(time.Second).Seconds())
+	}
+	// Episode artwork
+	if mp := a.cfg.Micropub; mp != nil {
+		if image := p.firstParameter(mp.PhotoParam); image != "" {
+			item.Image = &podcastImage{Href: a.getFullAddress(image)}
+		}
+	}
+	// Transcripts
+	for _, transcript := range p.Parameters[podcastTranscriptParam] {
+		if transcript = strings.TrimSpace(transcript); transcript != "" {
+			transcript = a.getFullAddress(transcript)
+			item.Transcripts = append(item.Transcripts, &podcastTranscript{URL: transcript, Type: podcastTranscriptType(transcript)})
+		}
+	}
+	if len(item.Transcripts) == 0 && tts {
+		// The post is the transcript of the TTS audio
+		item.Transcripts = append(item.Transcripts, &podcastTranscript{URL: postURL, Type: contenttype.HTML})
+	}
+	// Chapters, a JSON file or lines with start time and title
+	for _, chapter := range p.Parameters[podcastChaptersParam] {
+		for _, line := range strings.Split(chapter, "\n") {
+			line = strings.TrimSpace(line)
+			if isAbsoluteURL(line) || strings.HasPrefix(line, "/") {
+				item.Chapters = &podcastChapters{URL: a.getFullAddress(line), Type: "application/json+chapters"}
+				continue
+			}
+			if start, title, ok := strings.Cut(line, " "); ok && isPodcastChapterTime(start) {
+				if item.PscChapters == nil {
+					item.PscChapters = &pscChapters{Version: "1.2"}
+				}
+				item.PscChapters.Chapters = append(item.PscChapters.Chapters, &pscChapter{Start: start, Title: strings.TrimSpace(title)})
+			}
+		}
+	}
+	return item
+}
+
+func urlExt(u string) string {
+	if parsed, err := url.Parse(u); err == nil {
+		return path.Ext(parsed.Path)
+	}
+	return path.Ext(u)
+}
+
+func podcastTranscriptType(transcript string) string {
+	switch strings.ToLower(urlExt(transcript)) {
+	case ".vtt":
+		return "text/vtt"
+	case ".srt":
+		return "application/srt"
+	case ".json":
+		return "application/json"
+	case ".html", ".htm":
+		return contenttype.HTML
+	}
+	return "text/plain"
+}
+
+// Normal play time like 01:02:03, 02:03 or 02:03.500
+func isPodcastChapterTime(s string) bool {
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return false
+	}
+	for i, part := range parts {
+		if i == len(parts)-1 {
+			part, _, _ = strings.Cut(part, ".")
+		}
+		if _, err := strconv.Atoi(part); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Size and duration of an audio file from the media storage, cached in the database.
+// Usually saved on upload, older files are read once and failures are cached too.
+func (a *goBlog) mediaAudioInfo(location string) (size int64, duration time.Duration) {
+	row, err := a.db.queryRow("select size, duration from media_audio where location = @location", sql.Named("location", location))
+	if err == nil {
+		var durationMs int64
+		if err = row.Scan(&size, &durationMs); err == nil {
+			if size < 0 {
+				// Failed before
+				return 0, 0
+			}
+			return size, time.Duration(durationMs) * time.Millisecond
+		}
+	}
+	// Only files from the media storage
+	fileName := path.Base(urlPath(location))
+	if !a.mediaStorageEnabled() || a.getFullAddress(a.mediaFileLocation(fileName)) != location {
+		return 0, 0
+	}
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+	if err = a.readMediaFile(a.mediaStorage, fileName, buf); err != nil {
+		_ = a.db.saveMediaAudioInfo(location, -1, 0)
+		return 0, 0
+	}
+	return a.saveMediaAudioInfo(location, buf.Bytes())
+}
+
+// Compute and save size and duration of an audio file
+func (a *goBlog) saveMediaAudioInfo(location string, data []byte) (size int64, duration time.Duration) {
+	size = int64(len(data))
+	if strings.EqualFold(path.Ext(urlPath(location)), ".mp3") {
+		duration, _ = mp3merge.Duration(bytes.NewReader(data))
+	}
+	if err := a.db.saveMediaAudioInfo(location, size, duration); err != nil {
+		log.Println("Failed to save audio info:", err.Error())
+	}
+	return size, duration
+}
+
+func (db *database) saveMediaAudioInfo(location string, size int64, duration time.Duration) error {
+	_, err := db.exec(
+		"insert or replace into media_audio (location, size, duration) values (@location, @size, @duration)",
+		sql.Named("location", location), sql.Named("size", size), sql.Named("duration", duration.Milliseconds()),
+	)
+	return err
+}
+
+func urlPath(u string) string {
+	if parsed, err := url.Parse(u); err == nil {
+		return parsed.Path
+	}
+	return u
+}
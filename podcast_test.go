@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/requests"
+	"github.com/mmcdole/gofeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_podcastFeed(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	mediaDir := t.TempDir()
+	app.mediaStorageInit.Do(func() {
+		app.mediaStorage = &localMediaStorage{path: mediaDir}
+	})
+	_ = app.initConfig()
+	app.cfg.Blogs["default"].Podcast = &configPodcast{
+		Category: "Technology",
+		Explicit: true,
+	}
+	_ = app.initDatabase(false)
+	defer app.db.close()
+	app.initComponents(false)
+	app.d = app.buildRouter()
+	handlerClient := newHandlerClient(app.d)
+
+	// 38 frames with 1152 samples at 44.1 kHz are about one second
+	audio := testMP3Frames(38)
+	require.NoError(t, os.WriteFile(filepath.Join(mediaDir, "episode.mp3"), audio, 0644))
+
+	require.NoError(t, app.createPost(&post{
+		Path:      "/episode",
+		Section:   "posts",
+		Status:    statusPublished,
+		Published: "2020-01-01T00:00:00Z",
+		Parameters: map[string][]string{
+			"title":                {"Episode 1"},
+			"audio":                {"/m/episode.mp3"},
+			"images":               {"https://example.com/cover.jpg"},
+			podcastTranscriptParam: {"https://example.com/episode.vtt"},
+			podcastChaptersParam:   {"00:00:00 Intro\n00:00:30 Main topic"},
+		},
+		Content: "Episode content",
+	}))
+	require.NoError(t, app.createPost(&post{
+		Path:      "/noaudio",
+		Section:   "posts",
+		Status:    statusPublished,
+		Published: "2020-01-02T00:00:00Z",
+		Content:   "No audio",
+	}))
+
+	var raw string
+	err := requests.URL("http://localhost:8080/posts.podcast").Client(handlerClient).ToString(&raw).Fetch(context.Background())
+	require.NoError(t, err)
+
+	feed, err := gofeed.NewParser().ParseString(raw)
+	require.NoError(t, err)
+	if assert.NotNil(t, feed.ITunesExt) {
+		assert.Equal(t, "true", feed.ITunesExt.Explicit)
+		if assert.Len(t, feed.ITunesExt.Categories, 1) {
+			assert.Equal(t, "Technology", feed.ITunesExt.Categories[0].Text)
+		}
+	}
+
+	require.Len(t, feed.Items, 1)
+	item := feed.Items[0]
+	assert.Equal(t, "Episode 1", item.Title)
+	if assert.Len(t, item.Enclosures, 1) {
+		assert.Equal(t, "http://localhost:8080/m/episode.mp3", item.Enclosures[0].URL)
+		assert.Equal(t, "audio/mpeg", item.Enclosures[0].Type)
+		assert.Equal(t, "15846", item.Enclosures[0].Length)
+	}
+	if assert.NotNil(t, item.ITunesExt) {
+		assert.Equal(t, "1", item.ITunesExt.Duration)
+		assert.Equal(t, "https://example.com/cover.jpg", item.ITunesExt.Image)
+	}
+	assert.Contains(t, raw, `<podcast:transcript url="https://example.com/episode.vtt" type="text/vtt"`)
+	assert.Contains(t, raw, `<psc:chapter start="00:00:30" title="Main topic"`)
+
+	// Size and duration are cached
+	require.NoError(t, os.Remove(filepath.Join(mediaDir, "episode.mp3")))
+	size, duration := app.mediaAudioInfo("http://localhost:8080/m/episode.mp3")
+	assert.Equal(t, int64(len(audio)), size)
+	assert.Equal(t, 1, int(duration.Round(time.Second).Seconds()))
+
+	// Failures are cached
+	size, _ = app.mediaAudioInfo("http://localhost:8080/m/missing.mp3")
+	assert.Equal(t, int64(0), size)
+	require.NoError(t, os.WriteFile(filepath.Join(mediaDir, "missing.mp3"), audio, 0644))
+	size, _ = app.mediaAudioInfo("http://localhost:8080/m/missing.mp3")
+	assert.Equal(t, int64(0), size)
+
+	// Saved on upload
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	fw, err := mw.CreateFormFile("file", "upload.mp3")
+	require.NoError(t, err)
+	_, _ = fw.Write(testMP3Frames(76))
+	require.NoError(t, mw.Close())
+	req := httptest.NewRequest(http.MethodPost, "http://localhost:8080/micropub/media", body)
+	req.Header.Set(contentType, mw.FormDataContentType())
+	req = req.WithContext(context.WithValue(req.Context(), indieAuthScope, "media"))
+	rec := httptest.NewRecorder()
+	app.serveMicropubMedia(rec, req)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	location := rec.Header().Get("Location")
+	require.NoError(t, os.Remove(filepath.Join(mediaDir, path.Base(location))))
+	size, duration = app.mediaAudioInfo(location)
+	assert.Equal(t, int64(len(testMP3Frames(76))), size)
+	assert.Equal(t, 2, int(duration.Round(time.Second).Seconds()))
+
+	// Discovery link on index page
+	var index string
+	err = requests.URL("http://localhost:8080/posts").Client(handlerClient).ToString(&index).Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, index, "http://localhost:8080/posts.podcast")
+}
+
+func Test_podcastFeedAllEpisodes(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	_ = app.initConfig()
+	app.cfg.Blogs["default"].Pagination = 2
+	_ = app.initDatabase(false)
+	defer app.db.close()
+	app.initComponents(false)
+	app.d = app.buildRouter()
+	handlerClient := newHandlerClient(app.d)
+
+	// Older posts with audio aren't on the first page
+	for i := 1; i <= 5; i++ {
+		p := &post{
+			Path:      fmt.Sprintf("/%d", i),
+			Section:   "posts",
+			Status:    statusPublished,
+			Published: fmt.Sprintf("2020-01-0%dT00:00:00Z", i),
+			Content:   "Post",
+		}
+		if i <= 2 {
+			p.Parameters = map[string][]string{"audio": {fmt.Sprintf("https://example.com/%d.mp3", i)}}
+		}
+		require.NoError(t, app.createPost(p))
+	}
+
+	var raw string
+	err := requests.URL("http://localhost:8080/posts.podcast").Client(handlerClient).ToString(&raw).Fetch(context.Background())
+	require.NoError(t, err)
+	feed, err := gofeed.NewParser().ParseString(raw)
+	require.NoError(t, err)
+	if assert.Len(t, feed.Items, 2) {
+		assert.Equal(t, "http://localhost:8080/2", feed.Items[0].Link)
+		assert.Equal(t, "http://localhost:8080/1", feed.Items[1].Link)
+	}
+
+	// Discovery link although the page has no audio posts
+	var index string
+	err = requests.URL("http://localhost:8080/posts").Client(handlerClient).ToString(&index).Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, index, "http://localhost:8080/posts.podcast")
+}
+
+func Test_isPodcastChapterTime(t *testing.T) {
+	assert.True(t, isPodcastChapterTime("01:02:03"))
+	assert.True(t, isPodcastChapterTime("02:03"))
+	assert.True(t, isPodcastChapterTime("02:03.500"))
+	assert.False(t, isPodcastChapterTime("Intro"))
+	assert.False(t, isPodcastChapterTime("12"))
+}
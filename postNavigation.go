@@ -0,0 +1,66 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+)
+
+// By default, the navigation links the posts of the same section
+const postNavigationScopeBlog = "blog"
+
+func (bc *configBlog) postNavigationEnabled() bool {
+	return bc != nil && bc.PostNavigation != nil && bc.PostNavigation.Enabled
+}
+
+// Get the paths of the previous (older) and next (newer) published post of the same section or blog
+func (a *goBlog) postNavigationPaths(p *post) (prev, next string) {
+	bc := a.cfg.Blogs[p.Blog]
+	if !bc.postNavigationEnabled() || !p.isPublishedSectionPost() {
+		return "", ""
+	}
+	section := p.Section
+	if bc.PostNavigation.Scope == postNavigationScopeBlog {
+		section = ""
+	}
+	prev, _ = a.db.adjacentPostPath(p, section, true)
+	next, _ = a.db.adjacentPostPath(p, section, false)
+	return prev, next
+}
+
+// Get the previous (older) and next (newer) published post from their paths
+func (a *goBlog) postNavigation(prevPath, nextPath string) (prev, next *post) {
+	if prevPath != "" {
+		prev, _ = a.getPost(prevPath)
+	}
+	if nextPath != "" {
+		next, _ = a.getPost(nextPath)
+	}
+	return prev, next
+}
+
+// Posts with the same published time are ordered by path
+func (db *database) adjacentPostPath(p *post, section string, older bool) (string, error) {
+	query := "select path from posts where blog = @blog and status = @status and published != ''"
+	args := []any{
+		sql.Named("blog", p.Blog), sql.Named("status", statusPublished),
+		sql.Named("published", toUTCSafe(p.Published)), sql.Named("path", p.Path),
+	}
+	if section != "" {
+		query += " and section = @section"
+		args = append(args, sql.Named("section", section))
+	}
+	if older {
+		query += " and (published < @published or (published = @published and path < @path)) order by published desc, path desc limit 1"
+	} else {
+		query += " and (published > @published or (published = @published and path > @path)) order by published asc, path asc limit 1"
+	}
+	row, err := db.queryRow(query, args...)
+	if err != nil {
+		return "", err
+	}
+	var path string
+	if err = row.Scan(&path); errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return path, err
+}
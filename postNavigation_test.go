@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/carlmjohnson/requests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_postNavigation(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	_ = app.initConfig()
+	bc := app.cfg.Blogs["default"]
+	bc.Sections["micro"] = &configSection{Name: "micro", Title: "Micro"}
+	bc.PostNavigation = &configPostNavigation{Enabled: true}
+	_ = app.initDatabase(false)
+	defer app.db.close()
+	app.initComponents(false)
+	app.d = app.buildRouter()
+	handlerClient := newHandlerClient(app.d)
+
+	for _, p := range []*post{
+		{Path: "/1", Section: "posts", Status: statusPublished, Published: "2021-01-01T00:00:00Z", Parameters: map[string][]string{"title": {"First"}}},
+		{Path: "/2", Section: "micro", Status: statusPublished, Published: "2021-02-01T00:00:00Z"},
+		{Path: "/3", Section: "posts", Status: statusDraft, Published: "2021-03-01T00:00:00Z"},
+		{Path: "/4", Section: "posts", Status: statusPublished, Published: "2021-04-01T00:00:00Z", Parameters: map[string][]string{"title": {"Fourth"}}},
+	} {
+		p.Content = "Test"
+		require.NoError(t, app.createPost(p))
+	}
+
+	getPaths := func(path string) (string, string) {
+		p, err := app.getPost(path)
+		require.NoError(t, err)
+		return app.postNavigationPaths(p)
+	}
+
+	// Same section, drafts are skipped
+	prev, next := getPaths("/1")
+	assert.Equal(t, "", prev)
+	assert.Equal(t, "/4", next)
+	prev, next = getPaths("/4")
+	assert.Equal(t, "/1", prev)
+	assert.Equal(t, "", next)
+	prev, next = getPaths("/3")
+	assert.Equal(t, "", prev)
+	assert.Equal(t, "", next)
+
+	// Whole blog
+	bc.PostNavigation.Scope = postNavigationScopeBlog
+	prev, next = getPaths("/4")
+	assert.Equal(t, "/2", prev)
+	assert.Equal(t, "", next)
+	bc.PostNavigation.Scope = ""
+
+	// Links in HTML and headers
+	var html string
+	var header http.Header
+	err := requests.URL("http://localhost:8080/4").Client(handlerClient).
+		Handle(func(r *http.Response) error {
+			defer r.Body.Close()
+			header = r.Header
+			body, err := io.ReadAll(r.Body)
+			html = string(body)
+			return err
+		}).
+		Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, html, `<link rel=prev href=/1>`)
+	assert.Contains(t, html, `<a href=/1 rel=prev>First</a>`)
+	assert.NotContains(t, html, `rel=next`)
+	assert.Contains(t, header.Values("Link"), "<http://localhost:8080/1>; rel=prev")
+
+	// Cached page gets updated when a newer post is published
+	require.NoError(t, app.createPost(&post{
+		Path: "/5", Section: "posts", Status: statusPublished, Published: "2021-05-01T00:00:00Z", Content: "Test",
+		Parameters: map[string][]string{"title": {"Fifth"}},
+	}))
+	err = requests.URL("http://localhost:8080/4").Client(handlerClient).ToString(&html).Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, html, `<a href=/5 rel=next>Fifth</a>`)
+
+	// And when it gets deleted
+	require.NoError(t, app.deletePost("/5"))
+	err = requests.URL("http://localhost:8080/4").Client(handlerClient).ToString(&html).Fetch(context.Background())
+	require.NoError(t, err)
+	assert.NotContains(t, html, `rel=next`)
+}
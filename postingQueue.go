@@ -0,0 +1,243 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/araddon/dateparse"
+)
+
+const (
+	queueParam = "queue"
+	// How far ahead to look for a free slot
+	queueWeeks = 52
+)
+
+// A weekly slot of the posting queue, configured like "mon 09:00"
+type queueSlot struct {
+	weekday      time.Weekday
+	hour, minute int
+}
+
+var queueSlotWeekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+func parseQueueSlot(s string) (*queueSlot, error) {
+	fields := strings.Fields(strings.ToLower(s))
+	if len(fields) != 2 || len(fields[0]) < 3 {
+		return nil, fmt.Errorf("invalid posting queue slot %q", s)
+	}
+	weekday, ok := queueSlotWeekdays[fields[0][:3]]
+	if !ok {
+		return nil, fmt.Errorf("invalid weekday in posting queue slot %q", s)
+	}
+	t, err := time.Parse("15:04", fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid time in posting queue slot %q", s)
+	}
+	return &queueSlot{weekday: weekday, hour: t.Hour(), minute: t.Minute()}, nil
+}
+
+// Slots for posts of the section, the section slots override the blog slots
+func (bc *configBlog) queueSlots(section string) []string {
+	if s, ok := bc.Sections[section]; ok && len(s.QueueSlots) > 0 {
+		return s.QueueSlots
+	}
+	if bc.PostingQueue != nil {
+		return bc.PostingQueue.Slots
+	}
+	return nil
+}
+
+func (bc *configBlog) postingQueueEnabled() bool {
+	if bc.PostingQueue != nil && len(bc.PostingQueue.Slots) > 0 {
+		return true
+	}
+	for _, s := range bc.Sections {
+		if len(s.QueueSlots) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (bc *configBlog) checkQueueSlots() error {
+	slots := []string{}
+	if bc.PostingQueue != nil {
+		slots = append(slots, bc.PostingQueue.Slots...)
+	}
+	for _, s := range bc.Sections {
+		slots = append(slots, s.QueueSlots...)
+	}
+	for _, slot := range slots {
+		if _, err := parseQueueSlot(slot); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sections sharing the same slots share the queue
+func (bc *configBlog) sameQueue(section1, section2 string) bool {
+	return strings.Join(bc.queueSlots(section1), ",") == strings.Join(bc.queueSlots(section2), ",")
+}
+
+// Slot times after the given time for the next weeks, ordered
+func queueSlotTimes(slots []string, after time.Time, weeks int) (times []time.Time) {
+	after = after.In(time.Local)
+	for _, s := range slots {
+		slot, err := parseQueueSlot(s)
+		if err != nil {
+			continue
+		}
+		days := (int(slot.weekday) - int(after.Weekday()) + 7) % 7
+		for w := 0; w <= weeks; w++ {
+			t := time.Date(after.Year(), after.Month(), after.Day()+days+7*w, slot.hour, slot.minute, 0, 0, time.Local)
+			if t.After(after) {
+				times = append(times, t)
+			}
+		}
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	return times
+}
+
+// Queued posts of the blog that share the queue with the section, ordered by their slot
+func (a *goBlog) queuedPosts(blog, section string) ([]*post, error) {
+	posts, err := a.getPosts(&postsRequestConfig{
+		blog:           blog,
+		status:         statusScheduled,
+		parameter:      queueParam,
+		ascendingOrder: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	bc := a.cfg.Blogs[blog]
+	queued := []*post{}
+	for _, p := range posts {
+		if bc.sameQueue(p.Section, section) {
+			queued = append(queued, p)
+		}
+	}
+	return queued, nil
+}
+
+// Next slot of the queue of the section that isn't used by another scheduled post
+func (a *goBlog) nextFreeQueueSlot(blog, section string) (time.Time, error) {
+	bc := a.cfg.Blogs[blog]
+	slots := bc.queueSlots(section)
+	if len(slots) == 0 {
+		return time.Time{}, errors.New("no posting queue slots configured")
+	}
+	scheduled, err := a.getPosts(&postsRequestConfig{
+		blog:                 blog,
+		status:               statusScheduled,
+		withoutParameters:    true,
+		withoutRenderedTitle: true,
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	taken := map[int64]bool{}
+	for _, p := range scheduled {
+		if bc.sameQueue(p.Section, section) {
+			taken[timeNoErr(dateparse.ParseLocal(p.Published)).Unix()] = true
+		}
+	}
+	for _, t := range queueSlotTimes(slots, time.Now(), queueWeeks) {
+		if !taken[t.Unix()] {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("posting queue is full")
+}
+
+// Schedule the post for the next free slot of the posting queue,
+// the caller must hold the post creation lock until the post is saved
+func (a *goBlog) queuePost(p *post) error {
+	if p.Blog == "" {
+		p.Blog = a.cfg.DefaultBlog
+	}
+	bc, ok := a.cfg.Blogs[p.Blog]
+	if !ok {
+		return errors.New("blog doesn't exist")
+	}
+	if p.Section == "" {
+		p.Section = bc.DefaultSection
+	}
+	slot, err := a.nextFreeQueueSlot(p.Blog, p.Section)
+	if err != nil {
+		return err
+	}
+	p.Published = slot.Format(time.RFC3339)
+	p.Status = statusScheduled
+	if p.Parameters == nil {
+		p.Parameters = map[string][]string{}
+	}
+	p.Parameters[queueParam] = []string{"true"}
+	return nil
+}
+
+// Swap the slot of the queued post with the previous or next queued post
+func (a *goBlog) moveQueuedPost(blog, path string, up bool) error {
+	p, err := a.getPost(path)
+	if err != nil {
+		return err
+	}
+	if p.Blog != blog {
+		return errPostNotFound
+	}
+	queued, err := a.queuedPosts(blog, p.Section)
+	if err != nil {
+		return err
+	}
+	i := -1
+	for j, q := range queued {
+		if q.Path == p.Path {
+			i = j
+		}
+	}
+	if i == -1 {
+		return errors.New("post isn't queued")
+	}
+	j := i + 1
+	if up {
+		j = i - 1
+	}
+	if j < 0 || j >= len(queued) {
+		// Already first or last
+		return nil
+	}
+	if err = a.db.swapPostsPublished(queued[i].Path, queued[j].Path); err != nil {
+		return err
+	}
+	a.cache.purge()
+	return nil
+}
+
+// Swap the published dates of two posts in a single transaction
+func (db *database) swapPostsPublished(path1, path2 string) error {
+	// Lock post creation
+	db.pcm.Lock()
+	defer db.pcm.Unlock()
+	var published1, published2 string
+	row, err := db.queryRow("select (select published from posts where path = @path1), (select published from posts where path = @path2)", sql.Named("path1", path1), sql.Named("path2", path2))
+	if err != nil {
+		return err
+	}
+	if err = row.Scan(&published1, &published2); err != nil {
+		return err
+	}
+	_, err = db.exec(
+		"begin; update posts set published = ? where path = ?; update posts set published = ? where path = ?; commit;",
+		dbNoCache, published2, path1, published1, path2,
+	)
+	return err
+}
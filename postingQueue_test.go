@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/araddon/dateparse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.goblog.app/app/pkgs/contenttype"
+)
+
+func Test_parseQueueSlot(t *testing.T) {
+	slot, err := parseQueueSlot("Mon 09:30")
+	require.NoError(t, err)
+	assert.Equal(t, &queueSlot{weekday: time.Monday, hour: 9, minute: 30}, slot)
+	slot, err = parseQueueSlot("friday 18:00")
+	require.NoError(t, err)
+	assert.Equal(t, time.Friday, slot.weekday)
+	for _, invalid := range []string{"", "mon", "xyz 09:00", "mon 25:00", "mon 9"} {
+		_, err = parseQueueSlot(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func Test_queueSlotTimes(t *testing.T) {
+	// Wednesday
+	after := time.Date(2022, 6, 15, 10, 0, 0, 0, time.Local)
+	times := queueSlotTimes([]string{"fri 09:00", "mon 09:00", "wed 09:00"}, after, 1)
+	require.Len(t, times, 5)
+	assert.Equal(t, time.Date(2022, 6, 17, 9, 0, 0, 0, time.Local), times[0])
+	assert.Equal(t, time.Date(2022, 6, 20, 9, 0, 0, 0, time.Local), times[1])
+	assert.Equal(t, time.Date(2022, 6, 22, 9, 0, 0, 0, time.Local), times[2])
+	assert.Equal(t, time.Date(2022, 6, 24, 9, 0, 0, 0, time.Local), times[3])
+	assert.Equal(t, time.Date(2022, 6, 27, 9, 0, 0, 0, time.Local), times[4])
+}
+
+func Test_postingQueue(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	_ = app.initConfig()
+	bc := app.cfg.Blogs["default"]
+	bc.PostingQueue = &configPostingQueue{Slots: []string{"mon 09:00", "wed 09:00", "fri 09:00"}}
+	_ = app.initDatabase(false)
+	defer app.db.close()
+	app.initComponents(false)
+
+	assert.True(t, bc.postingQueueEnabled())
+	slots := queueSlotTimes(bc.queueSlots("posts"), time.Now(), 1)
+
+	// A manually scheduled post uses the second slot
+	require.NoError(t, app.createPost(&post{Path: "/manual", Section: "posts", Content: "Manual", Status: statusScheduled, Published: slots[1].Format(time.RFC3339)}))
+
+	// Queue new posts
+	for _, path := range []string{"/q1", "/q2"} {
+		require.NoError(t, app.createPost(&post{Path: path, Section: "posts", Content: "Test", Status: statusDraft, Parameters: map[string][]string{queueParam: {"true"}}}))
+	}
+	getPost := func(path string) *post {
+		p, err := app.getPost(path)
+		require.NoError(t, err)
+		return p
+	}
+	published := func(path string) time.Time {
+		return timeNoErr(dateparse.ParseLocal(getPost(path).Published))
+	}
+	assert.Equal(t, statusScheduled, getPost("/q1").Status)
+	assert.True(t, slots[0].Equal(published("/q1")))
+	assert.True(t, slots[2].Equal(published("/q2")))
+
+	// Queue a draft from the post manager
+	require.NoError(t, app.createPost(&post{Path: "/q3", Section: "posts", Content: "Test", Status: statusDraft}))
+	require.NoError(t, app.bulkEditPosts("default", []string{"/q3"}, &bulkPostAction{action: "queue"}))
+	assert.True(t, slots[3].Equal(published("/q3")))
+	assert.Equal(t, "true", getPost("/q3").firstParameter(queueParam))
+	assert.Error(t, app.bulkEditPosts("default", []string{"/manual"}, &bulkPostAction{action: "queue"}))
+
+	// Editor
+	editorRequest := func(method, target string, values url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(values.Encode()))
+		req.Header.Set(contentType, contenttype.WWWForm)
+		req = req.WithContext(context.WithValue(req.Context(), blogKey, "default"))
+		rec := httptest.NewRecorder()
+		if method == http.MethodPost {
+			app.serveEditorQueueMove(rec, req)
+		} else {
+			app.serveEditorQueue(rec, req)
+		}
+		return rec
+	}
+	rec := editorRequest(http.MethodGet, "/editor/queue", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	assert.True(t, strings.Index(body, "value=/q1") < strings.Index(body, "value=/q2"))
+	assert.NotContains(t, body, "value=/manual")
+
+	// Reorder
+	rec = editorRequest(http.MethodPost, "/editor/queue/move", url.Values{"path": {"/q2"}, "direction": {"up"}})
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.True(t, slots[0].Equal(published("/q2")))
+	assert.True(t, slots[2].Equal(published("/q1")))
+	editorRequest(http.MethodPost, "/editor/queue/move", url.Values{"path": {"/q3"}, "direction": {"down"}})
+	assert.True(t, slots[3].Equal(published("/q3")))
+	rec = editorRequest(http.MethodPost, "/editor/queue/move", url.Values{"path": {"/manual"}, "direction": {"up"}})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Publishing removes the post from the queue
+	p := getPost("/q2")
+	p.Published = time.Now().Add(-time.Minute).Format(time.RFC3339)
+	require.NoError(t, app.replacePost(p, p.Path, statusScheduled))
+	app.checkScheduledPosts()
+	assert.Equal(t, statusPublished, getPost("/q2").Status)
+	assert.Empty(t, getPost("/q2").firstParameter(queueParam))
+
+	// Concurrently queued posts get different slots
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, app.createPost(&post{Path: fmt.Sprintf("/c%d", i), Section: "posts", Content: "Test", Status: statusDraft, Parameters: map[string][]string{queueParam: {"true"}}}))
+		}(i)
+	}
+	wg.Wait()
+	taken := map[int64]bool{}
+	for i := 0; i < 5; i++ {
+		slot := published(fmt.Sprintf("/c%d", i)).Unix()
+		assert.False(t, taken[slot])
+		taken[slot] = true
+	}
+
+	// Invalid slots
+	bc.PostingQueue.Slots = []string{"someday"}
+	assert.Error(t, bc.checkQueueSlots())
+}
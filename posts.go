@@ -70,11 +70,18 @@ func (a *goBlog) servePost(w http.ResponseWriter, r *http.Request) {
 	if canonical == "" {
 		canonical = a.fullPostURL(p)
 	}
-	renderMethod := a.renderPost
+	w.Header().Add("Link", fmt.Sprintf("<%s>; rel=shortlink", a.shortPostURL(p)))
+	prev, next := a.postNavigationPaths(p)
+	renderMethod, data := a.renderPost, any(&postRenderData{post: p, prevPath: prev, nextPath: next})
 	if p.Path == a.getRelativePath(p.Blog, "") {
-		renderMethod = a.renderStaticHome
+		renderMethod, data = a.renderStaticHome, p
+	}
+	if prev != "" {
+		w.Header().Add("Link", fmt.Sprintf("<%s>; rel=prev", a.getFullAddress(prev)))
+	}
+	if next != "" {
+		w.Header().Add("Link", fmt.Sprintf("<%s>; rel=next", a.getFullAddress(next)))
 	}
-	w.Header().Add("Link", fmt.Sprintf("<%s>; rel=shortlink", a.shortPostURL(p)))
 	status := http.StatusOK
 	if strings.HasSuffix(string(p.Status), statusDeletedSuffix) {
 		status = http.StatusGone
@@ -82,7 +89,7 @@ func (a *goBlog) servePost(w http.ResponseWriter, r *http.Request) {
 	a.renderWithStatusCode(w, r, status, renderMethod, &renderData{
 		BlogString: p.Blog,
 		Canonical:  canonical,
-		Data:       p,
+		Data:       data,
 	})
 }
 
@@ -248,7 +255,12 @@ type indexConfig struct {
 	section          *configSection
 	tax              *configTaxonomy
 	taxValue         string
+	taxTerm          *taxonomyTerm   // Metadata of the taxonomy value
+	taxChildren      []*taxonomyTerm // Child terms of the taxonomy value
+	taxDescendants   []string        // Posts with these values also belong to the taxonomy value
 	parameter        string
+	custom           *configCustomIndex
+	ascending        bool // Oldest posts first
 	year, month, day int
 	title            string
 	description      string
@@ -284,39 +296,66 @@ func (a *goBlog) serveIndex(w http.ResponseWriter, r *http.Request) {
 	if len(statusse) == 0 {
 		statusse = []postStatus{statusPublished}
 	}
-	p := paginator.New(&postPaginationAdapter{config: &postsRequestConfig{
-		blog:           blog,
-		sections:       sections,
-		taxonomy:       ic.tax,
-		taxonomyValue:  ic.taxValue,
-		parameter:      ic.parameter,
-		search:         search,
-		publishedYear:  ic.year,
-		publishedMonth: ic.month,
-		publishedDay:   ic.day,
-		statusse:       statusse,
-		priorityOrder:  true,
-	}, a: a}, bc.Pagination)
-	p.SetPage(stringToInt(chi.URLParam(r, "page")))
-	var posts []*post
-	err := p.Results(&posts)
-	if err != nil {
-		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
-		return
+	ft := feedType(chi.URLParam(r, "feed"))
+	// Feeds always have the newest posts first
+	ascending := ic.ascending && ft == noFeed
+	prc := &postsRequestConfig{
+		blog:                blog,
+		sections:            sections,
+		taxonomy:            ic.tax,
+		taxonomyValue:       ic.taxValue,
+		taxonomyChildValues: ic.taxDescendants,
+		parameter:           ic.parameter,
+		search:              search,
+		publishedYear:       ic.year,
+		publishedMonth:      ic.month,
+		publishedDay:        ic.day,
+		statusse:            statusse,
+		priorityOrder:       !ascending,
+		ascendingOrder:      ascending,
+	}
+	if ic.custom != nil {
+		applyCustomIndex(prc, ic.custom)
+	}
+	var filters *searchFilters
+	if search != "" {
+		filters = getSearchFilters(bc, r.URL.Query())
+		filters.apply(prc)
 	}
 	// Meta
 	title := ic.title
 	description := ic.description
 	if ic.tax != nil {
 		title = fmt.Sprintf("%s: %s", ic.tax.Title, ic.taxValue)
+		if ic.taxTerm != nil && ic.taxTerm.Description != "" {
+			description = ic.taxTerm.Description
+		}
 	} else if ic.section != nil {
 		title = ic.section.Title
 		description = ic.section.Description
 	} else if search != "" {
 		title = fmt.Sprintf("%s: %s", bc.Search.Title, search)
 	}
+	if ft == podcastFeed {
+		// All episodes instead of the posts of the current page
+		posts, err := a.getPosts(a.podcastPostsConfig(prc, podcastFeedLimit))
+		if err != nil {
+			a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		a.generateFeed(blog, ft, w, r, posts, title, description)
+		return
+	}
+	p := paginator.New(&postPaginationAdapter{config: prc, a: a}, bc.Pagination)
+	p.SetPage(stringToInt(chi.URLParam(r, "page")))
+	var posts []*post
+	err := p.Results(&posts)
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
 	// Check if feed
-	if ft := feedType(chi.URLParam(r, "feed")); ft != noFeed {
+	if ft != noFeed {
 		a.generateFeed(blog, ft, w, r, posts, title, description)
 		return
 	}
@@ -325,6 +364,17 @@ func (a *goBlog) serveIndex(w http.ResponseWriter, r *http.Request) {
 	if strings.Contains(path, searchPlaceholder) {
 		path = strings.ReplaceAll(path, searchPlaceholder, searchEncode(search))
 	}
+	// Search facets and snippets
+	var query string
+	var searchData *searchRenderData
+	if filters != nil {
+		query = encodeQuery(filters.values())
+		searchData, err = a.getSearchRenderData(prc, bc, path, filters, posts, stringToInt(chi.URLParam(r, "page")) <= 1)
+		if err != nil {
+			a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
 	// Navigation
 	var hasPrev, hasNext bool
 	var prevPage, nextPage int
@@ -336,9 +386,9 @@ func (a *goBlog) serveIndex(w http.ResponseWriter, r *http.Request) {
 		prevPage, _ = p.Page()
 	}
 	if prevPage < 2 {
-		prevPath = path
+		prevPath = path + query
 	} else {
-		prevPath = fmt.Sprintf("%s/page/%d", strings.TrimSuffix(path, "/"), prevPage)
+		prevPath = fmt.Sprintf("%s/page/%d%s", strings.TrimSuffix(path, "/"), prevPage, query)
 	}
 	hasNext, _ = p.HasNext()
 	if hasNext {
@@ -346,13 +396,16 @@ func (a *goBlog) serveIndex(w http.ResponseWriter, r *http.Request) {
 	} else {
 		nextPage, _ = p.Page()
 	}
-	nextPath = fmt.Sprintf("%s/page/%d", strings.TrimSuffix(path, "/"), nextPage)
+	nextPath = fmt.Sprintf("%s/page/%d%s", strings.TrimSuffix(path, "/"), nextPage, query)
 	summaryTemplate := ic.summaryTemplate
 	if summaryTemplate == "" {
 		summaryTemplate = defaultSummary
 	}
+	// Podcast feed only if there are episodes
+	episodes, _ := a.db.countPosts(a.podcastPostsConfig(prc, 1))
+	a.setWebSubLinkHeaders(w, a.getFullAddress(path))
 	a.render(w, r, a.renderIndex, &renderData{
-		Canonical: a.getFullAddress(path),
+		Canonical: a.getFullAddress(path + query),
 		Data: &indexRenderData{
 			title:           title,
 			description:     description,
@@ -360,9 +413,13 @@ func (a *goBlog) serveIndex(w http.ResponseWriter, r *http.Request) {
 			hasPrev:         hasPrev,
 			hasNext:         hasNext,
 			first:           path,
+			query:           query,
 			prev:            prevPath,
 			next:            nextPath,
 			summaryTemplate: summaryTemplate,
+			search:          searchData,
+			taxonomy:        a.getTaxonomyTermRenderData(bc, ic),
+			podcast:         episodes > 0,
 		},
 	})
 }
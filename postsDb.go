@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 	"text/template"
@@ -14,7 +15,7 @@ import (
 	"go.goblog.app/app/pkgs/bufferpool"
 )
 
-func (a *goBlog) checkPost(p *post) (err error) {
+func (a *goBlog) checkPost(p *post, o *postCreationOptions) (err error) {
 	if p == nil {
 		return errors.New("no post")
 	}
@@ -66,6 +67,22 @@ func (a *goBlog) checkPost(p *post) (err error) {
 			delete(p.Parameters, key)
 		}
 	}
+	// Check expiry, only changed values, so posts with older values stay editable
+	var old *post
+	if !o.new && o.oldPath != "" {
+		old, _ = a.getPost(o.oldPath)
+	}
+	changed := func(param string) bool {
+		return old == nil || old.firstParameter(param) != p.firstParameter(param)
+	}
+	if expires := p.firstParameter(expiresParam); expires != "" && changed(expiresParam) {
+		if _, err := dateparse.ParseLocal(expires); err != nil {
+			return errors.New("invalid expires date")
+		}
+	}
+	if es := p.firstParameter(expiresStatusParam); es != "" && changed(expiresStatusParam) && es != string(statusUnlisted) && es != string(statusPrivate) && es != "deleted" {
+		return errors.New("expiresstatus must be unlisted, private or deleted")
+	}
 	// Check blog
 	if p.Blog == "" {
 		p.Blog = a.cfg.DefaultBlog
@@ -130,15 +147,12 @@ type postCreationOptions struct {
 	new       bool
 	oldPath   string
 	oldStatus postStatus
+	bulk      bool // Skip search index rebuild and cache purge, the caller does it once for all posts
+	queue     bool // Schedule the post for the next free slot of the posting queue
 }
 
 func (a *goBlog) createOrReplacePost(p *post, o *postCreationOptions) error {
-	// Check post
-	if err := a.checkPost(p); err != nil {
-		return err
-	}
-	// Save to db
-	if err := a.db.savePost(p, o); err != nil {
+	if err := a.checkAndSavePost(p, o); err != nil {
 		return err
 	}
 	// Reload post from database
@@ -156,20 +170,46 @@ func (a *goBlog) createOrReplacePost(p *post, o *postCreationOptions) error {
 		}
 	}
 	// Purge cache
-	a.cache.purge()
+	if !o.bulk {
+		a.cache.purge()
+	}
 	a.deleteReactionsCache(p.Path)
 	return nil
 }
 
+// Check and save the post, new posts can be added to the posting queue
+func (a *goBlog) checkAndSavePost(p *post, o *postCreationOptions) error {
+	// Lock post creation, so the queue slot is chosen and saved at once
+	a.db.pcm.Lock()
+	defer a.db.pcm.Unlock()
+	// Add to the posting queue
+	if o.queue || (o.new && p.firstParameter(queueParam) == "true" && (p.Status == statusNil || p.Status == statusDraft || p.Status == statusPublished)) {
+		if err := a.queuePost(p); err != nil {
+			return err
+		}
+	}
+	// Check post
+	if err := a.checkPost(p, o); err != nil {
+		return err
+	}
+	// Save to db
+	return a.db.savePostLocked(p, o)
+}
+
 // Save check post to database
 func (db *database) savePost(p *post, o *postCreationOptions) error {
+	// Lock post creation
+	db.pcm.Lock()
+	defer db.pcm.Unlock()
+	return db.savePostLocked(p, o)
+}
+
+// Save check post to database, the caller must hold the post creation lock
+func (db *database) savePostLocked(p *post, o *postCreationOptions) error {
 	// Check
 	if !o.new && o.oldPath == "" {
 		return errors.New("old path required")
 	}
-	// Lock post creation
-	db.pcm.Lock()
-	defer db.pcm.Unlock()
 	// Build SQL
 	sqlBuilder := bufferpool.Get()
 	defer bufferpool.Put(sqlBuilder)
@@ -206,11 +246,21 @@ func (db *database) savePost(p *post, o *postCreationOptions) error {
 		return err
 	}
 	// Update FTS index
-	db.rebuildFTSIndex()
+	if !o.bulk {
+		db.rebuildFTSIndex()
+	}
 	return nil
 }
 
+type postDeletionOptions struct {
+	bulk bool // Skip search index rebuild and cache purge, the caller does it once for all posts
+}
+
 func (a *goBlog) deletePost(path string) error {
+	return a.deletePostWithOptions(path, &postDeletionOptions{})
+}
+
+func (a *goBlog) deletePostWithOptions(path string, o *postDeletionOptions) error {
 	if path == "" {
 		return errors.New("path required")
 	}
@@ -231,10 +281,12 @@ func (a *goBlog) deletePost(path string) error {
 		); err != nil {
 			return err
 		}
-		// Rebuild FTS index
-		a.db.rebuildFTSIndex()
-		// Purge cache
-		a.cache.purge()
+		if !o.bulk {
+			// Rebuild FTS index
+			a.db.rebuildFTSIndex()
+			// Purge cache
+			a.cache.purge()
+		}
 		a.deleteReactionsCache(p.Path)
 	} else {
 		// Update post status
@@ -252,10 +304,12 @@ func (a *goBlog) deletePost(path string) error {
 		); err != nil {
 			return err
 		}
-		// Rebuild FTS index
-		a.db.rebuildFTSIndex()
-		// Purge cache
-		a.cache.purge()
+		if !o.bulk {
+			// Rebuild FTS index
+			a.db.rebuildFTSIndex()
+			// Purge cache
+			a.cache.purge()
+		}
 		// Trigger hooks
 		a.postDeleteHooks(p)
 	}
@@ -263,6 +317,10 @@ func (a *goBlog) deletePost(path string) error {
 }
 
 func (a *goBlog) undeletePost(path string) error {
+	return a.undeletePostWithOptions(path, &postDeletionOptions{})
+}
+
+func (a *goBlog) undeletePostWithOptions(path string, o *postDeletionOptions) error {
 	if path == "" {
 		return errors.New("path required")
 	}
@@ -285,10 +343,12 @@ func (a *goBlog) undeletePost(path string) error {
 	); err != nil {
 		return err
 	}
-	// Rebuild FTS index
-	a.db.rebuildFTSIndex()
-	// Purge cache
-	a.cache.purge()
+	if !o.bulk {
+		// Rebuild FTS index
+		a.db.rebuildFTSIndex()
+		// Purge cache
+		a.cache.purge()
+	}
 	// Trigger hooks
 	a.postUndeleteHooks(p)
 	return nil
@@ -337,13 +397,21 @@ type postsRequestConfig struct {
 	statusse                                    []postStatus
 	taxonomy                                    *configTaxonomy
 	taxonomyValue                               string
+	taxonomyChildValues                         []string // Posts with these values also match the taxonomy value
 	parameters                                  []string // Ignores parameterValue
 	parameter                                   string   // Ignores parameters
 	parameterValue                              string
+	excludedParameters                          []string // Posts must not have any of these
+	requiredParameters                          []string // Posts must have all of these
+	taxonomyValues                              map[string][]string
+	taxonomyValuesMatchAll                      bool // Posts must have all instead of any of the taxonomy values
+	excludedTaxonomyValues                      map[string][]string
 	publishedYear, publishedMonth, publishedDay int
 	publishedBefore                             time.Time
+	publishedAfter                              time.Time
 	randomOrder                                 bool
 	priorityOrder                               bool
+	ascendingOrder                              bool
 	withoutParameters                           bool
 	withOnlyParameters                          []string
 	withoutRenderedTitle                        bool
@@ -358,7 +426,8 @@ func buildPostsQuery(c *postsRequestConfig, selection string) (query string, arg
 	queryBuilder.WriteString(" from ")
 	// Table
 	if c.search != "" {
-		queryBuilder.WriteString("(select p.* from posts_fts(@search) ps, posts p where ps.path = p.path)")
+		// Rank title matches higher than content matches
+		queryBuilder.WriteString("(select p.*, bm25(posts_fts, 0, 10, 1) as searchrank from posts_fts(@search) ps, posts p where ps.path = p.path)")
 		args = append(args, sql.Named("search", c.search))
 	} else {
 		queryBuilder.WriteString("posts")
@@ -407,13 +476,51 @@ func buildPostsQuery(c *postsRequestConfig, selection string) (query string, arg
 			named := "param" + strconv.Itoa(i)
 			queryBuilder.WriteByte('@')
 			queryBuilder.WriteString(named)
-			args = append(args, param)
+			args = append(args, sql.Named(named, param))
+		}
+		queryBuilder.WriteString(") and length(coalesce(value, '')) > 0)")
+	}
+	if len(c.excludedParameters) > 0 {
+		queryBuilder.WriteString(" and path not in (select path from post_parameters where parameter in (")
+		for i, param := range c.excludedParameters {
+			if i > 0 {
+				queryBuilder.WriteString(", ")
+			}
+			named := "exparam" + strconv.Itoa(i)
+			queryBuilder.WriteByte('@')
+			queryBuilder.WriteString(named)
+			args = append(args, sql.Named(named, param))
 		}
 		queryBuilder.WriteString(") and length(coalesce(value, '')) > 0)")
 	}
+	for i, param := range c.requiredParameters {
+		named := "reqparam" + strconv.Itoa(i)
+		queryBuilder.WriteString(" and path in (select path from post_parameters where parameter = @" + named + " and length(coalesce(value, '')) > 0)")
+		args = append(args, sql.Named(named, param))
+	}
+	if conditions, conditionArgs := taxonomyValuesConditions(c.taxonomyValues, "taxvals"); len(conditions) > 0 {
+		if c.taxonomyValuesMatchAll {
+			for _, condition := range conditions {
+				queryBuilder.WriteString(" and path in (select path from post_parameters where " + condition + ")")
+			}
+		} else {
+			queryBuilder.WriteString(" and path in (select path from post_parameters where " + strings.Join(conditions, " or ") + ")")
+		}
+		args = append(args, conditionArgs...)
+	}
+	if conditions, conditionArgs := taxonomyValuesConditions(c.excludedTaxonomyValues, "extaxvals"); len(conditions) > 0 {
+		queryBuilder.WriteString(" and path not in (select path from post_parameters where " + strings.Join(conditions, " or ") + ")")
+		args = append(args, conditionArgs...)
+	}
 	if c.taxonomy != nil && len(c.taxonomyValue) > 0 {
-		queryBuilder.WriteString(" and path in (select path from post_parameters where parameter = @taxname and lowerx(value) = lowerx(@taxval))")
+		queryBuilder.WriteString(" and path in (select path from post_parameters where parameter = @taxname and (lowerx(value) = lowerx(@taxval)")
 		args = append(args, sql.Named("taxname", c.taxonomy.Name), sql.Named("taxval", c.taxonomyValue))
+		for i, value := range c.taxonomyChildValues {
+			named := "taxchild" + strconv.Itoa(i)
+			queryBuilder.WriteString(" or lowerx(value) = lowerx(@" + named + ")")
+			args = append(args, sql.Named(named, value))
+		}
+		queryBuilder.WriteString("))")
 	}
 	if len(c.sections) > 0 {
 		queryBuilder.WriteString(" and section in (")
@@ -444,12 +551,20 @@ func buildPostsQuery(c *postsRequestConfig, selection string) (query string, arg
 		queryBuilder.WriteString(" and toutc(published) < @publishedbefore")
 		args = append(args, sql.Named("publishedbefore", c.publishedBefore.UTC().Format(time.RFC3339)))
 	}
+	if !c.publishedAfter.IsZero() {
+		queryBuilder.WriteString(" and toutc(published) >= @publishedafter")
+		args = append(args, sql.Named("publishedafter", c.publishedAfter.UTC().Format(time.RFC3339)))
+	}
 	// Order
 	queryBuilder.WriteString(" order by ")
 	if c.randomOrder {
 		queryBuilder.WriteString("random()")
+	} else if c.search != "" {
+		queryBuilder.WriteString("searchrank, published desc")
 	} else if c.priorityOrder {
 		queryBuilder.WriteString("priority desc, published desc")
+	} else if c.ascendingOrder {
+		queryBuilder.WriteString("published asc, path asc")
 	} else {
 		queryBuilder.WriteString("published desc")
 	}
@@ -461,6 +576,23 @@ func buildPostsQuery(c *postsRequestConfig, selection string) (query string, arg
 	return queryBuilder.String(), args
 }
 
+// SQL conditions for post_parameters matching one of the taxonomy values each
+func taxonomyValuesConditions(values map[string][]string, prefix string) (conditions []string, args []any) {
+	taxonomies := make([]string, 0, len(values))
+	for taxonomy := range values {
+		taxonomies = append(taxonomies, taxonomy)
+	}
+	sort.Strings(taxonomies)
+	for _, taxonomy := range taxonomies {
+		for _, value := range values[taxonomy] {
+			named := prefix + strconv.Itoa(len(conditions))
+			conditions = append(conditions, fmt.Sprintf("(parameter = @%[1]st and lowerx(value) = lowerx(@%[1]sv))", named))
+			args = append(args, sql.Named(named+"t", taxonomy), sql.Named(named+"v", value))
+		}
+	}
+	return
+}
+
 func (d *database) loadPostParameters(posts []*post, parameters ...string) (err error) {
 	if len(posts) == 0 {
 		return nil
@@ -104,6 +104,18 @@ func (a *goBlog) postSummary(p *post) (summary string) {
 	return
 }
 
+// Title or shortened summary to use as link text
+func (a *goBlog) postLinkText(p *post) string {
+	if p.RenderedTitle != "" {
+		return p.RenderedTitle
+	}
+	text := a.postSummary(p)
+	if runes := []rune(text); len(runes) > 80 {
+		text = string(runes[:79]) + "…"
+	}
+	return defaultIfEmpty(text, p.Path)
+}
+
 func (a *goBlog) postTranslations(p *post) []*post {
 	translationkey := p.firstParameter("translationkey")
 	if translationkey == "" {
@@ -155,19 +167,21 @@ func (a *goBlog) postToMfItem(p *post) *microformatItem {
 	return &microformatItem{
 		Type: []string{"h-entry"},
 		Properties: &microformatProperties{
-			Name:       p.Parameters["title"],
-			Published:  []string{p.Published},
-			Updated:    []string{p.Updated},
-			PostStatus: []string{mfStatus},
-			Visibility: []string{mfVisibility},
-			Category:   p.Parameters[a.cfg.Micropub.CategoryParam],
-			Content:    []string{p.contentWithParams()},
-			URL:        []string{a.fullPostURL(p)},
-			InReplyTo:  p.Parameters[a.cfg.Micropub.ReplyParam],
-			LikeOf:     p.Parameters[a.cfg.Micropub.LikeParam],
-			BookmarkOf: p.Parameters[a.cfg.Micropub.BookmarkParam],
-			MpSlug:     []string{p.Slug},
-			Audio:      p.Parameters[a.cfg.Micropub.AudioParam],
+			Name:        p.Parameters["title"],
+			Published:   []string{p.Published},
+			Updated:     []string{p.Updated},
+			PostStatus:  []string{mfStatus},
+			Visibility:  []string{mfVisibility},
+			Category:    p.Parameters[a.cfg.Micropub.CategoryParam],
+			Content:     []string{p.contentWithParams()},
+			URL:         []string{a.fullPostURL(p)},
+			InReplyTo:   p.Parameters[a.cfg.Micropub.ReplyParam],
+			LikeOf:      p.Parameters[a.cfg.Micropub.LikeParam],
+			BookmarkOf:  p.Parameters[a.cfg.Micropub.BookmarkParam],
+			MpSlug:      []string{p.Slug},
+			Audio:       p.Parameters[a.cfg.Micropub.AudioParam],
+			Syndication: p.Parameters[syndicationParam],
+			Series:      p.Parameters[seriesParam],
 			// TODO: Photos
 		},
 	}
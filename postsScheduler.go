@@ -1,8 +1,16 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"time"
+
+	"github.com/araddon/dateparse"
+)
+
+const (
+	expiresParam       = "expires"
+	expiresStatusParam = "expiresstatus"
 )
 
 func (a *goBlog) startPostsScheduler() {
@@ -15,6 +23,7 @@ func (a *goBlog) startPostsScheduler() {
 				return
 			case <-ticker.C:
 				a.checkScheduledPosts()
+				a.checkExpiredPosts()
 			}
 		}
 	}()
@@ -36,6 +45,8 @@ func (a *goBlog) checkScheduledPosts() {
 	}
 	for _, post := range postsToPublish {
 		post.Status = "published"
+		// Not queued anymore
+		delete(post.Parameters, queueParam)
 		err := a.replacePost(post, post.Path, statusScheduled)
 		if err != nil {
 			log.Println("Error publishing scheduled post:", err)
@@ -44,3 +55,55 @@ func (a *goBlog) checkScheduledPosts() {
 		log.Println("Published scheduled post:", post.Path)
 	}
 }
+
+// Move posts with a passed expires date to the status of the expiresstatus parameter (unlisted by default)
+func (a *goBlog) checkExpiredPosts() {
+	postsWithExpiry, err := a.getPosts(&postsRequestConfig{
+		statusse:  []postStatus{statusPublished, statusUnlisted, statusPrivate},
+		parameter: expiresParam,
+	})
+	if err != nil {
+		log.Println("Error getting expiring posts:", err)
+		return
+	}
+	now := time.Now()
+	for _, p := range postsWithExpiry {
+		expires, err := dateparse.ParseLocal(p.firstParameter(expiresParam))
+		if err != nil || expires.After(now) {
+			continue
+		}
+		if err := a.expirePost(p); err != nil {
+			log.Println("Error expiring post:", err)
+			continue
+		}
+		log.Println("Expired post:", p.Path)
+	}
+}
+
+func (a *goBlog) expirePost(p *post) error {
+	target := postStatus(defaultIfEmpty(p.firstParameter(expiresStatusParam), string(statusUnlisted)))
+	if target == "deleted" {
+		// Remove expiry first, so an undeleted post doesn't get deleted again
+		if err := a.db.replacePostParam(p.Path, expiresParam, nil); err != nil {
+			return err
+		}
+		return a.deletePost(p.Path)
+	}
+	if target != statusUnlisted && target != statusPrivate {
+		return fmt.Errorf("invalid expiry status %q for %s", target, p.Path)
+	}
+	oldStatus := p.Status
+	delete(p.Parameters, expiresParam)
+	delete(p.Parameters, expiresStatusParam)
+	if oldStatus == statusPrivate || (oldStatus == statusUnlisted && target == statusUnlisted) {
+		// Already at least as hidden as the target, just remove the expiry
+		return a.db.replacePostParam(p.Path, expiresParam, nil)
+	}
+	p.Status = target
+	// Triggers the update hooks when changing from published to unlisted
+	if err := a.replacePost(p, p.Path, oldStatus); err != nil {
+		return err
+	}
+	a.postHiddenHooks(p, oldStatus)
+	return nil
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
@@ -57,3 +58,81 @@ func Test_postsScheduler(t *testing.T) {
 	assert.Equal(t, 1, count)
 
 }
+
+func Test_expiredPosts(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	_ = app.initConfig()
+	_ = app.initDatabase(false)
+	defer app.db.close()
+	app.initComponents(false)
+
+	var hookMu sync.Mutex
+	updated, deleted := []string{}, []string{}
+	app.pUpdateHooks = append(app.pUpdateHooks, func(p *post) {
+		hookMu.Lock()
+		updated = append(updated, p.Path)
+		hookMu.Unlock()
+	})
+	app.pDeleteHooks = append(app.pDeleteHooks, func(p *post) {
+		hookMu.Lock()
+		deleted = append(deleted, p.Path)
+		hookMu.Unlock()
+	})
+
+	past := time.Now().Add(-time.Minute).Format(time.RFC3339)
+	future := time.Now().Add(time.Hour).Format(time.RFC3339)
+	for _, p := range []*post{
+		{Path: "/unlisted", Parameters: map[string][]string{expiresParam: {past}}},
+		{Path: "/private", Parameters: map[string][]string{expiresParam: {past}, expiresStatusParam: {"private"}}},
+		{Path: "/deleted", Parameters: map[string][]string{expiresParam: {past}, expiresStatusParam: {"deleted"}}},
+		{Path: "/future", Parameters: map[string][]string{expiresParam: {future}}},
+	} {
+		p.Section = "posts"
+		p.Status = statusPublished
+		p.Content = "Test"
+		require.NoError(t, app.createPost(p))
+	}
+
+	// Invalid values
+	assert.Error(t, app.createPost(&post{Path: "/invalid", Content: "Test", Parameters: map[string][]string{expiresParam: {"abc"}}}))
+	assert.Error(t, app.createPost(&post{Path: "/invalid", Content: "Test", Parameters: map[string][]string{expiresParam: {past}, expiresStatusParam: {"draft"}}}))
+
+	app.checkExpiredPosts()
+
+	getPost := func(path string) *post {
+		p, err := app.getPost(path)
+		require.NoError(t, err)
+		return p
+	}
+	assert.Equal(t, statusUnlisted, getPost("/unlisted").Status)
+	assert.Empty(t, getPost("/unlisted").firstParameter(expiresParam))
+	assert.Equal(t, statusPrivate, getPost("/private").Status)
+	assert.Equal(t, statusPublishedDeleted, getPost("/deleted").Status)
+	assert.Empty(t, getPost("/deleted").firstParameter(expiresParam))
+	assert.Equal(t, statusPublished, getPost("/future").Status)
+
+	assert.Eventually(t, func() bool {
+		hookMu.Lock()
+		defer hookMu.Unlock()
+		return assert.ObjectsAreEqual([]string{"/unlisted"}, updated) &&
+			assert.ObjectsAreEqual(2, len(deleted))
+	}, time.Second, 10*time.Millisecond)
+	hookMu.Lock()
+	assert.ElementsMatch(t, []string{"/private", "/deleted"}, deleted)
+	hookMu.Unlock()
+
+	// Undeleted posts don't expire again
+	require.NoError(t, app.undeletePost("/deleted"))
+	app.checkExpiredPosts()
+	assert.Equal(t, statusPublished, getPost("/deleted").Status)
+
+	// Posts with older invalid values stay editable
+	require.NoError(t, app.db.savePost(&post{Path: "/old", Section: "posts", Status: statusPublished, Content: "Test", Parameters: map[string][]string{expiresParam: {"soon"}, expiresStatusParam: {"draft"}}}, &postCreationOptions{new: true}))
+	old := getPost("/old")
+	old.Content = "Edited"
+	require.NoError(t, app.replacePost(old, old.Path, old.Status))
+	old.Parameters[expiresParam] = []string{"later"}
+	assert.Error(t, app.replacePost(old, old.Path, old.Status))
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/gob"
+	"sort"
+	"strings"
+	"unicode"
+
+	"go.goblog.app/app/pkgs/bufferpool"
+)
+
+const (
+	defaultRelatedPostsCount = 5
+	relatedPostsCandidates   = 50
+	relatedPostsMaxTerms     = 12
+	relatedPostsMinTermLen   = 4
+)
+
+func (a *goBlog) relatedPostsEnabled(bc *configBlog) bool {
+	return bc != nil && bc.RelatedPosts != nil && bc.RelatedPosts.Enabled
+}
+
+func (a *goBlog) initRelatedPosts() {
+	// New, changed or removed posts can change the related posts of all posts of the blog
+	f := func(p *post) {
+		a.db.resetRelatedPosts(p.Blog)
+	}
+	a.pPostHooks = append(a.pPostHooks, f)
+	a.pUpdateHooks = append(a.pUpdateHooks, f)
+	a.pDeleteHooks = append(a.pDeleteHooks, f)
+	a.pUndeleteHooks = append(a.pUndeleteHooks, f)
+}
+
+// Get the related posts of a published post, cached persistently
+func (a *goBlog) relatedPosts(p *post) []*post {
+	bc := a.cfg.Blogs[p.Blog]
+	if !a.relatedPostsEnabled(bc) || p.Status != statusPublished {
+		return nil
+	}
+	paths, ok := a.db.loadRelatedPostsCache(p.Blog, p.Path)
+	if !ok {
+		var err error
+		if paths, err = a.computeRelatedPosts(bc, p); err != nil {
+			return nil
+		}
+		a.db.cacheRelatedPosts(p.Blog, p.Path, paths)
+	}
+	related := []*post{}
+	for _, path := range paths {
+		if rp, err := a.getPost(path); err == nil && rp.Status == statusPublished {
+			related = append(related, rp)
+		}
+	}
+	return related
+}
+
+// Score the other published posts of the blog by shared taxonomy values and text similarity
+func (a *goBlog) computeRelatedPosts(bc *configBlog, p *post) ([]string, error) {
+	rc := bc.RelatedPosts
+	count := rc.Count
+	if count <= 0 {
+		count = defaultRelatedPostsCount
+	}
+	taxonomyWeight, textWeight := rc.TaxonomyWeight, rc.TextWeight
+	if taxonomyWeight == 0 && textWeight == 0 {
+		taxonomyWeight, textWeight = 1, 1
+	}
+	scores := map[string]float64{}
+	// Shared taxonomy values
+	if taxonomyWeight != 0 {
+		values := map[string][]string{}
+		total := 0
+		for _, tax := range bc.Taxonomies {
+			if taxValues := p.Parameters[tax.Name]; len(taxValues) > 0 {
+				values[tax.Name] = taxValues
+				total += len(taxValues)
+			}
+		}
+		if conditions, args := taxonomyValuesConditions(values, "reltax"); len(conditions) > 0 {
+			rows, err := a.db.query(
+				"select path, count(distinct parameter || lowerx(value)) from post_parameters where ("+strings.Join(conditions, " or ")+
+					") and path != @path and path in (select path from posts where blog = @blog and status = @status) group by path",
+				append(args, sql.Named("path", p.Path), sql.Named("blog", p.Blog), sql.Named("status", statusPublished))...,
+			)
+			if err != nil {
+				return nil, err
+			}
+			var path string
+			var shared int
+			for rows.Next() {
+				if err = rows.Scan(&path, &shared); err != nil {
+					return nil, err
+				}
+				scores[path] += taxonomyWeight * float64(shared) / float64(total)
+			}
+			if err = rows.Err(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	// Text similarity using the full-text search index
+	if query := relatedPostsQuery(p.Title() + "\n" + a.renderText(p.Content)); textWeight != 0 && query != "" {
+		rows, err := a.db.query(
+			"select path, bm25(posts_fts, 0, 10, 1) as score from posts_fts(@search) where path != @path and path in (select path from posts where blog = @blog and status = @status) order by score limit @limit",
+			sql.Named("search", query), sql.Named("path", p.Path), sql.Named("blog", p.Blog), sql.Named("status", statusPublished), sql.Named("limit", relatedPostsCandidates),
+		)
+		if err != nil {
+			return nil, err
+		}
+		var path string
+		var score, best float64
+		textScores := map[string]float64{}
+		for rows.Next() {
+			if err = rows.Scan(&path, &score); err != nil {
+				return nil, err
+			}
+			// bm25 is negative, lower is better
+			if best == 0 {
+				best = score
+			}
+			textScores[path] = score
+		}
+		if err = rows.Err(); err != nil {
+			return nil, err
+		}
+		for path, score := range textScores {
+			if best != 0 {
+				scores[path] += textWeight * score / best
+			}
+		}
+	}
+	paths := make([]string, 0, len(scores))
+	for path, score := range scores {
+		if score > 0 {
+			paths = append(paths, path)
+		}
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if scores[paths[i]] != scores[paths[j]] {
+			return scores[paths[i]] > scores[paths[j]]
+		}
+		return paths[i] < paths[j]
+	})
+	if len(paths) > count {
+		paths = paths[:count]
+	}
+	return paths, nil
+}
+
+// Full-text search query with the most frequent words of the text
+func relatedPostsQuery(text string) string {
+	counts := map[string]int{}
+	var words []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		if len([]rune(word)) < relatedPostsMinTermLen {
+			continue
+		}
+		if counts[word] == 0 {
+			words = append(words, word)
+		}
+		counts[word]++
+	}
+	sort.SliceStable(words, func(i, j int) bool {
+		return counts[words[i]] > counts[words[j]]
+	})
+	if len(words) > relatedPostsMaxTerms {
+		words = words[:relatedPostsMaxTerms]
+	}
+	for i, word := range words {
+		words[i] = `"` + word + `"`
+	}
+	return strings.Join(words, " OR ")
+}
+
+func relatedPostsCacheKey(blog, path string) string {
+	return "relatedposts_" + blog + "_" + path
+}
+
+func (db *database) cacheRelatedPosts(blog, path string, paths []string) {
+	buf := bufferpool.Get()
+	_ = gob.NewEncoder(buf).Encode(paths)
+	_ = db.cachePersistently(relatedPostsCacheKey(blog, path), buf.Bytes())
+	bufferpool.Put(buf)
+}
+
+func (db *database) loadRelatedPostsCache(blog, path string) (paths []string, ok bool) {
+	data, err := db.retrievePersistentCache(relatedPostsCacheKey(blog, path))
+	if err != nil || data == nil {
+		return nil, false
+	}
+	if err = gob.NewDecoder(bytes.NewReader(data)).Decode(&paths); err != nil {
+		return nil, false
+	}
+	return paths, true
+}
+
+func (db *database) resetRelatedPosts(blog string) {
+	_ = db.clearPersistentCache(relatedPostsCacheKey(blog, "%"))
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/carlmjohnson/requests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_relatedPosts(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	_ = app.initConfig()
+	app.cfg.Blogs["default"].RelatedPosts = &configRelatedPosts{Enabled: true, Count: 2}
+	_ = app.initDatabase(false)
+	defer app.db.close()
+	app.initComponents(false)
+	app.d = app.buildRouter()
+
+	for _, p := range []*post{
+		{Path: "/1", Content: "Riding my bicycle through the mountains.", Parameters: map[string][]string{"tags": {"Travel", "Bike"}}},
+		{Path: "/2", Content: "Hiking in the mountains.", Parameters: map[string][]string{"tags": {"Travel", "Bike"}}},
+		{Path: "/3", Content: "Repairing the bicycle in the garage."},
+		{Path: "/4", Content: "Cooking pasta.", Parameters: map[string][]string{"tags": {"Food"}}},
+		{Path: "/5", Content: "Bicycle mountains bicycle.", Status: statusDraft, Parameters: map[string][]string{"tags": {"Travel", "Bike"}}},
+	} {
+		p.Section = "posts"
+		p.Published = "2022-01-01T00:00:00Z"
+		if p.Status == "" {
+			p.Status = statusPublished
+		}
+		require.NoError(t, app.createPost(p))
+	}
+
+	p, err := app.getPost("/1")
+	require.NoError(t, err)
+	paths, err := app.computeRelatedPosts(app.cfg.Blogs["default"], p)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/2", "/3"}, paths)
+	related := app.relatedPosts(p)
+	if assert.Len(t, related, 2) {
+		// Shared tags and text first
+		assert.Equal(t, "/2", related[0].Path)
+		assert.Equal(t, "/3", related[1].Path)
+	}
+
+	// Rendered on the post
+	var html string
+	err = requests.URL("http://localhost:8080/1").Client(newHandlerClient(app.d)).ToString(&html).Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, html, "Related posts")
+	assert.Contains(t, html, `<a href=/2>Hiking in the mountains.</a>`)
+
+	// Cache, the hooks of the created posts reset the default blog in the background
+	app.db.cacheRelatedPosts("other", "/1", []string{"/2", "/3"})
+	cached, ok := app.db.loadRelatedPostsCache("other", "/1")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"/2", "/3"}, cached)
+	app.db.resetRelatedPosts("other")
+	_, ok = app.db.loadRelatedPostsCache("other", "/1")
+	assert.False(t, ok)
+
+	// Drafts have no related posts
+	p, err = app.getPost("/5")
+	require.NoError(t, err)
+	assert.Empty(t, app.relatedPosts(p))
+
+	assert.Equal(t, `"bicycle" OR "mountains"`, relatedPostsQuery("Bicycle, mountains and the bicycle!"))
+}
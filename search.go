@@ -2,16 +2,32 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html"
 	"net/http"
 	"net/url"
 	"path"
+	"sort"
+	"strconv"
 	"strings"
+
+	"github.com/samber/lo"
+	"go.goblog.app/app/pkgs/bufferpool"
+	"go.goblog.app/app/pkgs/contenttype"
 )
 
 const defaultSearchPath = "/search"
 const searchPlaceholder = "{search}"
 
+const (
+	searchFacetLimit      = 20
+	searchSuggestionsMax  = 10
+	searchInteractionsMax = 20
+)
+
 func (a *goBlog) serveSearch(w http.ResponseWriter, r *http.Request) {
 	servePath := r.Context().Value(pathKey).(string)
 	err := r.ParseForm()
@@ -53,3 +69,474 @@ func searchDecode(encoded string) string {
 	}
 	return string(db)
 }
+
+// Filters to narrow down search results, set using query parameters
+type searchFilters struct {
+	section    string
+	year       int
+	taxonomies map[string][]string
+}
+
+func getSearchFilters(bc *configBlog, query url.Values) *searchFilters {
+	f := &searchFilters{taxonomies: map[string][]string{}}
+	if section := query.Get("section"); bc.Sections[section] != nil {
+		f.section = section
+	}
+	f.year = stringToInt(query.Get("year"))
+	for _, tax := range bc.Taxonomies {
+		if values := lo.Filter(query[tax.Name], func(v string, _ int) bool { return v != "" }); len(values) > 0 {
+			f.taxonomies[tax.Name] = values
+		}
+	}
+	return f
+}
+
+func (f *searchFilters) apply(c *postsRequestConfig) {
+	if f.section != "" {
+		c.sections = []string{f.section}
+	}
+	if f.year != 0 {
+		c.publishedYear = f.year
+	}
+	if len(f.taxonomies) > 0 {
+		c.taxonomyValues = f.taxonomies
+		c.taxonomyValuesMatchAll = true
+	}
+}
+
+func (f *searchFilters) values() url.Values {
+	values := url.Values{}
+	if f.section != "" {
+		values.Set("section", f.section)
+	}
+	if f.year != 0 {
+		values.Set("year", strconv.Itoa(f.year))
+	}
+	for taxonomy, taxValues := range f.taxonomies {
+		values[taxonomy] = append([]string{}, taxValues...)
+	}
+	return values
+}
+
+// Query string with the filters and the value of the key toggled
+func (f *searchFilters) toggledQuery(key, value string) string {
+	values := f.values()
+	if lo.ContainsBy(values[key], func(v string) bool { return strings.EqualFold(v, value) }) {
+		values[key] = lo.Filter(values[key], func(v string, _ int) bool { return !strings.EqualFold(v, value) })
+	} else if key == "section" || key == "year" {
+		values.Set(key, value)
+	} else {
+		values.Add(key, value)
+	}
+	return encodeQuery(values)
+}
+
+func encodeQuery(values url.Values) string {
+	if encoded := values.Encode(); encoded != "" {
+		return "?" + encoded
+	}
+	return ""
+}
+
+type searchFacet struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+type searchFacets struct {
+	Sections   []*searchFacet            `json:"sections"`
+	Years      []*searchFacet            `json:"years"`
+	Taxonomies map[string][]*searchFacet `json:"taxonomies"`
+}
+
+// Number of search results per section, year and taxonomy value
+func (db *database) getSearchFacets(c *postsRequestConfig, taxonomies []string) (*searchFacets, error) {
+	fc := *c
+	fc.limit, fc.offset = 0, 0
+	facets := &searchFacets{Taxonomies: map[string][]*searchFacet{}}
+	scanFacets := func(query string, args []any, add func(key string, f *searchFacet)) error {
+		rows, err := db.query(query, args...)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			var key string
+			f := &searchFacet{}
+			if err = rows.Scan(&key, &f.Value, &f.Count); err != nil {
+				return err
+			}
+			add(key, f)
+		}
+		return rows.Err()
+	}
+	// Sections
+	query, args := buildPostsQuery(&fc, "coalesce(section, '') as section")
+	err := scanFacets(
+		"select '', section, count(*) as c from ("+query+") where section != '' group by section order by c desc, section",
+		args, func(_ string, f *searchFacet) { facets.Sections = append(facets.Sections, f) },
+	)
+	if err != nil {
+		return nil, err
+	}
+	// Years
+	query, args = buildPostsQuery(&fc, "substr(tolocal(published), 1, 4) as year")
+	err = scanFacets(
+		"select '', year, count(*) from ("+query+") where coalesce(year, '') != '' group by year order by year desc",
+		args, func(_ string, f *searchFacet) { facets.Years = append(facets.Years, f) },
+	)
+	if err != nil {
+		return nil, err
+	}
+	// Taxonomies
+	if len(taxonomies) == 0 {
+		return facets, nil
+	}
+	query, args = buildPostsQuery(&fc, "path")
+	named := make([]string, 0, len(taxonomies))
+	for i, taxonomy := range taxonomies {
+		name := "facettax" + strconv.Itoa(i)
+		named = append(named, "@"+name)
+		args = append(args, sql.Named(name, taxonomy))
+	}
+	err = scanFacets(
+		"select parameter, min(value), count(distinct path) as c from post_parameters where path in ("+query+") and parameter in ("+
+			strings.Join(named, ", ")+") and length(coalesce(value, '')) > 0 group by parameter, lowerx(value) order by c desc, lowerx(value)",
+		args, func(taxonomy string, f *searchFacet) {
+			if len(facets.Taxonomies[taxonomy]) < searchFacetLimit {
+				facets.Taxonomies[taxonomy] = append(facets.Taxonomies[taxonomy], f)
+			}
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return facets, nil
+}
+
+// Highlighted title and content snippet (HTML) of a search result
+type searchSnippet struct {
+	title, content string
+}
+
+const (
+	searchHighlightStart = "\x02"
+	searchHighlightEnd   = "\x03"
+)
+
+func (db *database) getSearchSnippets(search string, posts []*post) (map[string]*searchSnippet, error) {
+	snippets := map[string]*searchSnippet{}
+	if len(posts) == 0 {
+		return snippets, nil
+	}
+	args := []any{sql.Named("search", search)}
+	named := make([]string, 0, len(posts))
+	for i, p := range posts {
+		name := "path" + strconv.Itoa(i)
+		named = append(named, "@"+name)
+		args = append(args, sql.Named(name, p.Path))
+	}
+	rows, err := db.query(
+		"select path, highlight(posts_fts, 1, char(2), char(3)), snippet(posts_fts, 2, char(2), char(3), '…', 32), snippet(posts_fts, 3, char(2), char(3), '…', 32) from posts_fts(@search) where path in ("+
+			strings.Join(named, ", ")+")",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	var path, title, content, params string
+	for rows.Next() {
+		if err = rows.Scan(&path, &title, &content, &params); err != nil {
+			return nil, err
+		}
+		if !strings.Contains(content, searchHighlightStart) && strings.Contains(params, searchHighlightStart) {
+			// Matched in the indexed parameters only
+			content = params
+		}
+		snippets[path] = &searchSnippet{
+			title:   searchHighlightHTML(title),
+			content: searchHighlightHTML(content),
+		}
+	}
+	return snippets, rows.Err()
+}
+
+// Escape the text and mark the highlighted parts
+func searchHighlightHTML(s string) string {
+	return strings.NewReplacer(
+		searchHighlightStart, "<mark>",
+		searchHighlightEnd, "</mark>",
+	).Replace(html.EscapeString(s))
+}
+
+// Post parameters to add to the full-text search index, if none are configured:
+// summary, reply and like titles and image descriptions
+func (a *goBlog) searchIndexParameters() []string {
+	configured := false
+	params := []string{}
+	for _, bc := range a.cfg.Blogs {
+		if bc.Search != nil && bc.Search.Parameters != nil {
+			configured = true
+			params = append(params, bc.Search.Parameters...)
+		}
+	}
+	if !configured {
+		params = append(params, "summary")
+		if mp := a.cfg.Micropub; mp != nil {
+			params = append(params, mp.ReplyTitleParam, mp.LikeTitleParam, mp.PhotoDescriptionParam)
+		}
+	}
+	params = lo.Uniq(lo.Filter(params, func(p string, _ int) bool { return p != "" }))
+	sort.Strings(params)
+	return params
+}
+
+// Update the indexed parameters and rebuild the index if they changed
+func (db *database) setSearchParameters(params []string) error {
+	rows, err := db.query("select parameter from search_parameters order by parameter")
+	if err != nil {
+		return err
+	}
+	current := []string{}
+	var param string
+	for rows.Next() {
+		if err = rows.Scan(&param); err != nil {
+			return err
+		}
+		current = append(current, param)
+	}
+	if strings.Join(current, "\n") == strings.Join(params, "\n") {
+		return nil
+	}
+	if _, err = db.exec("delete from search_parameters"); err != nil {
+		return err
+	}
+	for _, param := range params {
+		if _, err = db.exec("insert into search_parameters (parameter) values (@param)", sql.Named("param", param)); err != nil {
+			return err
+		}
+	}
+	db.rebuildFTSIndex()
+	return nil
+}
+
+// Approved comment or webmention matching the search
+type searchInteraction struct {
+	id                   int
+	path                 string
+	author, url, snippet string
+}
+
+func (a *goBlog) searchInteractions(blog, search string) ([]*searchInteraction, error) {
+	rows, err := a.db.query(
+		"select w.id, w.target, coalesce(w.author, ''), coalesce(nullif(w.url, ''), w.source), snippet(webmentions_fts, -1, char(2), char(3), '…', 24) "+
+			"from webmentions_fts(@search) f, webmentions w where f.rowid = w.id and w.status = @status order by bm25(webmentions_fts) limit @limit",
+		sql.Named("search", search), sql.Named("status", webmentionStatusApproved), sql.Named("limit", searchInteractionsMax),
+	)
+	if err != nil {
+		return nil, err
+	}
+	interactions := []*searchInteraction{}
+	for rows.Next() {
+		i := &searchInteraction{}
+		var target string
+		if err = rows.Scan(&i.id, &target, &i.author, &i.url, &i.snippet); err != nil {
+			return nil, err
+		}
+		i.snippet = searchHighlightHTML(i.snippet)
+		i.path = defaultIfEmpty(strings.TrimPrefix(target, a.getFullAddress("/")), "/")
+		interactions = append(interactions, i)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	// Only interactions on published posts of the blog
+	return lo.Filter(interactions, func(i *searchInteraction, _ int) bool {
+		p, err := a.getPost(i.path)
+		return err == nil && p.Blog == blog && p.Status == statusPublished
+	}), nil
+}
+
+// Link to the interaction on the post
+func (i *searchInteraction) postPath() string {
+	return fmt.Sprintf("%s#mention-%d", i.path, i.id)
+}
+
+// Data to render facets and snippets on the search result pages
+type searchRenderData struct {
+	path         string
+	filters      *searchFilters
+	facets       *searchFacets
+	snippets     map[string]*searchSnippet
+	interactions []*searchInteraction
+}
+
+func (a *goBlog) getSearchRenderData(c *postsRequestConfig, bc *configBlog, path string, filters *searchFilters, posts []*post, firstPage bool) (*searchRenderData, error) {
+	facets, err := a.db.getSearchFacets(c, lo.Map(bc.Taxonomies, func(t *configTaxonomy, _ int) string { return t.Name }))
+	if err != nil {
+		return nil, err
+	}
+	snippets, err := a.db.getSearchSnippets(c.search, posts)
+	if err != nil {
+		return nil, err
+	}
+	sd := &searchRenderData{path: path, filters: filters, facets: facets, snippets: snippets}
+	if firstPage && bc.Search.Interactions {
+		if sd.interactions, err = a.searchInteractions(c.blog, c.search); err != nil {
+			return nil, err
+		}
+	}
+	return sd, nil
+}
+
+type searchAPIResponse struct {
+	Query        string                        `json:"query"`
+	Page         int                           `json:"page"`
+	Pages        int                           `json:"pages"`
+	Total        int                           `json:"total"`
+	Results      []*searchAPIResult            `json:"results"`
+	Facets       *searchFacets                 `json:"facets"`
+	Interactions []*searchAPIInteractionResult `json:"interactions,omitempty"`
+}
+
+type searchAPIInteractionResult struct {
+	URL     string `json:"url"`
+	Source  string `json:"source"`
+	Author  string `json:"author,omitempty"`
+	Snippet string `json:"snippet,omitempty"`
+}
+
+type searchAPIResult struct {
+	URL       string `json:"url"`
+	Title     string `json:"title,omitempty"`
+	Snippet   string `json:"snippet,omitempty"`
+	Published string `json:"published,omitempty"`
+	Section   string `json:"section,omitempty"`
+}
+
+// Search the blog posts with filters and pagination
+func (a *goBlog) searchAPI(blog string, bc *configBlog, query url.Values, withFacets bool) (*searchAPIResponse, error) {
+	search := cleanHTMLText(query.Get("q"))
+	page := stringToInt(query.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	c := &postsRequestConfig{
+		blog:     blog,
+		sections: lo.Keys(bc.Sections),
+		search:   search,
+		statusse: []postStatus{statusPublished},
+	}
+	filters := getSearchFilters(bc, query)
+	filters.apply(c)
+	total, err := a.db.countPosts(c)
+	if err != nil {
+		return nil, err
+	}
+	pageSize := bc.Pagination
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	pc := *c
+	pc.limit, pc.offset = pageSize, (page-1)*pageSize
+	posts, err := a.getPosts(&pc)
+	if err != nil {
+		return nil, err
+	}
+	response := &searchAPIResponse{
+		Query:   search,
+		Page:    page,
+		Pages:   (total + pageSize - 1) / pageSize,
+		Total:   total,
+		Results: []*searchAPIResult{},
+	}
+	snippets, err := a.db.getSearchSnippets(search, posts)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range posts {
+		result := &searchAPIResult{
+			URL:       a.fullPostURL(p),
+			Title:     html.EscapeString(p.RenderedTitle),
+			Published: p.Published,
+			Section:   p.Section,
+		}
+		if s := snippets[p.Path]; s != nil {
+			result.Title = defaultIfEmpty(s.title, result.Title)
+			result.Snippet = s.content
+		}
+		response.Results = append(response.Results, result)
+	}
+	if withFacets {
+		taxonomies := lo.Map(bc.Taxonomies, func(t *configTaxonomy, _ int) string { return t.Name })
+		if response.Facets, err = a.db.getSearchFacets(c, taxonomies); err != nil {
+			return nil, err
+		}
+	}
+	if withFacets && page == 1 && bc.Search != nil && bc.Search.Interactions {
+		interactions, err := a.searchInteractions(blog, search)
+		if err != nil {
+			return nil, err
+		}
+		for _, i := range interactions {
+			response.Interactions = append(response.Interactions, &searchAPIInteractionResult{
+				URL:     a.getFullAddress(i.postPath()),
+				Source:  i.url,
+				Author:  i.author,
+				Snippet: i.snippet,
+			})
+		}
+	}
+	return response, nil
+}
+
+func (a *goBlog) serveSearchAPI(w http.ResponseWriter, r *http.Request) {
+	blog, bc := a.getBlog(r)
+	if r.URL.Query().Get("q") == "" {
+		a.serveError(w, r, "missing q", http.StatusBadRequest)
+		return
+	}
+	response, err := a.searchAPI(blog, bc, r.URL.Query(), true)
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+	if err := json.NewEncoder(buf).Encode(response); err != nil {
+		a.serveError(w, r, "Failed to encode json", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(contentType, contenttype.JSONUTF8)
+	_ = a.min.Get().Minify(contenttype.JSON, w, buf)
+}
+
+// OpenSearch suggestions: [query, [titles], [descriptions], [urls]]
+// https://github.com/dewitt/opensearch/blob/master/mediawiki/Specifications/OpenSearch/Extensions/Suggestions/1.1/Draft%201.wiki
+func (a *goBlog) serveSearchSuggestions(w http.ResponseWriter, r *http.Request) {
+	blog, bc := a.getBlog(r)
+	q := r.URL.Query().Get("q")
+	titles, descriptions, urls := []string{}, []string{}, []string{}
+	if q != "" {
+		response, err := a.searchAPI(blog, bc, url.Values{"q": {q}}, false)
+		if err != nil {
+			a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		for i, result := range response.Results {
+			if i >= searchSuggestionsMax {
+				break
+			}
+			titles = append(titles, htmlText(defaultIfEmpty(result.Title, result.URL)))
+			descriptions = append(descriptions, htmlText(result.Snippet))
+			urls = append(urls, result.URL)
+		}
+	}
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+	if err := json.NewEncoder(buf).Encode([]any{q, titles, descriptions, urls}); err != nil {
+		a.serveError(w, r, "Failed to encode json", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set(contentType, "application/x-suggestions+json"+contenttype.CharsetUtf8Suffix)
+	_ = a.min.Get().Minify(contenttype.JSON, w, buf)
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"context"
 	"testing"
 
+	"github.com/carlmjohnson/requests"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_searchEncoding(t *testing.T) {
@@ -13,3 +16,101 @@ func Test_searchEncoding(t *testing.T) {
 	assert.Equal(t, testString, searchDecode(searchEncode(testString)))
 
 }
+
+func Test_search(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	_ = app.initConfig()
+	bc := app.cfg.Blogs["default"]
+	bc.Search = &configSearch{Enabled: true, Title: "Search", Interactions: true}
+	bc.Sections["micro"] = &configSection{Name: "micro", Title: "Micro"}
+	_ = app.initDatabase(false)
+	defer app.db.close()
+	app.initComponents(false)
+	app.d = app.buildRouter()
+	handlerClient := newHandlerClient(app.d)
+
+	for _, p := range []*post{
+		{Path: "/1", Section: "posts", Published: "2021-05-01T00:00:00Z", Content: "Some text about a bicycle tour.", Parameters: map[string][]string{"tags": {"Travel"}}},
+		{Path: "/2", Section: "posts", Published: "2022-05-01T00:00:00Z", Content: "Something else.", Parameters: map[string][]string{"title": {"Bicycle repair"}, "tags": {"Travel", "Diy"}}},
+		{Path: "/3", Section: "micro", Published: "2022-06-01T00:00:00Z", Content: "My bicycle <is> broken.", Parameters: map[string][]string{"tags": {"Diy"}}},
+		{Path: "/4", Section: "posts", Published: "2022-07-01T00:00:00Z", Content: "Nothing to see here.", Parameters: map[string][]string{"summary": {"A unicycle"}}},
+	} {
+		p.Status = statusPublished
+		require.NoError(t, app.createPost(p))
+	}
+
+	// JSON API
+	var response searchAPIResponse
+	err := requests.URL("http://localhost:8080/search/api?q=bicycle").Client(handlerClient).ToJSON(&response).Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 3, response.Total)
+	require.Len(t, response.Results, 3)
+	// Title matches are ranked higher
+	assert.Equal(t, "http://localhost:8080/2", response.Results[0].URL)
+	assert.Equal(t, "<mark>Bicycle</mark> repair", response.Results[0].Title)
+	for _, result := range response.Results {
+		if result.URL == "http://localhost:8080/3" {
+			assert.Equal(t, "My <mark>bicycle</mark> &lt;is&gt; broken.", result.Snippet)
+		}
+	}
+	if assert.NotNil(t, response.Facets) {
+		assert.Equal(t, []*searchFacet{{Value: "posts", Count: 2}, {Value: "micro", Count: 1}}, response.Facets.Sections)
+		assert.Equal(t, []*searchFacet{{Value: "2022", Count: 2}, {Value: "2021", Count: 1}}, response.Facets.Years)
+		assert.Equal(t, []*searchFacet{{Value: "Diy", Count: 2}, {Value: "Travel", Count: 2}}, response.Facets.Taxonomies["tags"])
+	}
+
+	// Filters
+	err = requests.URL("http://localhost:8080/search/api?q=bicycle&tags=diy&year=2022&section=posts").Client(handlerClient).ToJSON(&response).Fetch(context.Background())
+	require.NoError(t, err)
+	if assert.Len(t, response.Results, 1) {
+		assert.Equal(t, "http://localhost:8080/2", response.Results[0].URL)
+	}
+
+	// HTML result page
+	var html string
+	err = requests.URL("http://localhost:8080/search/" + searchEncode("bicycle") + "?section=micro").Client(handlerClient).ToString(&html).Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, html, "My <mark>bicycle</mark> &lt;is> broken.")
+	assert.NotContains(t, html, "Bicycle</mark> repair")
+	assert.Contains(t, html, "✕ Micro")
+	assert.Contains(t, html, `href="/search/`+searchEncode("bicycle")+`?section=micro&tags=Diy"`)
+
+	// Indexed parameters
+	assert.Equal(t, []string{"imagealts", "liketitle", "replytitle", "summary"}, app.searchIndexParameters())
+	err = requests.URL("http://localhost:8080/search/api?q=unicycle").Client(handlerClient).ToJSON(&response).Fetch(context.Background())
+	require.NoError(t, err)
+	if assert.Len(t, response.Results, 1) {
+		assert.Equal(t, "http://localhost:8080/4", response.Results[0].URL)
+		assert.Equal(t, "A <mark>unicycle</mark>", response.Results[0].Snippet)
+	}
+
+	// Interactions
+	_, err = app.db.exec(
+		"insert into webmentions (source, target, created, status, content, author) values ('https://example.com/reply', 'http://localhost:8080/4', 1, 'approved', 'I own a unicycle too', 'Jane')",
+	)
+	require.NoError(t, err)
+	_, err = app.db.exec(
+		"insert into webmentions (source, target, created, status, content, author) values ('https://example.com/spam', 'http://localhost:8080/4', 1, 'new', 'Buy a unicycle', 'Spam')",
+	)
+	require.NoError(t, err)
+	app.cache.purge()
+	err = requests.URL("http://localhost:8080/search/api?q=unicycle").Client(handlerClient).ToJSON(&response).Fetch(context.Background())
+	require.NoError(t, err)
+	if assert.Len(t, response.Interactions, 1) {
+		assert.Regexp(t, `^http://localhost:8080/4#mention-\d+$`, response.Interactions[0].URL)
+		assert.Equal(t, "Jane", response.Interactions[0].Author)
+		assert.Equal(t, "I own a <mark>unicycle</mark> too", response.Interactions[0].Snippet)
+	}
+	err = requests.URL("http://localhost:8080/search/" + searchEncode("unicycle")).Client(handlerClient).ToString(&html).Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, html, "I own a <mark>unicycle</mark> too")
+	assert.NotContains(t, html, "Buy a")
+
+	// OpenSearch suggestions
+	var suggestions []any
+	err = requests.URL("http://localhost:8080/search/suggest?q=repair").Client(handlerClient).ToJSON(&suggestions).Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []any{"repair", []any{"Bicycle repair"}, []any{"Something else."}, []any{"http://localhost:8080/2"}}, suggestions)
+}
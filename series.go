@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Posts with the same series parameter are parts of a series, ordered by publishing date
+const seriesParam = "series"
+
+func (bc *configBlog) seriesEnabled() bool {
+	return bc != nil && bc.Series != nil && bc.Series.Enabled
+}
+
+// The series are served like a taxonomy, but with the oldest posts first
+func (a *goBlog) seriesTaxonomy(bc *configBlog) *configTaxonomy {
+	return &configTaxonomy{
+		Name:        seriesParam,
+		Title:       defaultIfEmpty(bc.Series.Title, a.ts.GetTemplateStringVariant(bc.Lang, "series")),
+		Description: bc.Series.Description,
+	}
+}
+
+func (a *goBlog) serveSeries(w http.ResponseWriter, r *http.Request) {
+	_, bc := a.getBlog(r)
+	a.serveTaxonomyValueIndex(w, r, a.seriesTaxonomy(bc), true)
+}
+
+type postSeriesInfo struct {
+	name, path  string
+	part, total int
+	prev, next  *post
+}
+
+// Get the position of a published post in its series
+func (a *goBlog) postSeries(p *post) *postSeriesInfo {
+	bc := a.cfg.Blogs[p.Blog]
+	name := p.firstParameter(seriesParam)
+	if !bc.seriesEnabled() || name == "" || p.Status != statusPublished {
+		return nil
+	}
+	parts, err := a.getPosts(&postsRequestConfig{
+		blog:               p.Blog,
+		statusse:           []postStatus{statusPublished},
+		taxonomyValues:     map[string][]string{seriesParam: {name}},
+		ascendingOrder:     true,
+		withOnlyParameters: []string{"title", "summary"},
+	})
+	if err != nil {
+		return nil
+	}
+	for i, part := range parts {
+		if part.Path != p.Path {
+			continue
+		}
+		info := &postSeriesInfo{
+			name:  name,
+			path:  bc.getRelativePath(fmt.Sprintf("/%s/%s", seriesParam, urlize(name))),
+			part:  i + 1,
+			total: len(parts),
+		}
+		if i > 0 {
+			info.prev = parts[i-1]
+		}
+		if i < len(parts)-1 {
+			info.next = parts[i+1]
+		}
+		return info
+	}
+	return nil
+}
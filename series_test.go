@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/carlmjohnson/requests"
+	"github.com/mmcdole/gofeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_series(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	_ = app.initConfig()
+	bc := app.cfg.Blogs["default"]
+	bc.Series = &configSeries{Enabled: true}
+	// More parts than fit on one page
+	bc.Pagination = 2
+	_ = app.initDatabase(false)
+	defer app.db.close()
+	app.initComponents(false)
+	app.d = app.buildRouter()
+	handlerClient := newHandlerClient(app.d)
+
+	for _, p := range []*post{
+		{Path: "/2", Published: "2021-02-01T00:00:00Z", Parameters: map[string][]string{"title": {"Part two"}, "series": {"House"}}},
+		{Path: "/1", Published: "2021-01-01T00:00:00Z", Parameters: map[string][]string{"title": {"Part one"}, "series": {"House"}}},
+		{Path: "/3", Published: "2021-03-01T00:00:00Z", Parameters: map[string][]string{"title": {"Part three"}, "series": {"House"}}},
+		{Path: "/4", Published: "2021-04-01T00:00:00Z", Parameters: map[string][]string{"title": {"Other"}}},
+	} {
+		p.Section = "posts"
+		p.Status = statusPublished
+		p.Content = "Test"
+		require.NoError(t, app.createPost(p))
+	}
+
+	// Position in the series
+	p, err := app.getPost("/2")
+	require.NoError(t, err)
+	series := app.postSeries(p)
+	require.NotNil(t, series)
+	assert.Equal(t, "House", series.name)
+	assert.Equal(t, "/series/house", series.path)
+	assert.Equal(t, 2, series.part)
+	assert.Equal(t, 3, series.total)
+	assert.Equal(t, "/1", series.prev.Path)
+	assert.Equal(t, "/3", series.next.Path)
+
+	p, err = app.getPost("/4")
+	require.NoError(t, err)
+	assert.Nil(t, app.postSeries(p))
+
+	// Post page
+	var html string
+	err = requests.URL("http://localhost:8080/2").Client(handlerClient).ToString(&html).Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, html, "Part 2 of 3 of the series")
+	assert.Contains(t, html, `class=p-series href=/series/house>House</a>`)
+	assert.Contains(t, html, `<a href=/1>Part one</a>`)
+	assert.Contains(t, html, `<a href=/3>Part three</a>`)
+
+	// Series index with the oldest part first
+	err = requests.URL("http://localhost:8080/series/house").Client(handlerClient).ToString(&html).Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, html, "Part one")
+	assert.Contains(t, html, "Part two")
+	assert.NotContains(t, html, "Part three")
+
+	// Series feed with the newest parts first
+	var feed *gofeed.Feed
+	err = requests.URL("http://localhost:8080/series/house.json").Client(handlerClient).
+		Handle(func(r *http.Response) (err error) {
+			defer r.Body.Close()
+			feed, err = gofeed.NewParser().Parse(r.Body)
+			return
+		}).
+		Fetch(context.Background())
+	require.NoError(t, err)
+	require.Len(t, feed.Items, 2)
+	assert.Equal(t, "/3", feed.Items[0].GUID)
+	assert.Equal(t, "/2", feed.Items[1].GUID)
+
+	// Overview
+	err = requests.URL("http://localhost:8080/series").Client(handlerClient).ToString(&html).Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, html, "House")
+
+	// Micropub source
+	p, err = app.getPost("/1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"House"}, app.postToMfItem(p).Properties.Series)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+const (
+	syndicationParam = "syndication"
+	syndicateToParam = "syndicateto"
+	syndicationNone  = "none"
+)
+
+// Check if the post should be syndicated to the target.
+// Posts without a selection are syndicated to all enabled targets.
+func (a *goBlog) syndicateTo(p *post, target string) bool {
+	selected, ok := p.Parameters[syndicateToParam]
+	if !ok || len(selected) == 0 {
+		return true
+	}
+	return lo.Contains(selected, target)
+}
+
+// Add a syndication link to the post and save it
+func (a *goBlog) addSyndicationLink(p *post, link string) {
+	if link == "" || lo.Contains(p.Parameters[syndicationParam], link) {
+		return
+	}
+	if p.Parameters == nil {
+		p.Parameters = map[string][]string{}
+	}
+	p.Parameters[syndicationParam] = append(p.Parameters[syndicationParam], link)
+	if err := a.db.replacePostParam(p.Path, syndicationParam, p.Parameters[syndicationParam]); err != nil {
+		log.Printf("Failed to save syndication link: %v", err)
+		return
+	}
+	// Purge cache
+	a.cache.purge()
+}
+
+// Remove a syndication link from the post and save it
+func (a *goBlog) removeSyndicationLink(p *post, link string) {
+	if link == "" || !lo.Contains(p.Parameters[syndicationParam], link) {
+		return
+	}
+	p.Parameters[syndicationParam] = lo.Filter(p.Parameters[syndicationParam], func(s string, _ int) bool {
+		return s != link
+	})
+	if err := a.db.replacePostParam(p.Path, syndicationParam, p.Parameters[syndicationParam]); err != nil {
+		log.Printf("Failed to remove syndication link: %v", err)
+		return
+	}
+	// Purge cache
+	a.cache.purge()
+}
+
+// Get a public link to a Telegram message, empty if the chat has no public link
+func (tg *configTelegram) messageLink(chatId int64, messageId int) string {
+	if tg == nil || messageId == 0 {
+		return ""
+	}
+	if strings.HasPrefix(tg.ChatID, "@") {
+		// Public channel or group
+		return "https://t.me/" + strings.TrimPrefix(tg.ChatID, "@") + "/" + strconv.Itoa(messageId)
+	}
+	if chatIdString := strconv.FormatInt(chatId, 10); strings.HasPrefix(chatIdString, "-100") {
+		// Private channel or supergroup, link only works for members
+		return "https://t.me/c/" + strings.TrimPrefix(chatIdString, "-100") + "/" + strconv.Itoa(messageId)
+	}
+	return ""
+}
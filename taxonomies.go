@@ -6,8 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/samber/lo"
 )
 
 const taxonomyContextKey = "taxonomy"
@@ -20,6 +22,20 @@ func (a *goBlog) serveTaxonomy(w http.ResponseWriter, r *http.Request) {
 		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	// Also list terms without posts, e.g. parent terms
+	terms, err := a.db.getTaxonomyTerms(blog, tax.Name)
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(terms) > 0 {
+		for _, t := range terms {
+			if !lo.ContainsBy(allValues, func(v string) bool { return strings.EqualFold(v, t.Value) }) {
+				allValues = append(allValues, t.Value)
+			}
+		}
+		allValues = sortedStrings(allValues)
+	}
 	a.render(w, r, a.renderTaxonomy, &renderData{
 		Canonical: a.getFullAddress(r.URL.Path),
 		Data: &taxonomyRenderData{
@@ -30,8 +46,11 @@ func (a *goBlog) serveTaxonomy(w http.ResponseWriter, r *http.Request) {
 }
 
 func (a *goBlog) serveTaxonomyValue(w http.ResponseWriter, r *http.Request) {
-	_, bc := a.getBlog(r)
-	tax := r.Context().Value(taxonomyContextKey).(*configTaxonomy)
+	a.serveTaxonomyValueIndex(w, r, r.Context().Value(taxonomyContextKey).(*configTaxonomy), false)
+}
+
+func (a *goBlog) serveTaxonomyValueIndex(w http.ResponseWriter, r *http.Request, tax *configTaxonomy, ascending bool) {
+	blog, bc := a.getBlog(r)
 	taxValueParam := chi.URLParam(r, "taxValue")
 	if taxValueParam == "" {
 		a.serve404(w, r)
@@ -46,10 +65,31 @@ func (a *goBlog) serveTaxonomyValue(w http.ResponseWriter, r *http.Request) {
 		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	terms, err := a.db.getTaxonomyTerms(blog, tax.Name)
+	if err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
 	var taxValue string
 	err = row.Scan(&taxValue)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Maybe a term without posts of its own
+		for _, t := range terms {
+			if urlize(t.Value) == taxValueParam {
+				taxValue, err = t.Value, nil
+				break
+			}
+		}
+	}
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
+			// Maybe a renamed value
+			if target, _ := a.db.taxonomyRedirect(blog, tax.Name, taxValueParam); target != "" {
+				oldPath := bc.getRelativePath(fmt.Sprintf("/%s/%s", tax.Name, taxValueParam))
+				newPath := bc.getRelativePath(fmt.Sprintf("/%s/%s", tax.Name, urlize(target)))
+				http.Redirect(w, r, newPath+strings.TrimPrefix(r.URL.Path, oldPath), http.StatusMovedPermanently)
+				return
+			}
 			a.serve404(w, r)
 			return
 		}
@@ -58,8 +98,12 @@ func (a *goBlog) serveTaxonomyValue(w http.ResponseWriter, r *http.Request) {
 	}
 	// Serve index
 	a.serveIndex(w, r.WithContext(context.WithValue(r.Context(), indexConfigKey, &indexConfig{
-		path:     bc.getRelativePath(fmt.Sprintf("/%s/%s", tax.Name, taxValueParam)),
-		tax:      tax,
-		taxValue: taxValue,
+		path:           bc.getRelativePath(fmt.Sprintf("/%s/%s", tax.Name, taxValueParam)),
+		tax:            tax,
+		taxValue:       taxValue,
+		taxTerm:        findTaxonomyTerm(terms, taxValue),
+		taxChildren:    taxonomyTermChildren(terms, taxValue),
+		taxDescendants: taxonomyTermDescendants(terms, taxValue),
+		ascending:      ascending,
 	})))
 }
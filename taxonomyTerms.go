@@ -0,0 +1,145 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+// Metadata of a taxonomy value, values are compared case insensitive
+type taxonomyTerm struct {
+	Value       string
+	Description string
+	Image       string
+	Parent      string
+}
+
+func (db *database) getTaxonomyTerms(blog, taxonomy string) ([]*taxonomyTerm, error) {
+	rows, err := db.query(
+		"select value, description, image, parent from taxonomy_terms where blog = @blog and taxonomy = @tax order by lowerx(value)",
+		sql.Named("blog", blog), sql.Named("tax", taxonomy),
+	)
+	if err != nil {
+		return nil, err
+	}
+	terms := []*taxonomyTerm{}
+	for rows.Next() {
+		t := &taxonomyTerm{}
+		if err = rows.Scan(&t.Value, &t.Description, &t.Image, &t.Parent); err != nil {
+			return nil, err
+		}
+		terms = append(terms, t)
+	}
+	return terms, rows.Err()
+}
+
+func (db *database) saveTaxonomyTerm(blog, taxonomy string, t *taxonomyTerm) error {
+	t.Value, t.Parent = strings.TrimSpace(t.Value), strings.TrimSpace(t.Parent)
+	if t.Value == "" {
+		return errors.New("term value required")
+	}
+	terms, err := db.getTaxonomyTerms(blog, taxonomy)
+	if err != nil {
+		return err
+	}
+	if t.Parent != "" && (strings.EqualFold(t.Parent, t.Value) || lo.ContainsBy(taxonomyTermDescendants(terms, t.Value), func(v string) bool { return strings.EqualFold(v, t.Parent) })) {
+		return errors.New("a term can't be its own parent")
+	}
+	_, err = db.exec(
+		"begin; delete from taxonomy_terms where blog = ? and taxonomy = ? and lowerx(value) = lowerx(?); insert into taxonomy_terms (blog, taxonomy, value, description, image, parent) values (?, ?, ?, ?, ?, ?); commit;",
+		dbNoCache, blog, taxonomy, t.Value, blog, taxonomy, t.Value, strings.TrimSpace(t.Description), strings.TrimSpace(t.Image), t.Parent,
+	)
+	return err
+}
+
+func (db *database) deleteTaxonomyTerm(blog, taxonomy, value string) error {
+	_, err := db.exec(
+		"delete from taxonomy_terms where blog = @blog and taxonomy = @tax and lowerx(value) = lowerx(@value)",
+		sql.Named("blog", blog), sql.Named("tax", taxonomy), sql.Named("value", value),
+	)
+	return err
+}
+
+func findTaxonomyTerm(terms []*taxonomyTerm, value string) *taxonomyTerm {
+	for _, t := range terms {
+		if strings.EqualFold(t.Value, value) {
+			return t
+		}
+	}
+	return nil
+}
+
+func taxonomyTermChildren(terms []*taxonomyTerm, value string) (children []*taxonomyTerm) {
+	for _, t := range terms {
+		if t.Parent != "" && strings.EqualFold(t.Parent, value) {
+			children = append(children, t)
+		}
+	}
+	return children
+}
+
+// Values of all child terms, their children and so on
+func taxonomyTermDescendants(terms []*taxonomyTerm, value string) (descendants []string) {
+	queue := []string{value}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, child := range taxonomyTermChildren(terms, current) {
+			if strings.EqualFold(child.Value, value) || lo.ContainsBy(descendants, func(v string) bool { return strings.EqualFold(v, child.Value) }) {
+				// Cycle
+				continue
+			}
+			descendants = append(descendants, child.Value)
+			queue = append(queue, child.Value)
+		}
+	}
+	return descendants
+}
+
+// Values of the parent term, its parent and so on
+func taxonomyTermAncestors(terms []*taxonomyTerm, value string) (ancestors []string) {
+	for t := findTaxonomyTerm(terms, value); t != nil && t.Parent != ""; t = findTaxonomyTerm(terms, t.Parent) {
+		if strings.EqualFold(t.Parent, value) || lo.ContainsBy(ancestors, func(v string) bool { return strings.EqualFold(v, t.Parent) }) {
+			// Cycle
+			break
+		}
+		ancestors = append(ancestors, t.Parent)
+	}
+	return ancestors
+}
+
+type taxonomyTermRenderData struct {
+	image    string
+	parent   *taxonomyTermLink
+	children []*taxonomyTermLink
+}
+
+type taxonomyTermLink struct {
+	value, path string
+}
+
+func (a *goBlog) getTaxonomyTermRenderData(bc *configBlog, ic *indexConfig) *taxonomyTermRenderData {
+	if ic.tax == nil {
+		return nil
+	}
+	link := func(value string) *taxonomyTermLink {
+		return &taxonomyTermLink{value: value, path: bc.getRelativePath(fmt.Sprintf("/%s/%s", ic.tax.Name, urlize(value)))}
+	}
+	td := &taxonomyTermRenderData{}
+	if ic.taxTerm != nil {
+		td.image = ic.taxTerm.Image
+		if ic.taxTerm.Parent != "" {
+			td.parent = link(ic.taxTerm.Parent)
+		}
+	}
+	for _, child := range ic.taxChildren {
+		td.children = append(td.children, link(child.Value))
+	}
+	if td.image == "" && td.parent == nil && len(td.children) == 0 {
+		return nil
+	}
+	return td
+}
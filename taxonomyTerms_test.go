@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/carlmjohnson/requests"
+	"github.com/mmcdole/gofeed"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.goblog.app/app/pkgs/contenttype"
+)
+
+func Test_taxonomyTerms(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	_ = app.initConfig()
+	_ = app.initDatabase(false)
+	defer app.db.close()
+	app.initComponents(false)
+	app.d = app.buildRouter()
+	handlerClient := newHandlerClient(app.d)
+
+	// Europe > Germany > Berlin
+	require.NoError(t, app.db.saveTaxonomyTerm("default", "tags", &taxonomyTerm{Value: "Europe", Description: "All about *Europe*", Image: "https://example.com/europe.jpg"}))
+	require.NoError(t, app.db.saveTaxonomyTerm("default", "tags", &taxonomyTerm{Value: "Germany", Parent: "Europe"}))
+	require.NoError(t, app.db.saveTaxonomyTerm("default", "tags", &taxonomyTerm{Value: "Berlin", Parent: "germany"}))
+
+	// No cycles
+	assert.Error(t, app.db.saveTaxonomyTerm("default", "tags", &taxonomyTerm{Value: "Europe", Parent: "Berlin"}))
+	assert.Error(t, app.db.saveTaxonomyTerm("default", "tags", &taxonomyTerm{Value: "Europe", Parent: "Europe"}))
+
+	// Case insensitive update
+	require.NoError(t, app.db.saveTaxonomyTerm("default", "tags", &taxonomyTerm{Value: "berlin", Parent: "Germany", Description: "Capital"}))
+
+	terms, err := app.db.getTaxonomyTerms("default", "tags")
+	require.NoError(t, err)
+	require.Len(t, terms, 3)
+	assert.Equal(t, []string{"Germany", "berlin"}, taxonomyTermDescendants(terms, "europe"))
+	assert.Equal(t, []string{"Germany", "Europe"}, taxonomyTermAncestors(terms, "Berlin"))
+	assert.Empty(t, taxonomyTermAncestors(terms, "Europe"))
+
+	for _, p := range []*post{
+		{Path: "/1", Published: "2021-01-01T00:00:00Z", Parameters: map[string][]string{"tags": {"Berlin"}}},
+		{Path: "/2", Published: "2021-02-01T00:00:00Z", Parameters: map[string][]string{"tags": {"Germany"}}},
+		{Path: "/3", Published: "2021-03-01T00:00:00Z", Parameters: map[string][]string{"tags": {"Asia"}}},
+	} {
+		p.Section = "posts"
+		p.Status = statusPublished
+		p.Content = "Test"
+		require.NoError(t, app.createPost(p))
+	}
+
+	getFeedPaths := func(path string) []string {
+		var feed *gofeed.Feed
+		err := requests.URL("http://localhost:8080" + path + ".json").Client(handlerClient).
+			Handle(func(r *http.Response) (err error) {
+				defer r.Body.Close()
+				feed, err = gofeed.NewParser().Parse(r.Body)
+				return
+			}).
+			Fetch(context.Background())
+		require.NoError(t, err)
+		var paths []string
+		for _, item := range feed.Items {
+			paths = append(paths, item.GUID)
+		}
+		return paths
+	}
+
+	// Posts of child terms are listed under the parent terms
+	assert.ElementsMatch(t, []string{"/1", "/2"}, getFeedPaths("/tags/europe"))
+	assert.ElementsMatch(t, []string{"/1", "/2"}, getFeedPaths("/tags/germany"))
+	assert.ElementsMatch(t, []string{"/1"}, getFeedPaths("/tags/berlin"))
+
+	// Term page without posts of its own
+	var html string
+	err = requests.URL("http://localhost:8080/tags/europe").Client(handlerClient).ToString(&html).Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, html, "<em>Europe</em>")
+	assert.Contains(t, html, `src=https://example.com/europe.jpg`)
+	assert.Contains(t, html, `Sub-terms: <a href=/tags/germany>Germany</a>`)
+
+	err = requests.URL("http://localhost:8080/tags/berlin").Client(handlerClient).ToString(&html).Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, html, "Capital")
+	assert.Contains(t, html, `Parent term: <a href=/tags/germany>Germany</a>`)
+
+	// Overview lists all terms
+	err = requests.URL("http://localhost:8080/tags").Client(handlerClient).ToString(&html).Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, html, `<a href=/tags/europe>Europe</a>`)
+
+	// Index pages for WebSub
+	p, err := app.getPost("/1")
+	require.NoError(t, err)
+	assert.Subset(t, app.postIndexPaths(p), []string{"/tags/berlin", "/tags/germany", "/tags/europe"})
+
+	// Editor
+	editorRequest := func(method, target string, body io.Reader) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, body)
+		if body != nil {
+			req.Header.Set(contentType, contenttype.WWWForm)
+		}
+		req = req.WithContext(context.WithValue(req.Context(), blogKey, "default"))
+		rec := httptest.NewRecorder()
+		switch target {
+		case "/editor/taxonomies":
+			app.serveEditorTaxonomies(rec, req)
+		case "/editor/taxonomies/save":
+			app.serveEditorTaxonomiesSave(rec, req)
+		}
+		return rec
+	}
+	rec := editorRequest(http.MethodGet, "/editor/taxonomies", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `id=tags-asia`)
+	assert.Contains(t, rec.Body.String(), `id=tags-germany`)
+	rec = editorRequest(http.MethodPost, "/editor/taxonomies/save", strings.NewReader(url.Values{
+		"taxonomy": {"tags"}, "value": {"Asia"}, "description": {"Far away"},
+	}.Encode()))
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Equal(t, "/editor/taxonomies#tags-asia", rec.Header().Get("Location"))
+	terms, err = app.db.getTaxonomyTerms("default", "tags")
+	require.NoError(t, err)
+	assert.Equal(t, "Far away", findTaxonomyTerm(terms, "asia").Description)
+	rec = editorRequest(http.MethodPost, "/editor/taxonomies/save", strings.NewReader(url.Values{
+		"taxonomy": {"unknown"}, "value": {"Asia"},
+	}.Encode()))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	// Delete
+	require.NoError(t, app.db.deleteTaxonomyTerm("default", "tags", "GERMANY"))
+	terms, err = app.db.getTaxonomyTerms("default", "tags")
+	require.NoError(t, err)
+	assert.Len(t, terms, 3)
+	assert.ElementsMatch(t, []string{"/1"}, getFeedPaths("/tags/berlin"))
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"go.goblog.app/app/pkgs/bufferpool"
+)
+
+// Number of posts of the blog per taxonomy value
+func (db *database) countTaxonomyValues(blog, taxonomy string) (map[string]int, error) {
+	rows, err := db.query(
+		"select value, count(distinct path) from post_parameters where parameter = @tax and length(coalesce(value, '')) > 0 and path in (select path from posts where blog = @blog) group by value",
+		sql.Named("tax", taxonomy), sql.Named("blog", blog),
+	)
+	if err != nil {
+		return nil, err
+	}
+	counts := map[string]int{}
+	var value string
+	var count int
+	for rows.Next() {
+		if err = rows.Scan(&value, &count); err != nil {
+			return nil, err
+		}
+		counts[value] = count
+	}
+	return counts, rows.Err()
+}
+
+// Paths of the posts of the blog with the taxonomy value
+func (db *database) taxonomyValuePaths(blog, taxonomy, value string) ([]string, error) {
+	rows, err := db.query(
+		"select distinct path from post_parameters where parameter = @tax and lowerx(value) = lowerx(@value) and path in (select path from posts where blog = @blog)",
+		sql.Named("tax", taxonomy), sql.Named("value", value), sql.Named("blog", blog),
+	)
+	if err != nil {
+		return nil, err
+	}
+	paths := []string{}
+	var path string
+	for rows.Next() {
+		if err = rows.Scan(&path); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, rows.Err()
+}
+
+// Rename the taxonomy value of all posts of the blog, or merge it into an existing value.
+// The term metadata is moved too and the old term URL redirects to the new one.
+func (a *goBlog) renameTaxonomyValue(blog, taxonomy, oldValue, newValue string, hooks bool) error {
+	oldValue, newValue = strings.TrimSpace(oldValue), strings.TrimSpace(newValue)
+	if oldValue == "" || newValue == "" {
+		return errors.New("old and new value required")
+	}
+	if oldValue == newValue {
+		return nil
+	}
+	// Lock post creation
+	a.db.pcm.Lock()
+	defer a.db.pcm.Unlock()
+	paths, err := a.db.taxonomyValuePaths(blog, taxonomy, oldValue)
+	if err != nil {
+		return err
+	}
+	terms, err := a.db.getTaxonomyTerms(blog, taxonomy)
+	if err != nil {
+		return err
+	}
+	sameValue := strings.EqualFold(oldValue, newValue)
+	sqlBuilder := bufferpool.Get()
+	defer bufferpool.Put(sqlBuilder)
+	sqlArgs := []any{dbNoCache}
+	sqlBuilder.WriteString("begin;")
+	// Posts
+	if !sameValue {
+		// Remove the old value from posts that already have the new one
+		sqlBuilder.WriteString("delete from post_parameters where parameter = ? and lowerx(value) = lowerx(?) and path in (select path from posts where blog = ?) and path in (select path from post_parameters where parameter = ? and lowerx(value) = lowerx(?));")
+		sqlArgs = append(sqlArgs, taxonomy, oldValue, blog, taxonomy, newValue)
+	}
+	sqlBuilder.WriteString("update post_parameters set value = ? where parameter = ? and lowerx(value) = lowerx(?) and path in (select path from posts where blog = ?);")
+	sqlArgs = append(sqlArgs, newValue, taxonomy, oldValue, blog)
+	// Term metadata, the metadata of an existing new term is kept
+	if sameValue || findTaxonomyTerm(terms, newValue) == nil {
+		sqlBuilder.WriteString("update taxonomy_terms set value = ? where blog = ? and taxonomy = ? and lowerx(value) = lowerx(?);")
+		sqlArgs = append(sqlArgs, newValue, blog, taxonomy, oldValue)
+	} else {
+		sqlBuilder.WriteString("delete from taxonomy_terms where blog = ? and taxonomy = ? and lowerx(value) = lowerx(?);")
+		sqlArgs = append(sqlArgs, blog, taxonomy, oldValue)
+	}
+	sqlBuilder.WriteString("update taxonomy_terms set parent = ? where blog = ? and taxonomy = ? and lowerx(parent) = lowerx(?);")
+	sqlArgs = append(sqlArgs, newValue, blog, taxonomy, oldValue)
+	sqlBuilder.WriteString("update taxonomy_terms set parent = '' where blog = ? and taxonomy = ? and lowerx(parent) = lowerx(value);")
+	sqlArgs = append(sqlArgs, blog, taxonomy)
+	// Redirects
+	sqlBuilder.WriteString("update taxonomy_redirects set target = ? where blog = ? and taxonomy = ? and lowerx(target) = lowerx(?);")
+	sqlArgs = append(sqlArgs, newValue, blog, taxonomy, oldValue)
+	if oldPath, newPath := urlize(oldValue), urlize(newValue); oldPath != newPath {
+		sqlBuilder.WriteString("insert or replace into taxonomy_redirects (blog, taxonomy, value, target) values (?, ?, ?, ?);")
+		sqlArgs = append(sqlArgs, blog, taxonomy, oldPath, newValue)
+		sqlBuilder.WriteString("delete from taxonomy_redirects where blog = ? and taxonomy = ? and value = ?;")
+		sqlArgs = append(sqlArgs, blog, taxonomy, newPath)
+	}
+	sqlBuilder.WriteString("commit;")
+	if _, err = a.db.exec(sqlBuilder.String(), sqlArgs...); err != nil {
+		return err
+	}
+	a.taxonomyValuesChanged(blog, paths, hooks)
+	return nil
+}
+
+// Remove the taxonomy value from all posts of the blog
+func (a *goBlog) deleteTaxonomyValue(blog, taxonomy, value string, hooks bool) error {
+	if value == "" {
+		return errors.New("value required")
+	}
+	// Lock post creation
+	a.db.pcm.Lock()
+	defer a.db.pcm.Unlock()
+	paths, err := a.db.taxonomyValuePaths(blog, taxonomy, value)
+	if err != nil {
+		return err
+	}
+	// Child terms get the parent of the deleted term
+	_, err = a.db.exec(
+		`begin;
+		delete from post_parameters where parameter = ? and lowerx(value) = lowerx(?) and path in (select path from posts where blog = ?);
+		update taxonomy_terms set parent = coalesce((select parent from taxonomy_terms where blog = ? and taxonomy = ? and lowerx(value) = lowerx(?)), '') where blog = ? and taxonomy = ? and lowerx(parent) = lowerx(?);
+		delete from taxonomy_terms where blog = ? and taxonomy = ? and lowerx(value) = lowerx(?);
+		delete from taxonomy_redirects where blog = ? and taxonomy = ? and lowerx(target) = lowerx(?);
+		commit;`,
+		dbNoCache,
+		taxonomy, value, blog,
+		blog, taxonomy, value, blog, taxonomy, value,
+		blog, taxonomy, value,
+		blog, taxonomy, value,
+	)
+	if err != nil {
+		return err
+	}
+	a.taxonomyValuesChanged(blog, paths, hooks)
+	return nil
+}
+
+func (a *goBlog) taxonomyValuesChanged(blog string, paths []string, hooks bool) {
+	a.db.rebuildFTSIndex()
+	a.db.resetRelatedPosts(blog)
+	a.cache.purge()
+	if !hooks {
+		// Changing taxonomy values shouldn't notify followers etc. about every post by default
+		return
+	}
+	for _, path := range paths {
+		if p, err := a.getPost(path); err == nil && (p.Status == statusPublished || p.Status == statusUnlisted) {
+			a.postUpdateHooks(p)
+		}
+	}
+}
+
+// Get the new value of a renamed taxonomy value
+func (db *database) taxonomyRedirect(blog, taxonomy, urlValue string) (string, error) {
+	row, err := db.queryRow(
+		"select target from taxonomy_redirects where blog = @blog and taxonomy = @tax and value = @value",
+		sql.Named("blog", blog), sql.Named("tax", taxonomy), sql.Named("value", urlValue),
+	)
+	if err != nil {
+		return "", err
+	}
+	var target string
+	if err = row.Scan(&target); errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	return target, err
+}
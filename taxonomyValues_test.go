@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/requests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_taxonomyValues(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	_ = app.initConfig()
+	_ = app.initDatabase(false)
+	defer app.db.close()
+	app.initComponents(false)
+	app.d = app.buildRouter()
+	handlerClient := newHandlerClient(app.d)
+
+	for _, p := range []*post{
+		{Path: "/1", Parameters: map[string][]string{"tags": {"golang", "Web"}}},
+		{Path: "/2", Parameters: map[string][]string{"tags": {"golang", "Go"}}},
+		{Path: "/3", Parameters: map[string][]string{"tags": {"Go", "Typo"}}},
+	} {
+		p.Section = "posts"
+		p.Status = statusPublished
+		p.Published = "2021-01-01T00:00:00Z"
+		p.Content = "Test"
+		require.NoError(t, app.createPost(p))
+	}
+	require.NoError(t, app.db.saveTaxonomyTerm("default", "tags", &taxonomyTerm{Value: "golang", Description: "Old"}))
+	require.NoError(t, app.db.saveTaxonomyTerm("default", "tags", &taxonomyTerm{Value: "Generics", Parent: "golang"}))
+
+	counts, err := app.db.countTaxonomyValues("default", "tags")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"golang": 2, "Go": 2, "Web": 1, "Typo": 1}, counts)
+
+	// Track update hooks
+	var hookMu sync.Mutex
+	hookPaths := []string{}
+	app.pUpdateHooks = append(app.pUpdateHooks, func(p *post) {
+		hookMu.Lock()
+		hookPaths = append(hookPaths, p.Path)
+		hookMu.Unlock()
+	})
+
+	// Merge "golang" into "Go" without hooks
+	require.NoError(t, app.renameTaxonomyValue("default", "tags", "golang", "Go", false))
+	for path, tags := range map[string][]string{"/1": {"Go", "Web"}, "/2": {"Go"}, "/3": {"Go", "Typo"}} {
+		p, err := app.getPost(path)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, tags, p.Parameters["tags"], path)
+	}
+	terms, err := app.db.getTaxonomyTerms("default", "tags")
+	require.NoError(t, err)
+	require.NotNil(t, findTaxonomyTerm(terms, "Go"))
+	assert.Equal(t, "Old", findTaxonomyTerm(terms, "Go").Description)
+	assert.Equal(t, "Go", findTaxonomyTerm(terms, "Generics").Parent)
+
+	// Old term URLs redirect
+	rec := httptest.NewRecorder()
+	app.d.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://localhost:8080/tags/golang.rss", nil))
+	assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+	assert.Equal(t, "/tags/go.rss", rec.Header().Get("Location"))
+	var html string
+	err = requests.URL("http://localhost:8080/tags/golang").Client(handlerClient).ToString(&html).Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, html, "Tags: Go")
+
+	// Rename with hooks, existing redirects are updated
+	require.NoError(t, app.renameTaxonomyValue("default", "tags", "Go", "Golang", true))
+	target, err := app.db.taxonomyRedirect("default", "tags", "golang")
+	require.NoError(t, err)
+	assert.Equal(t, "", target) // Valid value again
+	target, err = app.db.taxonomyRedirect("default", "tags", "go")
+	require.NoError(t, err)
+	assert.Equal(t, "Golang", target)
+	assert.Eventually(t, func() bool {
+		hookMu.Lock()
+		defer hookMu.Unlock()
+		return assert.ObjectsAreEqual(3, len(hookPaths))
+	}, time.Second, 10*time.Millisecond)
+
+	// Delete
+	require.NoError(t, app.deleteTaxonomyValue("default", "tags", "typo", false))
+	p, err := app.getPost("/3")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Golang"}, p.Parameters["tags"])
+	require.NoError(t, app.deleteTaxonomyValue("default", "tags", "Golang", false))
+	target, err = app.db.taxonomyRedirect("default", "tags", "go")
+	require.NoError(t, err)
+	assert.Equal(t, "", target)
+	terms, err = app.db.getTaxonomyTerms("default", "tags")
+	require.NoError(t, err)
+	assert.Equal(t, "", findTaxonomyTerm(terms, "Generics").Parent)
+}
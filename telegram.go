@@ -26,7 +26,7 @@ func (tg *configTelegram) enabled() bool {
 
 func (a *goBlog) tgPost(silent bool) func(*post) {
 	return func(p *post) {
-		if tg := a.cfg.Blogs[p.Blog].Telegram; tg.enabled() && p.isPublishedSectionPost() {
+		if tg := a.cfg.Blogs[p.Blog].Telegram; tg.enabled() && p.isPublishedSectionPost() && a.syndicateTo(p, syndicationTelegram) {
 			tgChat := p.firstParameter("telegramchat")
 			tgMsg := p.firstParameter("telegrammsg")
 			if tgChat != "" && tgMsg != "" {
@@ -57,6 +57,8 @@ func (a *goBlog) tgPost(silent bool) func(*post) {
 			if err != nil {
 				log.Printf("Failed to save Telegram message id: %v", err)
 			}
+			// Save link to message as syndication link
+			a.addSyndicationLink(p, tg.messageLink(chatId, msgId))
 		}
 	}
 }
@@ -128,6 +130,7 @@ func (a *goBlog) tgDelete(p *post) {
 		if err != nil {
 			log.Printf("Failed to remove Telegram message id: %v", err)
 		}
+		a.removeSyndicationLink(p, tg.messageLink(chatId, messageId))
 	}
 }
 
@@ -44,6 +44,17 @@ func Test_configTelegram_generateHTML(t *testing.T) {
 	}
 }
 
+func Test_configTelegram_messageLink(t *testing.T) {
+	tg := &configTelegram{Enabled: true, ChatID: "@channel", BotToken: "abc"}
+	assert.Equal(t, "https://t.me/channel/123", tg.messageLink(-1001234, 123))
+
+	tg.ChatID = "-1001234"
+	assert.Equal(t, "https://t.me/c/1234/123", tg.messageLink(-1001234, 123))
+
+	assert.Equal(t, "", tg.messageLink(789, 123))
+	assert.Equal(t, "", tg.messageLink(-1001234, 0))
+}
+
 func Test_configTelegram_send(t *testing.T) {
 	fakeClient := newFakeHttpClient()
 
@@ -148,6 +159,45 @@ func Test_telegram(t *testing.T) {
 		assert.Equal(t, "Title\n\n<a href=\"http://localhost:8080/s/1\">http://localhost:8080/s/1</a>", req.FormValue("text"))
 	})
 
+	t.Run("Telegram not selected", func(t *testing.T) {
+		fakeClient := newFakeHttpClient()
+
+		cfg := createDefaultTestConfig(t)
+		cfg.Blogs = map[string]*configBlog{
+			"en": createDefaultBlog(),
+		}
+		cfg.Blogs["en"].Telegram = &configTelegram{
+			Enabled:  true,
+			ChatID:   "chatid",
+			BotToken: "bottoken",
+		}
+
+		app := &goBlog{
+			cfg:        cfg,
+			httpClient: fakeClient.Client,
+		}
+		_ = app.initConfig()
+		_ = app.initDatabase(false)
+		defer app.db.close()
+
+		app.initMarkdown()
+		app.initTelegram()
+
+		app.pPostHooks[0](&post{
+			Path:          "/test",
+			RenderedTitle: "Title",
+			Published:     time.Now().String(),
+			Section:       "test",
+			Blog:          "en",
+			Status:        statusPublished,
+			Parameters: map[string][]string{
+				syndicateToParam: {syndicationNone},
+			},
+		})
+
+		assert.Nil(t, fakeClient.req)
+	})
+
 	t.Run("Telegram disabled", func(t *testing.T) {
 		fakeClient := newFakeHttpClient()
 
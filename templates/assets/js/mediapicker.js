@@ -0,0 +1,14 @@
+(function () {
+    let pickerBtn = document.querySelector('#mediapickerbtn')
+    if (!pickerBtn) return
+    let picker = document.querySelector('#mediapicker')
+    let target = document.getElementById(picker.dataset.target)
+    function insert() {
+        let start = target.selectionStart, end = target.selectionEnd
+        target.value = target.value.substring(0, start) + picker.value + target.value.substring(end)
+        target.selectionStart = target.selectionEnd = start + picker.value.length
+        target.focus()
+        target.dispatchEvent(new Event('input'))
+    }
+    pickerBtn.addEventListener('click', insert)
+})()
@@ -0,0 +1,12 @@
+(function () {
+    // Navigate to the previous or next post using the keys "p" and "n"
+    document.addEventListener('keyup', function (e) {
+        if (e.altKey || e.ctrlKey || e.metaKey || e.shiftKey) return
+        let target = e.target
+        if (target.isContentEditable || ['INPUT', 'TEXTAREA', 'SELECT'].includes(target.tagName)) return
+        let rel = { p: 'prev', n: 'next' }[e.key]
+        if (!rel) return
+        let link = document.querySelector('.post-navigation a[rel=' + rel + ']')
+        if (link) window.location.href = link.href
+    })
+})()
@@ -1,21 +1,16 @@
 package main
 
 import (
-	"context"
 	"crypto/sha256"
-	"encoding/base64"
 	"errors"
 	"fmt"
-	"html"
 	"io"
 	"log"
-	"net/http"
 	"net/url"
 	"path"
 	"strings"
 	"sync"
 
-	"github.com/carlmjohnson/requests"
 	"go.goblog.app/app/pkgs/bufferpool"
 	"go.goblog.app/app/pkgs/mp3merge"
 )
@@ -53,9 +48,8 @@ func (a *goBlog) initTTS() {
 }
 
 func (a *goBlog) ttsEnabled() bool {
-	tts := a.cfg.TTS
 	// Requires media storage as well
-	return tts != nil && tts.Enabled && tts.GoogleAPIKey != "" && a.mediaStorageEnabled()
+	return a.ttsProvider() != nil && a.mediaStorageEnabled()
 }
 
 func (a *goBlog) createPostTTSAudio(p *post) error {
@@ -87,10 +81,8 @@ func (a *goBlog) createPostTTSAudio(p *post) error {
 		wg.Add(1)
 		go func(i int, part string) {
 			defer wg.Done()
-			// Build SSML
-			ssml := "<speak>" + html.EscapeString(part) + "<break time=\"500ms\"/></speak>"
 			// Create TTS audio
-			err := a.createTTSAudio(lang, ssml, partWriters[i])
+			err := a.createTTSAudio(lang, part, partWriters[i])
 			if err != nil {
 				errs[i] = err
 				return
@@ -173,62 +165,23 @@ func (a *goBlog) deletePostTTSAudio(p *post) bool {
 	return true
 }
 
-func (a *goBlog) createTTSAudio(lang, ssml string, w io.Writer) error {
-	// Check if Google Cloud TTS is enabled
-	gctts := a.cfg.TTS
-	if !gctts.Enabled || gctts.GoogleAPIKey == "" {
-		return errors.New("missing config for Google Cloud TTS")
+func (a *goBlog) createTTSAudio(lang, text string, w io.Writer) error {
+	// Check if TTS is configured
+	provider := a.ttsProvider()
+	if provider == nil {
+		return errors.New("missing config for TTS")
 	}
 
 	// Check parameters
 	if lang == "" {
 		return errors.New("language not provided")
 	}
-	if ssml == "" {
+	if text == "" {
 		return errors.New("empty text")
 	}
 	if w == nil {
 		return errors.New("writer not provided")
 	}
 
-	// Create request body
-	body := map[string]any{
-		"audioConfig": map[string]any{
-			"audioEncoding": "MP3",
-		},
-		"input": map[string]any{
-			"ssml": ssml,
-		},
-		"voice": map[string]any{
-			"languageCode": lang,
-		},
-	}
-
-	// Do request
-	var response map[string]any
-	err := requests.
-		URL("https://texttospeech.googleapis.com/v1beta1/text:synthesize").
-		Param("key", gctts.GoogleAPIKey).
-		Client(a.httpClient).
-		UserAgent(appUserAgent).
-		Method(http.MethodPost).
-		BodyJSON(body).
-		ToJSON(&response).
-		Fetch(context.Background())
-	if err != nil {
-		return errors.New("tts request failed: " + err.Error())
-	}
-
-	// Decode response
-	if encoded, ok := response["audioContent"]; ok {
-		if encodedStr, ok := encoded.(string); ok {
-			if audio, err := base64.StdEncoding.DecodeString(encodedStr); err == nil {
-				_, err := w.Write(audio)
-				return err
-			} else {
-				return err
-			}
-		}
-	}
-	return errors.New("no audio content")
+	return provider.synthesize(lang, text, w)
 }
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"html"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/carlmjohnson/requests"
+	"go.goblog.app/app/pkgs/bufferpool"
+)
+
+const (
+	ttsProviderGoogle  = "google"
+	ttsProviderCommand = "command"
+	ttsProviderOpenAI  = "openai"
+
+	defaultTTSOpenAIURL   = "https://api.openai.com/v1"
+	defaultTTSOpenAIModel = "tts-1"
+	defaultTTSOpenAIVoice = "alloy"
+)
+
+type ttsProvider interface {
+	// Write the MP3 audio of the text to w
+	synthesize(lang, text string, w io.Writer) error
+}
+
+// Get the configured TTS provider, nil if TTS isn't configured
+func (a *goBlog) ttsProvider() ttsProvider {
+	tts := a.cfg.TTS
+	if tts == nil || !tts.Enabled {
+		return nil
+	}
+	provider := tts.Provider
+	if provider == "" {
+		// Detect provider from the configured values
+		switch {
+		case tts.GoogleAPIKey != "":
+			provider = ttsProviderGoogle
+		case tts.Command != "":
+			provider = ttsProviderCommand
+		case tts.OpenAIURL != "" || tts.OpenAIKey != "":
+			provider = ttsProviderOpenAI
+		}
+	}
+	switch provider {
+	case ttsProviderGoogle:
+		if tts.GoogleAPIKey != "" {
+			return &googleTTS{key: tts.GoogleAPIKey, client: a.httpClient}
+		}
+	case ttsProviderCommand:
+		if tts.Command != "" {
+			return &commandTTS{
+				command: tts.Command,
+				shell:   defaultIfEmpty(tts.Shell, "/bin/sh"),
+				format:  defaultIfEmpty(tts.CommandFormat, "wav"),
+				ffmpeg:  defaultIfEmpty(tts.FFmpeg, "ffmpeg"),
+			}
+		}
+	case ttsProviderOpenAI:
+		return &openAITTS{
+			url:    strings.TrimSuffix(defaultIfEmpty(tts.OpenAIURL, defaultTTSOpenAIURL), "/"),
+			key:    tts.OpenAIKey,
+			model:  defaultIfEmpty(tts.OpenAIModel, defaultTTSOpenAIModel),
+			voice:  defaultIfEmpty(tts.OpenAIVoice, defaultTTSOpenAIVoice),
+			client: a.httpClient,
+		}
+	}
+	return nil
+}
+
+// Google Cloud Text-to-Speech
+
+type googleTTS struct {
+	key    string
+	client *http.Client
+}
+
+func (g *googleTTS) synthesize(lang, text string, w io.Writer) error {
+	// Build SSML
+	ssml := "<speak>" + html.EscapeString(text) + "<break time=\"500ms\"/></speak>"
+	// Create request body
+	body := map[string]any{
+		"audioConfig": map[string]any{
+			"audioEncoding": "MP3",
+		},
+		"input": map[string]any{
+			"ssml": ssml,
+		},
+		"voice": map[string]any{
+			"languageCode": lang,
+		},
+	}
+	// Do request
+	var response map[string]any
+	err := requests.
+		URL("https://texttospeech.googleapis.com/v1beta1/text:synthesize").
+		Param("key", g.key).
+		Client(g.client).
+		UserAgent(appUserAgent).
+		Method(http.MethodPost).
+		BodyJSON(body).
+		ToJSON(&response).
+		Fetch(context.Background())
+	if err != nil {
+		return errors.New("tts request failed: " + err.Error())
+	}
+	// Decode response
+	if encoded, ok := response["audioContent"]; ok {
+		if encodedStr, ok := encoded.(string); ok {
+			audio, err := base64.StdEncoding.DecodeString(encodedStr)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(audio)
+			return err
+		}
+	}
+	return errors.New("no audio content")
+}
+
+// Local command like piper or espeak, gets the text on stdin and writes to the output file
+
+type commandTTS struct {
+	command string // text/template with .Lang and .Output
+	shell   string
+	format  string // wav or mp3
+	ffmpeg  string // to convert wav to mp3
+}
+
+func (c *commandTTS) synthesize(lang, text string, w io.Writer) error {
+	dir, err := os.MkdirTemp("", "goblog-tts-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	output := filepath.Join(dir, "speech."+c.format)
+	cmdTmpl, err := template.New("tts").Parse(c.command)
+	if err != nil {
+		return err
+	}
+	cmdBuf := bufferpool.Get()
+	defer bufferpool.Put(cmdBuf)
+	if err = cmdTmpl.Execute(cmdBuf, map[string]string{"Lang": lang, "Output": output}); err != nil {
+		return err
+	}
+	cmd := exec.Command(c.shell, "-c", cmdBuf.String())
+	cmd.Stdin = strings.NewReader(text)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return errors.New("tts command failed: " + err.Error() + ": " + strings.TrimSpace(string(out)))
+	}
+	if c.format != "mp3" {
+		// Convert to MP3, so the parts can be merged
+		mp3 := filepath.Join(dir, "speech.mp3")
+		cmd = exec.Command(c.ffmpeg, "-hide_banner", "-loglevel", "error", "-y", "-i", output, "-codec:a", "libmp3lame", "-q:a", "4", mp3)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return errors.New("tts conversion failed: " + err.Error() + ": " + strings.TrimSpace(string(out)))
+		}
+		output = mp3
+	}
+	audio, err := os.ReadFile(output)
+	if err != nil {
+		return err
+	}
+	if len(audio) == 0 {
+		return errors.New("no audio content")
+	}
+	_, err = io.Copy(w, bytes.NewReader(audio))
+	return err
+}
+
+// OpenAI-compatible speech API
+
+type openAITTS struct {
+	url    string
+	key    string // optional for local servers
+	model  string
+	voice  string
+	client *http.Client
+}
+
+func (o *openAITTS) synthesize(_, text string, w io.Writer) error {
+	rb := requests.URL(o.url + "/audio/speech").
+		Client(o.client).
+		UserAgent(appUserAgent).
+		Method(http.MethodPost).
+		BodyJSON(map[string]any{
+			"model":           o.model,
+			"voice":           o.voice,
+			"input":           text,
+			"response_format": "mp3",
+		}).
+		ToWriter(w)
+	if o.key != "" {
+		rb.Bearer(o.key)
+	}
+	if err := rb.Fetch(context.Background()); err != nil {
+		return errors.New("tts request failed: " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// MP3 with silent MPEG-1 Layer III frames (128 kbps, 44.1 kHz)
+func testMP3Frames(count int) []byte {
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x64})
+	return bytes.Repeat(frame, count)
+}
+
+func Test_ttsProvider(t *testing.T) {
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+
+	assert.Nil(t, app.ttsProvider())
+
+	app.cfg.TTS = &configTTS{Enabled: true}
+	assert.Nil(t, app.ttsProvider())
+
+	app.cfg.TTS.GoogleAPIKey = "key"
+	assert.IsType(t, &googleTTS{}, app.ttsProvider())
+
+	app.cfg.TTS.Command = "piper"
+	assert.IsType(t, &googleTTS{}, app.ttsProvider())
+
+	app.cfg.TTS.Provider = ttsProviderCommand
+	if assert.IsType(t, &commandTTS{}, app.ttsProvider()) {
+		c := app.ttsProvider().(*commandTTS)
+		assert.Equal(t, "wav", c.format)
+		assert.Equal(t, "ffmpeg", c.ffmpeg)
+	}
+
+	app.cfg.TTS.Provider = ttsProviderOpenAI
+	if assert.IsType(t, &openAITTS{}, app.ttsProvider()) {
+		o := app.ttsProvider().(*openAITTS)
+		assert.Equal(t, defaultTTSOpenAIURL, o.url)
+		assert.Equal(t, defaultTTSOpenAIModel, o.model)
+	}
+}
+
+func Test_commandTTS(t *testing.T) {
+	dir := t.TempDir()
+	frames := filepath.Join(dir, "frames.mp3")
+	require.NoError(t, os.WriteFile(frames, testMP3Frames(3), 0644))
+	texts := filepath.Join(dir, "texts.txt")
+	ffmpeg := filepath.Join(dir, "ffmpeg")
+	require.NoError(t, os.WriteFile(ffmpeg, []byte("#!/bin/sh\nfor last; do :; done\ncp "+frames+" \"$last\"\n"), 0755))
+
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	app.cfg.TTS = &configTTS{
+		Enabled:       true,
+		Command:       "cat >> " + texts + " && echo {{.Lang}} >> " + texts + " && cp " + frames + " {{.Output}}",
+		CommandFormat: "mp3",
+	}
+	mediaDir := t.TempDir()
+	app.mediaStorageInit.Do(func() {
+		app.mediaStorage = &localMediaStorage{path: mediaDir}
+	})
+	_ = app.initConfig()
+	_ = app.initDatabase(false)
+	defer app.db.close()
+	app.initComponents(false)
+
+	t.Run("Post audio", func(t *testing.T) {
+		require.True(t, app.ttsEnabled())
+		require.NoError(t, app.db.savePost(&post{
+			Path:    "/test/tts",
+			Content: "First paragraph\n\nSecond paragraph",
+			Blog:    "default",
+			Section: "posts",
+			Status:  statusPublished,
+		}, &postCreationOptions{new: true}))
+		p, err := app.getPost("/test/tts")
+		require.NoError(t, err)
+
+		require.NoError(t, app.createPostTTSAudio(p))
+
+		p, err = app.getPost("/test/tts")
+		require.NoError(t, err)
+		audio := p.firstParameter(ttsParameter)
+		require.NotEmpty(t, audio)
+		merged, err := os.ReadFile(filepath.Join(mediaDir, filepath.Base(audio)))
+		require.NoError(t, err)
+		// Frames of both parts
+		assert.Equal(t, 6, bytes.Count(merged, []byte{0xFF, 0xFB, 0x90, 0x64}))
+
+		spoken, err := os.ReadFile(texts)
+		require.NoError(t, err)
+		assert.Contains(t, string(spoken), "First paragraph")
+		assert.Contains(t, string(spoken), "Second paragraph")
+		assert.Contains(t, string(spoken), "en\n")
+	})
+
+	t.Run("WAV conversion", func(t *testing.T) {
+		c := &commandTTS{
+			command: "echo wav > {{.Output}}",
+			shell:   "/bin/sh",
+			format:  "wav",
+			ffmpeg:  ffmpeg,
+		}
+		buf := &bytes.Buffer{}
+		require.NoError(t, c.synthesize("en", "Test", buf))
+		assert.Equal(t, testMP3Frames(3), buf.Bytes())
+	})
+
+	t.Run("Failing command", func(t *testing.T) {
+		c := &commandTTS{command: "exit 1", shell: "/bin/sh", format: "mp3"}
+		assert.Error(t, c.synthesize("en", "Test", &bytes.Buffer{}))
+	})
+}
+
+func Test_openAITTS(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/audio/speech", r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		var body map[string]string
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "Hello", body["input"])
+		assert.Equal(t, "nova", body["voice"])
+		assert.Equal(t, "mp3", body["response_format"])
+		_, _ = w.Write(testMP3Frames(1))
+	}))
+	defer srv.Close()
+
+	app := &goBlog{
+		cfg:        createDefaultTestConfig(t),
+		httpClient: srv.Client(),
+	}
+	app.cfg.TTS = &configTTS{
+		Enabled:     true,
+		OpenAIURL:   srv.URL + "/v1/",
+		OpenAIKey:   "secret",
+		OpenAIVoice: "nova",
+	}
+
+	buf := &strings.Builder{}
+	require.NoError(t, app.createTTSAudio("en", "Hello", buf))
+	assert.Equal(t, string(testMP3Frames(1)), buf.String())
+
+	assert.Error(t, app.createTTSAudio("en", "", buf))
+}
@@ -2,8 +2,11 @@ package main
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/araddon/dateparse"
 	"github.com/hacdias/indieauth/v2"
 	"github.com/kaorimatz/go-opml"
 	"github.com/mergestat/timediff"
@@ -345,7 +348,11 @@ type indexRenderData struct {
 	posts              []*post
 	hasPrev, hasNext   bool
 	first, prev, next  string
+	query              string // Search filters
 	summaryTemplate    summaryTyp
+	search             *searchRenderData
+	taxonomy           *taxonomyTermRenderData
+	podcast            bool // Index has posts with audio
 }
 
 func (a *goBlog) renderIndex(hb *htmlBuilder, rd *renderData) {
@@ -365,11 +372,15 @@ func (a *goBlog) renderIndex(hb *htmlBuilder, rd *renderData) {
 				feedTitle = " (" + renderedIndexTitle + ")"
 			}
 			// RSS
-			hb.writeElementOpen("link", "rel", "alternate", "type", "application/rss+xml", "title", "RSS"+feedTitle, "href", a.getFullAddress(id.first+".rss"))
+			hb.writeElementOpen("link", "rel", "alternate", "type", "application/rss+xml", "title", "RSS"+feedTitle, "href", a.getFullAddress(id.first+".rss"+id.query))
 			// ATOM
-			hb.writeElementOpen("link", "rel", "alternate", "type", "application/atom+xml", "title", "ATOM"+feedTitle, "href", a.getFullAddress(id.first+".atom"))
+			hb.writeElementOpen("link", "rel", "alternate", "type", "application/atom+xml", "title", "ATOM"+feedTitle, "href", a.getFullAddress(id.first+".atom"+id.query))
 			// JSON Feed
-			hb.writeElementOpen("link", "rel", "alternate", "type", "application/feed+json", "title", "JSON Feed"+feedTitle, "href", a.getFullAddress(id.first+".json"))
+			hb.writeElementOpen("link", "rel", "alternate", "type", "application/feed+json", "title", "JSON Feed"+feedTitle, "href", a.getFullAddress(id.first+".json"+id.query))
+			// Podcast
+			if id.podcast {
+				hb.writeElementOpen("link", "rel", "alternate", "type", "application/rss+xml", "title", "Podcast"+feedTitle, "href", a.getFullAddress(id.first+".podcast"+id.query))
+			}
 		},
 		func(hb *htmlBuilder) {
 			hb.writeElementOpen("main", "class", "h-feed")
@@ -386,12 +397,25 @@ func (a *goBlog) renderIndex(hb *htmlBuilder, rd *renderData) {
 				titleOrDesc = true
 				_ = a.renderMarkdownToWriter(hb, id.description, false)
 			}
+			// Taxonomy term
+			if id.taxonomy != nil {
+				titleOrDesc = true
+				a.renderTaxonomyTerm(hb, rd.Blog, id.taxonomy)
+			}
 			if titleOrDesc {
 				hb.writeElementOpen("hr")
 			}
+			// Search facets
+			if id.search != nil {
+				a.renderSearchFacets(hb, rd.Blog, id.search)
+			}
 			if id.posts != nil && len(id.posts) > 0 {
 				// Posts
 				for _, p := range id.posts {
+					if id.search != nil {
+						a.renderSearchSummary(hb, rd.Blog, p, id.search.snippets[p.Path])
+						continue
+					}
 					a.renderSummary(hb, rd.Blog, p, id.summaryTemplate)
 				}
 			} else {
@@ -400,6 +424,10 @@ func (a *goBlog) renderIndex(hb *htmlBuilder, rd *renderData) {
 				hb.writeEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "noposts"))
 				hb.writeElementClose("p")
 			}
+			// Interactions matching the search
+			if id.search != nil {
+				a.renderSearchInteractions(hb, rd.Blog, id.search.interactions)
+			}
 			// Navigation
 			a.renderPagination(hb, rd.Blog, id.hasPrev, id.hasNext, id.prev, id.next)
 			// Author
@@ -838,11 +866,19 @@ func (a *goBlog) renderTaxonomy(hb *htmlBuilder, rd *renderData) {
 	)
 }
 
+type postRenderData struct {
+	post               *post
+	prevPath, nextPath string // Post navigation
+}
+
 func (a *goBlog) renderPost(hb *htmlBuilder, rd *renderData) {
-	p, ok := rd.Data.(*post)
+	prd, ok := rd.Data.(*postRenderData)
 	if !ok {
 		return
 	}
+	p := prd.post
+	series := a.postSeries(p)
+	prev, next := a.postNavigation(prd.prevPath, prd.nextPath)
 	a.renderBase(
 		hb, rd,
 		func(hb *htmlBuilder) {
@@ -852,6 +888,12 @@ func (a *goBlog) renderPost(hb *htmlBuilder, rd *renderData) {
 			if su := a.shortPostURL(p); su != "" {
 				hb.writeElementOpen("link", "rel", "shortlink", "href", su)
 			}
+			if prev != nil {
+				hb.writeElementOpen("link", "rel", "prev", "href", prev.Path)
+			}
+			if next != nil {
+				hb.writeElementOpen("link", "rel", "next", "href", next.Path)
+			}
 		},
 		func(hb *htmlBuilder) {
 			hb.writeElementOpen("main", "class", "h-entry")
@@ -863,6 +905,8 @@ func (a *goBlog) renderPost(hb *htmlBuilder, rd *renderData) {
 			a.renderPostTitle(hb, p)
 			// Post meta
 			a.renderPostMeta(hb, p, rd.Blog, "post")
+			// Series
+			a.renderPostSeries(hb, rd.Blog, series)
 			// Post actions
 			hb.writeElementOpen("div", "class", "actions")
 			// Share button
@@ -913,6 +957,12 @@ func (a *goBlog) renderPost(hb *htmlBuilder, rd *renderData) {
 			a.renderPostGPX(hb, p, rd.Blog)
 			// Taxonomies
 			a.renderPostTax(hb, p, rd.Blog)
+			// Series navigation
+			a.renderPostSeriesNavigation(hb, rd.Blog, series)
+			// Related posts
+			a.renderRelatedPosts(hb, rd.Blog, p)
+			// Previous and next post
+			a.renderPostNavigation(hb, rd.Blog, prev, next)
 			hb.writeElementClose("article")
 			// Author
 			a.renderAuthor(hb)
@@ -1069,8 +1119,9 @@ func (a *goBlog) renderIndieAuth(hb *htmlBuilder, rd *renderData) {
 }
 
 type editorFilesRenderData struct {
-	files []*mediaFile
-	uses  []int
+	files  []*mediaLibraryItem
+	tags   []string
+	filter *mediaLibraryFilter
 }
 
 func (a *goBlog) renderEditorFiles(hb *htmlBuilder, rd *renderData) {
@@ -1089,19 +1140,99 @@ func (a *goBlog) renderEditorFiles(hb *htmlBuilder, rd *renderData) {
 			hb.writeElementOpen("h1")
 			hb.writeEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "mediafiles"))
 			hb.writeElementClose("h1")
-			// Files
-			if len(ef.files) > 0 {
-				// Form
-				hb.writeElementOpen("form", "method", "post", "class", "fw p")
-				// Select with number of uses
-				hb.writeElementOpen("select", "name", "filename")
-				usesString := a.ts.GetTemplateStringVariant(rd.Blog.Lang, "fileuses")
-				for i, f := range ef.files {
-					hb.writeElementOpen("option", "value", f.Name)
-					hb.writeEscaped(fmt.Sprintf("%s (%s), %s, ~%d %s", f.Name, f.Time.Local().Format(isoDateFormat), mBytesString(f.Size), ef.uses[i], usesString))
+			// Search and filter
+			hb.writeElementOpen("form", "method", "get", "class", "fw p")
+			hb.writeElementOpen("input", "type", "text", "name", "q", "value", ef.filter.query, "placeholder", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "search"))
+			hb.writeElementOpen("select", "name", "type", "aria-label", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "filetype"))
+			for _, t := range []string{"", "image", "audio", "video", "other"} {
+				if t == ef.filter.typ {
+					hb.writeElementOpen("option", "value", t, "selected", "")
+				} else {
+					hb.writeElementOpen("option", "value", t)
+				}
+				hb.writeEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "mediatype"+defaultIfEmpty(t, "all")))
+				hb.writeElementClose("option")
+			}
+			hb.writeElementClose("select")
+			if len(ef.tags) > 0 {
+				hb.writeElementOpen("select", "name", "tag", "aria-label", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "mediatags"))
+				hb.writeElementOpen("option", "value", "")
+				hb.writeEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "alltags"))
+				hb.writeElementClose("option")
+				for _, tag := range ef.tags {
+					if tag == ef.filter.tag {
+						hb.writeElementOpen("option", "value", tag, "selected", "")
+					} else {
+						hb.writeElementOpen("option", "value", tag)
+					}
+					hb.writeEscaped(tag)
 					hb.writeElementClose("option")
 				}
 				hb.writeElementClose("select")
+			}
+			hb.writeElementOpen("label")
+			if ef.filter.missAlt {
+				hb.writeElementOpen("input", "type", "checkbox", "name", "missingalt", "checked", "")
+			} else {
+				hb.writeElementOpen("input", "type", "checkbox", "name", "missingalt")
+			}
+			hb.writeEscaped(" " + a.ts.GetTemplateStringVariant(rd.Blog.Lang, "missingalt"))
+			hb.writeElementClose("label")
+			hb.writeElementOpen("input", "type", "submit", "value", "🔍 "+a.ts.GetTemplateStringVariant(rd.Blog.Lang, "filter"))
+			hb.writeElementClose("form")
+			// Files
+			if len(ef.files) == 0 {
+				hb.writeElementOpen("p")
+				hb.writeEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "nofiles"))
+				hb.writeElementClose("p")
+			}
+			usesString := a.ts.GetTemplateStringVariant(rd.Blog.Lang, "fileuses")
+			for _, f := range ef.files {
+				hb.writeElementOpen("hr")
+				hb.writeElementOpen("div", "id", f.Name)
+				// Preview and info
+				if f.isImage() {
+					hb.writeElementOpen("a", "href", f.Location, "target", "_blank")
+					hb.writeElementOpen("img", "src", f.Location, "alt", f.Alt, "class", "mediathumb", "loading", "lazy")
+					hb.writeElementClose("a")
+				}
+				hb.writeElementOpen("p")
+				hb.writeElementOpen("b")
+				hb.writeEscaped(f.Name)
+				hb.writeElementClose("b")
+				hb.writeElementOpen("br")
+				info := []string{f.Time.Local().Format(isoDateFormat)}
+				if f.OriginalName != "" {
+					info = append(info, f.OriginalName)
+				}
+				if f.MimeType != "" {
+					info = append(info, f.MimeType)
+				}
+				if f.Width > 0 && f.Height > 0 {
+					info = append(info, fmt.Sprintf("%d×%d", f.Width, f.Height))
+				}
+				info = append(info, mBytesString(f.Size), fmt.Sprintf("~%d %s", f.Uses, usesString))
+				if f.Uploader != "" {
+					info = append(info, f.Uploader)
+				}
+				hb.writeEscaped(strings.Join(info, ", "))
+				if f.isImage() && f.Alt == "" {
+					hb.writeElementOpen("br")
+					hb.writeEscaped("⚠️ " + a.ts.GetTemplateStringVariant(rd.Blog.Lang, "missingalt"))
+				}
+				hb.writeElementClose("p")
+				// Markdown to copy
+				hb.writeElementOpen("input", "type", "text", "class", "fw", "readonly", "", "value", f.markdown())
+				// Metadata
+				hb.writeElementOpen("form", "method", "post", "class", "fw p")
+				hb.writeElementOpen("input", "type", "hidden", "name", "filename", "value", f.Name)
+				hb.writeElementOpen("input", "type", "text", "name", "alt", "value", f.Alt, "placeholder", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "alttext"))
+				hb.writeElementOpen("input", "type", "text", "name", "caption", "value", f.Caption, "placeholder", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "caption"))
+				hb.writeElementOpen("input", "type", "text", "name", "tags", "value", strings.Join(f.Tags, ", "), "placeholder", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "mediatags"))
+				hb.writeElementOpen(
+					"input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "save"),
+					"formaction", rd.Blog.getRelativePath("/editor/files/edit"),
+				)
 				// View button
 				hb.writeElementOpen(
 					"input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "view"),
@@ -1113,14 +1244,280 @@ func (a *goBlog) renderEditorFiles(hb *htmlBuilder, rd *renderData) {
 					"formaction", rd.Blog.getRelativePath("/editor/files/delete"),
 					"class", "confirm", "data-confirmmessage", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "confirmdelete"),
 				)
-				hb.writeElementOpen("script", "src", a.assetFileName("js/formconfirm.js"), "defer", "")
-				hb.writeElementClose("script")
 				hb.writeElementClose("form")
+				hb.writeElementClose("div")
+			}
+			hb.writeElementOpen("script", "src", a.assetFileName("js/formconfirm.js"), "defer", "")
+			hb.writeElementClose("script")
+			hb.writeElementClose("main")
+		},
+	)
+}
+
+func (a *goBlog) renderEditorTaxonomies(hb *htmlBuilder, rd *renderData) {
+	et, ok := rd.Data.(*editorTaxonomiesRenderData)
+	if !ok {
+		return
+	}
+	a.renderBase(
+		hb, rd,
+		func(hb *htmlBuilder) {
+			a.renderTitleTag(hb, rd.Blog, a.ts.GetTemplateStringVariant(rd.Blog.Lang, "taxonomyterms"))
+		},
+		func(hb *htmlBuilder) {
+			hb.writeElementOpen("main")
+			// Title
+			hb.writeElementOpen("h1")
+			hb.writeEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "taxonomyterms"))
+			hb.writeElementClose("h1")
+			for _, tax := range et.taxonomies {
+				hb.writeElementOpen("h2", "id", tax.taxonomy.Name)
+				hb.writeEscaped(defaultIfEmpty(tax.taxonomy.Title, tax.taxonomy.Name))
+				hb.writeElementClose("h2")
+				// Values to use as parent
+				datalist := "terms-" + tax.taxonomy.Name
+				hb.writeElementOpen("datalist", "id", datalist)
+				for _, t := range tax.terms {
+					hb.writeElementOpen("option", "value", t.Value)
+					hb.writeElementClose("option")
+				}
+				hb.writeElementClose("datalist")
+				// Form for each term, the first one creates a new term
+				for _, t := range append([]*taxonomyTerm{nil}, tax.terms...) {
+					hb.writeElementOpen("hr")
+					if t == nil {
+						hb.writeElementOpen("form", "method", "post", "class", "fw p", "action", rd.Blog.getRelativePath("/editor/taxonomies/save"))
+						hb.writeElementOpen("input", "type", "hidden", "name", "taxonomy", "value", tax.taxonomy.Name)
+						hb.writeElementOpen("input", "type", "text", "name", "value", "required", "", "placeholder", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "termvalue"))
+						t = &taxonomyTerm{}
+					} else {
+						hb.writeElementOpen("form", "method", "post", "class", "fw p", "id", tax.taxonomy.Name+"-"+urlize(t.Value))
+						hb.writeElementOpen("input", "type", "hidden", "name", "taxonomy", "value", tax.taxonomy.Name)
+						hb.writeElementOpen("input", "type", "hidden", "name", "value", "value", t.Value)
+						hb.writeElementOpen("p")
+						hb.writeElementOpen("a", "href", rd.Blog.getRelativePath(fmt.Sprintf("/%s/%s", tax.taxonomy.Name, urlize(t.Value))))
+						hb.writeEscaped(t.Value)
+						hb.writeElementClose("a")
+						hb.writeEscaped(fmt.Sprintf(" (%d)", tax.counts[t.Value]))
+						hb.writeElementClose("p")
+					}
+					hb.writeElementOpen("textarea", "name", "description", "placeholder", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "termdescription"))
+					hb.writeEscaped(t.Description)
+					hb.writeElementClose("textarea")
+					hb.writeElementOpen("input", "type", "text", "name", "image", "value", t.Image, "placeholder", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "termimage"))
+					hb.writeElementOpen("input", "type", "text", "name", "parent", "value", t.Parent, "list", datalist, "placeholder", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "parentterm"))
+					if t.Value == "" {
+						hb.writeElementOpen("input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "create"))
+					} else {
+						hb.writeElementOpen(
+							"input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "save"),
+							"formaction", rd.Blog.getRelativePath("/editor/taxonomies/save"),
+						)
+						hb.writeElementOpen(
+							"input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "delete"),
+							"formaction", rd.Blog.getRelativePath("/editor/taxonomies/delete"),
+							"class", "confirm", "data-confirmmessage", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "confirmdelete"),
+						)
+						// Change the value of the posts
+						if tax.counts[t.Value] > 0 {
+							hb.writeElementOpen("input", "type", "text", "name", "newvalue", "list", datalist, "placeholder", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "newvalue"))
+							hb.writeElementOpen("label")
+							hb.writeElementOpen("input", "type", "checkbox", "name", "hooks")
+							hb.writeEscaped(" " + a.ts.GetTemplateStringVariant(rd.Blog.Lang, "triggerhooks"))
+							hb.writeElementClose("label")
+							hb.writeElementOpen(
+								"input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "renamevalue"),
+								"formaction", rd.Blog.getRelativePath("/editor/taxonomies/rename"),
+							)
+							hb.writeElementOpen(
+								"input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "removevalue"),
+								"formaction", rd.Blog.getRelativePath("/editor/taxonomies/remove"),
+								"class", "confirm", "data-confirmmessage", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "confirmdelete"),
+							)
+						}
+					}
+					hb.writeElementClose("form")
+				}
+			}
+			hb.writeElementOpen("script", "src", a.assetFileName("js/formconfirm.js"), "defer", "")
+			hb.writeElementClose("script")
+			hb.writeElementClose("main")
+		},
+	)
+}
+
+func (a *goBlog) renderEditorPosts(hb *htmlBuilder, rd *renderData) {
+	ep, ok := rd.Data.(*editorPostsRenderData)
+	if !ok {
+		return
+	}
+	sections := lo.Keys(rd.Blog.Sections)
+	sort.Strings(sections)
+	// Helper to render a select with the first option for no selection
+	renderSelect := func(name, label, empty, selected string, options []string) {
+		hb.writeElementOpen("select", "name", name, "aria-label", label)
+		if empty != "" {
+			hb.writeElementOpen("option", "value", "")
+			hb.writeEscaped(empty)
+			hb.writeElementClose("option")
+		}
+		for _, o := range options {
+			if o == selected {
+				hb.writeElementOpen("option", "value", o, "selected", "")
 			} else {
+				hb.writeElementOpen("option", "value", o)
+			}
+			hb.writeEscaped(o)
+			hb.writeElementClose("option")
+		}
+		hb.writeElementClose("select")
+	}
+	taxonomies := lo.Map(rd.Blog.Taxonomies, func(t *configTaxonomy, _ int) string { return t.Name })
+	a.renderBase(
+		hb, rd,
+		func(hb *htmlBuilder) {
+			a.renderTitleTag(hb, rd.Blog, a.ts.GetTemplateStringVariant(rd.Blog.Lang, "postmanager"))
+		},
+		func(hb *htmlBuilder) {
+			hb.writeElementOpen("main")
+			// Title
+			hb.writeElementOpen("h1")
+			hb.writeEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "postmanager"))
+			hb.writeElementClose("h1")
+			// Filter
+			hb.writeElementOpen("form", "method", "get", "class", "fw p")
+			renderSelect(
+				"status", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "status"), a.ts.GetTemplateStringVariant(rd.Blog.Lang, "allstatuses"), ep.filter.status,
+				[]string{
+					string(statusPublished), string(statusDraft), string(statusUnlisted), string(statusPrivate), string(statusScheduled),
+					string(statusPublishedDeleted), string(statusDraftDeleted), string(statusUnlistedDeleted), string(statusPrivateDeleted), string(statusScheduledDeleted),
+				},
+			)
+			renderSelect("section", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "section"), a.ts.GetTemplateStringVariant(rd.Blog.Lang, "allsections"), ep.filter.section, sections)
+			if len(taxonomies) > 0 {
+				renderSelect("taxonomy", "taxonomy", "", ep.filter.taxonomy, taxonomies)
+				hb.writeElementOpen("input", "type", "text", "name", "value", "value", ep.filter.value, "placeholder", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "termvalue"))
+			}
+			hb.writeElementOpen("label")
+			hb.writeEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "publishedfrom") + " ")
+			hb.writeElementOpen("input", "type", "date", "name", "from", "value", ep.filter.from)
+			hb.writeElementClose("label")
+			hb.writeElementOpen("label")
+			hb.writeEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "publisheduntil") + " ")
+			hb.writeElementOpen("input", "type", "date", "name", "to", "value", ep.filter.to)
+			hb.writeElementClose("label")
+			hb.writeElementOpen("input", "type", "submit", "value", "🔍 "+a.ts.GetTemplateStringVariant(rd.Blog.Lang, "filter"))
+			hb.writeElementClose("form")
+			// Posts
+			if len(ep.posts) == 0 {
 				hb.writeElementOpen("p")
-				hb.writeEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "nofiles"))
+				hb.writeEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "noposts"))
+				hb.writeElementClose("p")
+			} else {
+				hb.writeElementOpen("form", "method", "post", "class", "fw p", "action", rd.Blog.getRelativePath("/editor/posts"))
+				hb.writeElementOpen("input", "type", "hidden", "name", "query", "value", ep.query)
+				for _, p := range ep.posts {
+					hb.writeElementOpen("p")
+					hb.writeElementOpen("label")
+					hb.writeElementOpen("input", "type", "checkbox", "name", "path", "value", p.Path)
+					hb.writeElementClose("label")
+					hb.write(" ")
+					hb.writeElementOpen("a", "href", p.Path, "target", "_blank")
+					hb.writeEscaped(a.postLinkText(p))
+					hb.writeElementClose("a")
+					details := []string{string(p.Status)}
+					if p.Section != "" {
+						details = append(details, p.Section)
+					}
+					if published := timeNoErr(dateparse.ParseLocal(p.Published)); !published.IsZero() {
+						details = append(details, published.Format(isoDateFormat))
+					}
+					hb.writeEscaped(" (" + strings.Join(details, ", ") + ")")
+					hb.writeElementClose("p")
+				}
+				// Action
+				hb.writeElementOpen("select", "name", "bulkaction", "aria-label", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "bulkaction"))
+				actions := []string{"status", "section", "addvalue", "removevalue", "reschedule", "delete", "undelete"}
+				if rd.Blog.postingQueueEnabled() {
+					actions = append(actions, "queue")
+				}
+				for _, action := range actions {
+					hb.writeElementOpen("option", "value", action)
+					hb.writeEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, lo.If(action == "delete" || action == "undelete", action).Else("bulk"+action)))
+					hb.writeElementClose("option")
+				}
+				hb.writeElementClose("select")
+				renderSelect("status", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "status"), "", "", lo.Map(editorPostsStatuses, func(s postStatus, _ int) string { return string(s) }))
+				renderSelect("section", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "section"), "", "", sections)
+				if len(taxonomies) > 0 {
+					renderSelect("taxonomy", "taxonomy", "", "", taxonomies)
+					hb.writeElementOpen("input", "type", "text", "name", "value", "placeholder", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "termvalue"))
+				}
+				hb.writeElementOpen("input", "type", "datetime-local", "name", "published", "aria-label", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "bulkreschedule"))
+				hb.writeElementOpen(
+					"input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "apply"),
+					"class", "confirm", "data-confirmmessage", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "bulkconfirm"),
+				)
+				hb.writeElementClose("form")
+			}
+			// Pagination
+			a.renderPagination(hb, rd.Blog, ep.hasPrev, ep.hasNext, ep.prev, ep.next)
+			hb.writeElementOpen("script", "src", a.assetFileName("js/formconfirm.js"), "defer", "")
+			hb.writeElementClose("script")
+			hb.writeElementClose("main")
+		},
+	)
+}
+
+func (a *goBlog) renderEditorQueue(hb *htmlBuilder, rd *renderData) {
+	eq, ok := rd.Data.(*editorQueueRenderData)
+	if !ok {
+		return
+	}
+	a.renderBase(
+		hb, rd,
+		func(hb *htmlBuilder) {
+			a.renderTitleTag(hb, rd.Blog, a.ts.GetTemplateStringVariant(rd.Blog.Lang, "postingqueue"))
+		},
+		func(hb *htmlBuilder) {
+			hb.writeElementOpen("main")
+			// Title
+			hb.writeElementOpen("h1")
+			hb.writeEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "postingqueue"))
+			hb.writeElementClose("h1")
+			hb.writeElementOpen("p")
+			hb.writeEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "postingqueuedesc"))
+			hb.writeElementClose("p")
+			if len(eq.posts) == 0 {
+				hb.writeElementOpen("p")
+				hb.writeEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "noposts"))
 				hb.writeElementClose("p")
 			}
+			for _, p := range eq.posts {
+				hb.writeElementOpen("form", "method", "post", "class", "actions", "action", rd.Blog.getRelativePath("/editor/queue/move"))
+				hb.writeElementOpen("input", "type", "hidden", "name", "path", "value", p.Path)
+				hb.writeElementOpen("p")
+				if published := timeNoErr(dateparse.ParseLocal(p.Published)); !published.IsZero() {
+					hb.writeElementOpen("b")
+					hb.writeEscaped(published.Format("Mon " + isoDateFormat + " 15:04"))
+					hb.writeElementClose("b")
+					hb.write(" ")
+				}
+				hb.writeElementOpen("a", "href", p.Path)
+				hb.writeEscaped(a.postLinkText(p))
+				hb.writeElementClose("a")
+				if p.Section != "" {
+					hb.writeEscaped(" (" + p.Section + ")")
+				}
+				hb.writeElementClose("p")
+				hb.writeElementOpen("button", "type", "submit", "name", "direction", "value", "up")
+				hb.writeEscaped("↑ " + a.ts.GetTemplateStringVariant(rd.Blog.Lang, "moveup"))
+				hb.writeElementClose("button")
+				hb.writeElementOpen("button", "type", "submit", "name", "direction", "value", "down")
+				hb.writeEscaped("↓ " + a.ts.GetTemplateStringVariant(rd.Blog.Lang, "movedown"))
+				hb.writeElementClose("button")
+				hb.writeElementClose("form")
+			}
 			hb.writeElementClose("main")
 		},
 	)
@@ -1334,6 +1731,7 @@ func (a *goBlog) renderWebmentionAdmin(hb *htmlBuilder, rd *renderData) {
 type editorRenderData struct {
 	updatePostUrl     string
 	updatePostContent string
+	media             []*mediaRecord
 }
 
 func (a *goBlog) renderEditor(hb *htmlBuilder, rd *renderData) {
@@ -1374,7 +1772,44 @@ func (a *goBlog) renderEditor(hb *htmlBuilder, rd *renderData) {
 			hb.writeElementClose("textarea")
 			hb.writeElementOpen("div", "id", "post-preview", "class", "hide")
 			hb.writeElementClose("div")
+			// Media picker
+			if len(edrd.media) > 0 {
+				hb.writeElementOpen("select", "id", "mediapicker", "data-target", "create-input", "aria-label", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "insertmedia"))
+				for _, m := range edrd.media {
+					hb.writeElementOpen("option", "value", m.markdown())
+					label := defaultIfEmpty(m.OriginalName, m.Name)
+					if m.Alt != "" {
+						label += ": " + m.Alt
+					} else if m.isImage() {
+						label += " ⚠️"
+					}
+					hb.writeEscaped(label)
+					hb.writeElementClose("option")
+				}
+				hb.writeElementClose("select")
+				hb.writeElementOpen("input", "id", "mediapickerbtn", "type", "button", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "insertmedia"))
+			}
+			if targets := a.micropubSyndicationTargets(rd.BlogString); len(targets) > 0 {
+				hb.writeElementOpen("p")
+				hb.writeEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "syndicateto"))
+				hb.writeEscaped(": ")
+				// Always send a selection, so unchecking all targets disables syndication
+				hb.writeElementOpen("input", "type", "hidden", "name", "mp-syndicate-to", "value", syndicationNone)
+				for _, target := range targets {
+					hb.writeElementOpen("label")
+					hb.writeElementOpen("input", "type", "checkbox", "name", "mp-syndicate-to", "value", target.UID, "checked", "")
+					hb.writeEscaped(" " + target.Name + " ")
+					hb.writeElementClose("label")
+				}
+				hb.writeElementClose("p")
+			}
 			hb.writeElementOpen("input", "type", "submit", "value", a.ts.GetTemplateStringVariant(rd.Blog.Lang, "create"))
+			if rd.Blog.postingQueueEnabled() {
+				// Schedule for the next free slot instead
+				hb.writeElementOpen("button", "type", "submit", "name", queueParam, "value", "true")
+				hb.writeEscaped(a.ts.GetTemplateStringVariant(rd.Blog.Lang, "addtoqueue"))
+				hb.writeElementClose("button")
+			}
 			hb.writeElementClose("form")
 
 			// Update
@@ -1422,6 +1857,14 @@ func (a *goBlog) renderEditor(hb *htmlBuilder, rd *renderData) {
 			postsListLink("/editor/scheduled", "scheduledposts")
 			// Deleted
 			postsListLink("/editor/deleted", "deletedposts")
+			// Posting queue
+			if rd.Blog.postingQueueEnabled() {
+				postsListLink("/editor/queue", "postingqueue")
+			}
+			// Post manager
+			postsListLink("/editor/posts", "postmanager")
+			// Taxonomy terms
+			postsListLink("/editor/taxonomies", "taxonomyterms")
 
 			// Upload
 			hb.writeElementOpen("h2")
@@ -1469,7 +1912,7 @@ func (a *goBlog) renderEditor(hb *htmlBuilder, rd *renderData) {
 			hb.writeElementClose("main")
 
 			// Scripts
-			for _, script := range []string{"js/mdpreview.js", "js/geohelper.js", "js/formcache.js"} {
+			for _, script := range []string{"js/mdpreview.js", "js/geohelper.js", "js/formcache.js", "js/mediapicker.js"} {
 				hb.writeElementOpen("script", "src", a.assetFileName(script), "defer", "")
 				hb.writeElementClose("script")
 			}
@@ -2,9 +2,12 @@ package main
 
 import (
 	"fmt"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/samber/lo"
 	"go.goblog.app/app/pkgs/bufferpool"
 )
 
@@ -45,6 +48,7 @@ func (a *goBlog) renderSummary(hb *htmlBuilder, bc *configBlog, p *post, typ sum
 	if typ == photoSummary && len(photos) > 0 {
 		for _, photo := range photos {
 			_ = a.renderMarkdownToWriter(hb, fmt.Sprintf("![](%s)", photo), false)
+			a.renderPhotoMetadata(hb, photo)
 		}
 	}
 	// Post meta
@@ -234,6 +238,25 @@ func (a *goBlog) renderPostMeta(hb *htmlBuilder, p *post, b *configBlog, typ str
 			hb.writeElementClose("a")
 			hb.writeElementClose("div")
 		}
+		// Syndication ("u-syndication")
+		if syndicationLinks := p.Parameters[syndicationParam]; len(syndicationLinks) > 0 {
+			hb.writeElementOpen("div")
+			hb.writeEscaped(a.ts.GetTemplateStringVariant(b.Lang, "alsoon"))
+			hb.writeEscaped(": ")
+			for i, link := range syndicationLinks {
+				if i > 0 {
+					hb.writeEscaped(", ")
+				}
+				hb.writeElementOpen("a", "class", "u-syndication", "rel", "syndication noopener", "target", "_blank", "href", link)
+				if u, err := url.Parse(link); err == nil && u.Hostname() != "" {
+					hb.writeEscaped(u.Hostname())
+				} else {
+					hb.writeEscaped(link)
+				}
+				hb.writeElementClose("a")
+			}
+			hb.writeElementClose("div")
+		}
 		// Status
 		if p.Status != statusPublished {
 			hb.writeElementOpen("div")
@@ -248,6 +271,28 @@ func (a *goBlog) renderPostMeta(hb *htmlBuilder, p *post, b *configBlog, typ str
 	}
 }
 
+// Camera and capture date of an uploaded photo
+func (a *goBlog) renderPhotoMetadata(hb *htmlBuilder, photo string) {
+	m := a.mediaExif(photo)
+	if m == nil {
+		return
+	}
+	var details []string
+	if camera := m.Camera(); camera != "" {
+		details = append(details, camera)
+	}
+	if !m.Time.IsZero() {
+		details = append(details, m.Time.Format(isoDateFormat))
+	}
+	if len(details) == 0 {
+		return
+	}
+	hb.writeElementOpen("p", "class", "photo-meta")
+	hb.writeEscaped("📷 ")
+	hb.writeEscaped(strings.Join(details, ", "))
+	hb.writeElementClose("p")
+}
+
 // warning for old posts
 func (a *goBlog) renderOldContentWarning(hb *htmlBuilder, p *post, b *configBlog) {
 	if b == nil || p == nil || !p.Old() {
@@ -274,7 +319,7 @@ func (a *goBlog) renderInteractions(hb *htmlBuilder, rd *renderData) {
 		}
 		hb.writeElementOpen("ul")
 		for _, mention := range m {
-			hb.writeElementOpen("li")
+			hb.writeElementOpen("li", "id", fmt.Sprintf("mention-%d", mention.ID))
 			hb.writeElementOpen("a", "href", mention.Url, "target", "_blank", "rel", "nofollow noopener noreferrer ugc")
 			hb.writeEscaped(defaultIfEmpty(mention.Author, mention.Url))
 			hb.writeElementClose("a")
@@ -486,8 +531,239 @@ func (a *goBlog) renderPostVideo(hb *htmlBuilder, p *post) {
 	if !p.hasVideoPlaylist() {
 		return
 	}
-	hb.writeElementOpen("div", "id", "video", "data-url", p.firstParameter(videoPlaylistParam))
+	hb.writeElementOpen("div", "id", "video", "data-url", p.firstParameter(videoPlaylistParam), "data-poster", p.firstParameter(videoPosterParam))
 	hb.writeElementClose("div")
 	hb.writeElementOpen("script", "defer", "", "src", a.assetFileName("js/video.js"))
 	hb.writeElementClose("script")
 }
+
+func (a *goBlog) renderSearchFacets(hb *htmlBuilder, bc *configBlog, sd *searchRenderData) {
+	if sd == nil || sd.facets == nil {
+		return
+	}
+	renderFacets := func(label, key string, facets []*searchFacet, active []string, title func(string) string) {
+		if len(facets) == 0 && len(active) == 0 {
+			return
+		}
+		hb.writeElementOpen("p", "class", "search-facets")
+		hb.writeElementOpen("strong")
+		hb.writeEscaped(label)
+		hb.writeElementClose("strong")
+		hb.writeEscaped(":")
+		for _, f := range facets {
+			hb.write(" ")
+			isActive := lo.ContainsBy(active, func(v string) bool { return strings.EqualFold(v, f.Value) })
+			if isActive {
+				hb.writeElementOpen("mark")
+			}
+			hb.writeElementOpen("a", "href", sd.path+sd.filters.toggledQuery(key, f.Value))
+			if isActive {
+				hb.writeEscaped("✕ ")
+			}
+			hb.writeEscaped(title(f.Value))
+			hb.writeElementClose("a")
+			if isActive {
+				hb.writeElementClose("mark")
+			}
+			hb.writeEscaped(fmt.Sprintf(" (%d)", f.Count))
+		}
+		hb.writeElementClose("p")
+	}
+	// Sections
+	var activeSections []string
+	if sd.filters.section != "" {
+		activeSections = []string{sd.filters.section}
+	}
+	renderFacets(a.ts.GetTemplateStringVariant(bc.Lang, "section"), "section", sd.facets.Sections, activeSections, func(s string) string {
+		if section, ok := bc.Sections[s]; ok && section.Title != "" {
+			return section.Title
+		}
+		return s
+	})
+	// Years
+	var activeYears []string
+	if sd.filters.year != 0 {
+		activeYears = []string{strconv.Itoa(sd.filters.year)}
+	}
+	renderFacets(a.ts.GetTemplateStringVariant(bc.Lang, "year"), "year", sd.facets.Years, activeYears, func(y string) string {
+		return y
+	})
+	// Taxonomies
+	for _, tax := range bc.Taxonomies {
+		renderFacets(defaultIfEmpty(tax.Title, tax.Name), tax.Name, sd.facets.Taxonomies[tax.Name], sd.filters.taxonomies[tax.Name], func(v string) string {
+			return v
+		})
+	}
+	hb.writeElementOpen("hr")
+}
+
+// Search result with highlighted title and snippet
+func (a *goBlog) renderSearchSummary(hb *htmlBuilder, bc *configBlog, p *post, snippet *searchSnippet) {
+	if bc == nil || p == nil {
+		return
+	}
+	if snippet == nil {
+		a.renderSummary(hb, bc, p, defaultSummary)
+		return
+	}
+	hb.writeElementOpen("article", "class", "h-entry border-bottom")
+	if p.RenderedTitle != "" {
+		hb.writeElementOpen("h2", "class", "p-name")
+		hb.writeElementOpen("a", "class", "u-url", "href", p.Path)
+		if snippet.title != "" && p.Title() == p.RenderedTitle {
+			// Only use highlighted title if it isn't rendered differently
+			hb.write(snippet.title)
+		} else {
+			hb.writeEscaped(p.RenderedTitle)
+		}
+		hb.writeElementClose("a")
+		hb.writeElementClose("h2")
+	}
+	a.renderPostMeta(hb, p, bc, "summary")
+	hb.writeElementOpen("p", "class", "p-summary")
+	if snippet.content != "" {
+		hb.write(snippet.content)
+	} else {
+		hb.writeEscaped(a.postSummary(p))
+	}
+	hb.writeElementClose("p")
+	hb.writeElementOpen("p")
+	hb.writeElementOpen("a", "class", "u-url", "href", p.Path)
+	hb.writeEscaped(a.ts.GetTemplateStringVariant(bc.Lang, "view"))
+	hb.writeElementClose("a")
+	hb.writeElementClose("p")
+	hb.writeElementClose("article")
+}
+
+// Comments and webmentions matching the search
+func (a *goBlog) renderSearchInteractions(hb *htmlBuilder, bc *configBlog, interactions []*searchInteraction) {
+	if len(interactions) == 0 {
+		return
+	}
+	hb.writeElementOpen("h2")
+	hb.writeEscaped(a.ts.GetTemplateStringVariant(bc.Lang, "interactions"))
+	hb.writeElementClose("h2")
+	hb.writeElementOpen("ul")
+	for _, i := range interactions {
+		hb.writeElementOpen("li")
+		hb.writeElementOpen("a", "href", i.postPath())
+		hb.writeEscaped(defaultIfEmpty(i.author, i.url))
+		hb.writeElementClose("a")
+		if i.snippet != "" {
+			hb.write(" ")
+			hb.writeElementOpen("i")
+			hb.write(i.snippet)
+			hb.writeElementClose("i")
+		}
+		hb.writeElementClose("li")
+	}
+	hb.writeElementClose("ul")
+}
+
+func (a *goBlog) renderRelatedPosts(hb *htmlBuilder, bc *configBlog, p *post) {
+	related := a.relatedPosts(p)
+	if len(related) == 0 {
+		return
+	}
+	hb.writeElementOpen("div", "class", "p related-posts")
+	hb.writeElementOpen("strong")
+	hb.writeEscaped(a.ts.GetTemplateStringVariant(bc.Lang, "relatedposts"))
+	hb.writeElementClose("strong")
+	hb.writeElementOpen("ul")
+	for _, rp := range related {
+		hb.writeElementOpen("li")
+		hb.writeElementOpen("a", "href", rp.Path)
+		hb.writeEscaped(a.postLinkText(rp))
+		hb.writeElementClose("a")
+		hb.writeElementClose("li")
+	}
+	hb.writeElementClose("ul")
+	hb.writeElementClose("div")
+}
+
+// "Part N of M" of the series
+func (a *goBlog) renderPostSeries(hb *htmlBuilder, bc *configBlog, series *postSeriesInfo) {
+	if series == nil {
+		return
+	}
+	hb.writeElementOpen("p", "class", "series")
+	hb.writeEscaped(fmt.Sprintf(a.ts.GetTemplateStringVariant(bc.Lang, "seriespart"), series.part, series.total))
+	hb.write(" ")
+	hb.writeElementOpen("a", "class", "p-series", "href", series.path)
+	hb.writeEscaped(series.name)
+	hb.writeElementClose("a")
+	hb.writeElementClose("p")
+}
+
+// Links to the previous and next part of the series
+func (a *goBlog) renderPostSeriesNavigation(hb *htmlBuilder, bc *configBlog, series *postSeriesInfo) {
+	if series == nil {
+		return
+	}
+	a.renderPrevNextPosts(hb, bc, "p series-navigation", series.prev, series.next, false)
+}
+
+// Links to the previous (older) and next (newer) post
+func (a *goBlog) renderPostNavigation(hb *htmlBuilder, bc *configBlog, prev, next *post) {
+	a.renderPrevNextPosts(hb, bc, "p post-navigation", prev, next, true)
+	if prev != nil || next != nil {
+		hb.writeElementOpen("script", "defer", "", "src", a.assetFileName("js/postnav.js"))
+		hb.writeElementClose("script")
+	}
+}
+
+func (a *goBlog) renderPrevNextPosts(hb *htmlBuilder, bc *configBlog, class string, prev, next *post, withRel bool) {
+	if prev == nil && next == nil {
+		return
+	}
+	hb.writeElementOpen("div", "class", class)
+	for _, link := range []struct {
+		p   *post
+		rel string
+	}{{prev, "prev"}, {next, "next"}} {
+		if link.p == nil {
+			continue
+		}
+		hb.writeElementOpen("p")
+		hb.writeEscaped(a.ts.GetTemplateStringVariant(bc.Lang, link.rel))
+		hb.writeEscaped(": ")
+		if withRel {
+			hb.writeElementOpen("a", "href", link.p.Path, "rel", link.rel)
+		} else {
+			hb.writeElementOpen("a", "href", link.p.Path)
+		}
+		hb.writeEscaped(a.postLinkText(link.p))
+		hb.writeElementClose("a")
+		hb.writeElementClose("p")
+	}
+	hb.writeElementClose("div")
+}
+
+// Image, parent and child terms of a taxonomy value
+func (a *goBlog) renderTaxonomyTerm(hb *htmlBuilder, bc *configBlog, td *taxonomyTermRenderData) {
+	if td.image != "" {
+		hb.writeElementOpen("p")
+		hb.writeElementOpen("img", "src", td.image, "alt", "", "loading", "lazy")
+		hb.writeElementClose("p")
+	}
+	termLinks := func(title string, links ...*taxonomyTermLink) {
+		hb.writeElementOpen("p")
+		hb.writeEscaped(a.ts.GetTemplateStringVariant(bc.Lang, title))
+		hb.writeEscaped(": ")
+		for i, link := range links {
+			if i > 0 {
+				hb.write(" &bull; ")
+			}
+			hb.writeElementOpen("a", "href", link.path)
+			hb.writeEscaped(link.value)
+			hb.writeElementClose("a")
+		}
+		hb.writeElementClose("p")
+	}
+	if td.parent != nil {
+		termLinks("parentterm", td.parent)
+	}
+	if len(td.children) > 0 {
+		termLinks("childterms", td.children...)
+	}
+}
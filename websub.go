@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/carlmjohnson/requests"
+	"github.com/samber/lo"
+)
+
+// Notify WebSub hubs about updated feeds
+// https://www.w3.org/TR/websub/
+
+func (a *goBlog) initWebSub() {
+	if !a.webSubEnabled() {
+		return
+	}
+	if a.webSubHubEnabled() {
+		a.initWebSubHub()
+	}
+	// Add hooks
+	hook := func(p *post) {
+		// Check if post is published
+		if !p.isPublishedSectionPost() {
+			return
+		}
+		a.webSubPublish(a.postWebSubTopics(p))
+	}
+	a.pPostHooks = append(a.pPostHooks, hook)
+	a.pUpdateHooks = append(a.pUpdateHooks, hook)
+}
+
+func (a *goBlog) webSubEnabled() bool {
+	// Check if private mode is enabled
+	if a.isPrivate() {
+		return false
+	}
+	return len(a.webSubHubs()) > 0
+}
+
+func (a *goBlog) webSubHubs() []string {
+	var hubs []string
+	if a.webSubHubEnabled() {
+		hubs = append(hubs, a.getFullAddress(webSubPath))
+	}
+	if wc := a.cfg.WebSub; wc != nil {
+		hubs = append(hubs, wc.Hubs...)
+	}
+	return hubs
+}
+
+// Paths of the index pages that contain the post: blog, section, taxonomy values (and their parent terms), series and custom indexes
+func (a *goBlog) postIndexPaths(p *post) []string {
+	bc := a.cfg.Blogs[p.Blog]
+	if bc == nil {
+		return nil
+	}
+	paths := []string{bc.getRelativePath("")}
+	if p.Section != "" {
+		paths = append(paths, bc.getRelativePath(p.Section))
+	}
+	for _, tax := range bc.Taxonomies {
+		values := append([]string{}, p.Parameters[tax.Name]...)
+		// Posts also belong to the parent terms
+		if terms, _ := a.db.getTaxonomyTerms(p.Blog, tax.Name); len(terms) > 0 {
+			for _, value := range p.Parameters[tax.Name] {
+				for _, ancestor := range taxonomyTermAncestors(terms, value) {
+					if !lo.ContainsBy(values, func(v string) bool { return strings.EqualFold(v, ancestor) }) {
+						values = append(values, ancestor)
+					}
+				}
+			}
+		}
+		for _, value := range values {
+			paths = append(paths, bc.getRelativePath(fmt.Sprintf("/%s/%s", tax.Name, urlize(value))))
+		}
+	}
+	if series := p.firstParameter(seriesParam); series != "" && bc.seriesEnabled() {
+		paths = append(paths, bc.getRelativePath(fmt.Sprintf("/%s/%s", seriesParam, urlize(series))))
+	}
+	return append(paths, a.postCustomIndexPaths(p)...)
+}
+
+// Full URLs of the index pages and their feeds that contain the post
+func (a *goBlog) postWebSubTopics(p *post) []string {
+	feedTypes := []feedType{rssFeed, atomFeed, jsonFeed}
+	if audio, _ := a.podcastAudio(p); audio != "" {
+		feedTypes = append(feedTypes, podcastFeed)
+	}
+	urls := []string{}
+	for _, path := range a.postIndexPaths(p) {
+		urls = append(urls, a.getFullAddress(path))
+		for _, f := range feedTypes {
+			urls = append(urls, a.getFullAddress(path+"."+string(f)))
+		}
+	}
+	return urls
+}
+
+func (a *goBlog) webSubPublish(topics []string) {
+	for _, hub := range a.webSubHubs() {
+		if a.webSubHubEnabled() && hub == a.getFullAddress(webSubPath) {
+			// Built-in hub
+			a.webSubDistribute(topics)
+			continue
+		}
+		// All topics in one request, hubs accept multiple hub.url values
+		err := requests.URL(hub).
+			Client(a.httpClient).
+			UserAgent(appUserAgent).
+			Method(http.MethodPost).
+			BodyForm(url.Values{"hub.mode": {"publish"}, "hub.url": topics}).
+			Fetch(context.Background())
+		if err != nil {
+			log.Println("Sending WebSub publish request failed:", err.Error())
+		}
+	}
+}
+
+// Advertise the hubs for the topic using HTTP Link headers
+func (a *goBlog) setWebSubLinkHeaders(w http.ResponseWriter, self string) {
+	if !a.webSubEnabled() {
+		return
+	}
+	for _, hub := range a.webSubHubs() {
+		w.Header().Add("Link", fmt.Sprintf("<%s>; rel=hub", hub))
+	}
+	w.Header().Add("Link", fmt.Sprintf("<%s>; rel=self", self))
+}
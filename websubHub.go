@@ -0,0 +1,320 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/carlmjohnson/requests"
+	"go.goblog.app/app/pkgs/bufferpool"
+)
+
+// Built-in WebSub hub for the feeds and index pages
+// https://www.w3.org/TR/websub/#hub
+
+const (
+	webSubPath = "/websub"
+
+	webSubVerifyQueue   = "websubverify"
+	webSubDeliveryQueue = "websub"
+
+	webSubDefaultLease = 10 * 24 * time.Hour
+	webSubMaxLease     = 30 * 24 * time.Hour
+	webSubMinLease     = time.Hour
+)
+
+func (a *goBlog) webSubHubEnabled() bool {
+	return !a.isPrivate() && a.cfg.WebSub != nil && a.cfg.WebSub.Hub
+}
+
+type webSubVerification struct {
+	Mode, Topic, Callback, Secret string
+	Lease                         time.Duration
+}
+
+type webSubDelivery struct {
+	Topic, Callback string
+	Try             int
+}
+
+func (a *goBlog) initWebSubHub() {
+	a.listenOnQueue(webSubVerifyQueue, 30*time.Second, func(qi *queueItem, dequeue func(), _ func(time.Duration)) {
+		var v webSubVerification
+		if err := gob.NewDecoder(bytes.NewReader(qi.content)).Decode(&v); err != nil {
+			log.Println("websub verify queue:", err.Error())
+			dequeue()
+			return
+		}
+		if err := a.webSubVerify(&v); err != nil {
+			log.Printf("Failed to verify WebSub %s of %s for %s: %v", v.Mode, v.Callback, v.Topic, err)
+		}
+		dequeue()
+	})
+	a.listenOnQueue(webSubDeliveryQueue, 30*time.Second, func(qi *queueItem, dequeue func(), reschedule func(time.Duration)) {
+		var d webSubDelivery
+		if err := gob.NewDecoder(bytes.NewReader(qi.content)).Decode(&d); err != nil {
+			log.Println("websub delivery queue:", err.Error())
+			dequeue()
+			return
+		}
+		if err := a.webSubDeliver(&d); err != nil {
+			if d.Try++; d.Try < 10 {
+				// Try it again
+				buf := bufferpool.Get()
+				_ = gob.NewEncoder(buf).Encode(&d)
+				qi.content = buf.Bytes()
+				reschedule(time.Duration(d.Try) * 10 * time.Minute)
+				bufferpool.Put(buf)
+				return
+			}
+			log.Printf("Failed to deliver WebSub content of %s to %s: %v", d.Topic, d.Callback, err)
+		}
+		dequeue()
+	})
+}
+
+// Handle subscription requests
+func (a *goBlog) serveWebSubHub(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		a.serveError(w, r, err.Error(), http.StatusBadRequest)
+		return
+	}
+	v := &webSubVerification{
+		Mode:     r.Form.Get("hub.mode"),
+		Topic:    r.Form.Get("hub.topic"),
+		Callback: r.Form.Get("hub.callback"),
+		Secret:   r.Form.Get("hub.secret"),
+		Lease:    webSubDefaultLease,
+	}
+	if v.Mode != "subscribe" && v.Mode != "unsubscribe" {
+		a.serveError(w, r, "unsupported hub.mode", http.StatusBadRequest)
+		return
+	}
+	if cu, err := url.Parse(v.Callback); err != nil || (cu.Scheme != "http" && cu.Scheme != "https") || cu.Host == "" {
+		a.serveError(w, r, "invalid hub.callback", http.StatusBadRequest)
+		return
+	}
+	if !a.isWebSubTopic(v.Topic) {
+		a.serveError(w, r, "invalid hub.topic", http.StatusBadRequest)
+		return
+	}
+	if len(v.Secret) > 200 {
+		a.serveError(w, r, "hub.secret too long", http.StatusBadRequest)
+		return
+	}
+	if lease, err := strconv.Atoi(r.Form.Get("hub.lease_seconds")); err == nil && lease > 0 {
+		v.Lease = time.Duration(lease) * time.Second
+		if v.Lease < webSubMinLease {
+			v.Lease = webSubMinLease
+		} else if v.Lease > webSubMaxLease {
+			v.Lease = webSubMaxLease
+		}
+	}
+	buf := bufferpool.Get()
+	defer bufferpool.Put(buf)
+	if err := gob.NewEncoder(buf).Encode(v); err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := a.enqueue(webSubVerifyQueue, buf.Bytes(), time.Now()); err != nil {
+		a.serveError(w, r, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Topics are pages of the blogs
+func (a *goBlog) isWebSubTopic(topic string) bool {
+	base := a.getFullAddress("/")
+	return topic == base || strings.HasPrefix(topic, base+"/")
+}
+
+// Verify the intent of the subscriber and save or delete the subscription
+func (a *goBlog) webSubVerify(v *webSubVerification) error {
+	if v.Mode == "subscribe" {
+		// Check if the topic exists
+		resp, err := a.fetchWebSubTopic(v.Topic)
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return a.webSubDeny(v, "topic not found")
+		}
+	}
+	challenge := randomString(32)
+	rb := requests.URL(v.Callback).
+		Client(a.httpClient).
+		UserAgent(appUserAgent).
+		Param("hub.mode", v.Mode).
+		Param("hub.topic", v.Topic).
+		Param("hub.challenge", challenge)
+	if v.Mode == "subscribe" {
+		rb.Param("hub.lease_seconds", strconv.Itoa(int(v.Lease.Seconds())))
+	}
+	var body string
+	if err := rb.ToString(&body).Fetch(context.Background()); err != nil {
+		return err
+	}
+	if strings.TrimSpace(body) != challenge {
+		return errors.New("subscriber didn't confirm")
+	}
+	if v.Mode == "unsubscribe" {
+		return a.db.deleteWebSubSubscription(v.Topic, v.Callback)
+	}
+	return a.db.saveWebSubSubscription(v.Topic, v.Callback, v.Secret, time.Now().Add(v.Lease))
+}
+
+func (a *goBlog) webSubDeny(v *webSubVerification, reason string) error {
+	_ = requests.URL(v.Callback).
+		Client(a.httpClient).
+		UserAgent(appUserAgent).
+		Param("hub.mode", "denied").
+		Param("hub.topic", v.Topic).
+		Param("hub.reason", reason).
+		Fetch(context.Background())
+	return errors.New(reason)
+}
+
+// Get the current content of the topic like a visitor would
+func (a *goBlog) fetchWebSubTopic(topic string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, topic, nil)
+	if err != nil {
+		return nil, err
+	}
+	return doHandlerRequest(req, a.getAppRouter())
+}
+
+// Queue content distribution to the subscribers of the topics
+func (a *goBlog) webSubDistribute(topics []string) {
+	for _, topic := range topics {
+		callbacks, err := a.db.getWebSubCallbacks(topic)
+		if err != nil {
+			log.Println("Failed to get WebSub subscriptions:", err.Error())
+			continue
+		}
+		for _, callback := range callbacks {
+			buf := bufferpool.Get()
+			err = gob.NewEncoder(buf).Encode(&webSubDelivery{Topic: topic, Callback: callback})
+			if err == nil {
+				err = a.enqueue(webSubDeliveryQueue, buf.Bytes(), time.Now())
+			}
+			bufferpool.Put(buf)
+			if err != nil {
+				log.Println("Failed to queue WebSub delivery:", err.Error())
+			}
+		}
+	}
+}
+
+// Send the current content of the topic to the subscriber
+func (a *goBlog) webSubDeliver(d *webSubDelivery) error {
+	secret, ok, err := a.db.getWebSubSecret(d.Topic, d.Callback)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		// Subscription expired or removed
+		return nil
+	}
+	topicResp, err := a.fetchWebSubTopic(d.Topic)
+	if err != nil {
+		return err
+	}
+	defer topicResp.Body.Close()
+	if topicResp.StatusCode != http.StatusOK {
+		return fmt.Errorf("topic returned status %d", topicResp.StatusCode)
+	}
+	content, err := io.ReadAll(topicResp.Body)
+	if err != nil {
+		return err
+	}
+	rb := requests.URL(d.Callback).
+		Client(a.httpClient).
+		UserAgent(appUserAgent).
+		Method(http.MethodPost).
+		ContentType(topicResp.Header.Get(contentType)).
+		Header("Link", fmt.Sprintf("<%s>; rel=hub", a.getFullAddress(webSubPath)), fmt.Sprintf("<%s>; rel=self", d.Topic)).
+		BodyBytes(content)
+	if secret != "" {
+		mac := hmac.New(sha256.New, []byte(secret))
+		_, _ = mac.Write(content)
+		rb.Header("X-Hub-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	if err = rb.Fetch(context.Background()); err != nil {
+		if requests.HasStatusErr(err, http.StatusGone) {
+			// Subscriber doesn't want any more updates
+			return a.db.deleteWebSubSubscription(d.Topic, d.Callback)
+		}
+		return err
+	}
+	return nil
+}
+
+func (db *database) saveWebSubSubscription(topic, callback, secret string, expires time.Time) error {
+	_, err := db.exec(
+		"insert or replace into websub_subscriptions (topic, callback, secret, expires) values (@topic, @callback, @secret, @expires)",
+		sql.Named("topic", topic), sql.Named("callback", callback), sql.Named("secret", secret),
+		sql.Named("expires", expires.UTC().Format(time.RFC3339)),
+	)
+	return err
+}
+
+func (db *database) deleteWebSubSubscription(topic, callback string) error {
+	_, err := db.exec(
+		"delete from websub_subscriptions where topic = @topic and callback = @callback",
+		sql.Named("topic", topic), sql.Named("callback", callback),
+	)
+	return err
+}
+
+// Callbacks of the active subscriptions of the topic, removes expired subscriptions
+func (db *database) getWebSubCallbacks(topic string) ([]string, error) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	if _, err := db.exec("delete from websub_subscriptions where expires <= @now", sql.Named("now", now)); err != nil {
+		return nil, err
+	}
+	rows, err := db.query("select callback from websub_subscriptions where topic = @topic", dbNoCache, sql.Named("topic", topic))
+	if err != nil {
+		return nil, err
+	}
+	var callbacks []string
+	var callback string
+	for rows.Next() {
+		if err = rows.Scan(&callback); err != nil {
+			return nil, err
+		}
+		callbacks = append(callbacks, callback)
+	}
+	return callbacks, nil
+}
+
+func (db *database) getWebSubSecret(topic, callback string) (secret string, ok bool, err error) {
+	row, err := db.queryRow(
+		"select secret from websub_subscriptions where topic = @topic and callback = @callback and expires > @now",
+		sql.Named("topic", topic), sql.Named("callback", callback), sql.Named("now", time.Now().UTC().Format(time.RFC3339)),
+	)
+	if err != nil {
+		return "", false, err
+	}
+	if err = row.Scan(&secret); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return secret, true, nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/carlmjohnson/requests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_webSubHub(t *testing.T) {
+	var mu sync.Mutex
+	var deliveries []*http.Request
+	var bodies [][]byte
+	subscriber := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			// Confirm intent
+			_, _ = io.WriteString(w, r.URL.Query().Get("hub.challenge"))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		deliveries = append(deliveries, r)
+		bodies = append(bodies, body)
+		mu.Unlock()
+	}))
+	defer subscriber.Close()
+
+	app := &goBlog{
+		cfg: createDefaultTestConfig(t),
+	}
+	_ = app.initConfig()
+	app.cfg.WebSub = &configWebSub{Hub: true}
+	_ = app.initDatabase(false)
+	defer app.db.close()
+	app.initComponents(false)
+	app.d = app.buildRouter()
+	handlerClient := newHandlerClient(app.d)
+
+	require.True(t, app.webSubHubEnabled())
+	assert.Equal(t, []string{"http://localhost:8080/websub"}, app.webSubHubs())
+
+	// Hub is advertised on index pages
+	var links []string
+	err := requests.URL("http://localhost:8080/posts").Client(handlerClient).
+		Handle(func(r *http.Response) error {
+			links = r.Header.Values("Link")
+			return r.Body.Close()
+		}).
+		Fetch(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, links, "<http://localhost:8080/websub>; rel=hub")
+	assert.Contains(t, links, "<http://localhost:8080/posts>; rel=self")
+
+	// Invalid topic
+	err = requests.URL("http://localhost:8080/websub").Client(handlerClient).
+		BodyForm(url.Values{
+			"hub.mode":     {"subscribe"},
+			"hub.topic":    {"https://example.com/feed"},
+			"hub.callback": {subscriber.URL},
+		}).
+		Fetch(context.Background())
+	assert.True(t, requests.HasStatusErr(err, http.StatusBadRequest))
+
+	// Subscribe
+	topic := "http://localhost:8080/posts.rss"
+	err = requests.URL("http://localhost:8080/websub").Client(handlerClient).
+		BodyForm(url.Values{
+			"hub.mode":     {"subscribe"},
+			"hub.topic":    {topic},
+			"hub.callback": {subscriber.URL},
+			"hub.secret":   {"secret"},
+		}).
+		CheckStatus(http.StatusAccepted).
+		Fetch(context.Background())
+	require.NoError(t, err)
+
+	qi, err := app.peekQueue(context.Background(), webSubVerifyQueue)
+	require.NoError(t, err)
+	require.NotNil(t, qi)
+	var v webSubVerification
+	require.NoError(t, gob.NewDecoder(bytes.NewReader(qi.content)).Decode(&v))
+	require.NoError(t, app.dequeue(qi))
+	require.NoError(t, app.webSubVerify(&v))
+
+	callbacks, err := app.db.getWebSubCallbacks(topic)
+	require.NoError(t, err)
+	assert.Equal(t, []string{subscriber.URL}, callbacks)
+
+	// Publishing a post queues the content distribution
+	require.NoError(t, app.createPost(&post{
+		Path:      "/test/websub",
+		Section:   "posts",
+		Status:    statusPublished,
+		Published: "2020-01-01T00:00:00Z",
+		Content:   "WebSub test",
+	}))
+	var d webSubDelivery
+	assert.Eventually(t, func() bool {
+		qi, err = app.peekQueue(context.Background(), webSubDeliveryQueue)
+		return err == nil && qi != nil
+	}, 5*time.Second, 50*time.Millisecond)
+	require.NotNil(t, qi)
+	require.NoError(t, gob.NewDecoder(bytes.NewReader(qi.content)).Decode(&d))
+	require.NoError(t, app.dequeue(qi))
+	assert.Equal(t, topic, d.Topic)
+	require.NoError(t, app.webSubDeliver(&d))
+
+	mu.Lock()
+	require.Len(t, deliveries, 1)
+	assert.Contains(t, string(bodies[0]), "WebSub test")
+	mac := hmac.New(sha256.New, []byte("secret"))
+	_, _ = mac.Write(bodies[0])
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), deliveries[0].Header.Get("X-Hub-Signature"))
+	assert.Contains(t, deliveries[0].Header.Values("Link"), "<"+topic+">; rel=self")
+	mu.Unlock()
+
+	// Unsubscribe
+	require.NoError(t, app.webSubVerify(&webSubVerification{Mode: "unsubscribe", Topic: topic, Callback: subscriber.URL}))
+	callbacks, err = app.db.getWebSubCallbacks(topic)
+	require.NoError(t, err)
+	assert.Empty(t, callbacks)
+}